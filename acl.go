@@ -0,0 +1,161 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"fmt"
+
+	"github.com/altoros/gosigma/data"
+)
+
+const (
+	// ACLPermissionList defines permission to see the resource in lists
+	ACLPermissionList = "LIST"
+	// ACLPermissionAttach defines permission to attach the resource to own servers
+	ACLPermissionAttach = "ATTACH"
+	// ACLPermissionEdit defines permission to change the resource
+	ACLPermissionEdit = "EDIT"
+	// ACLPermissionOpenVNC defines permission to open VNC tunnel to the server
+	ACLPermissionOpenVNC = "OPEN_VNC"
+	// ACLPermissionStart defines permission to start the server
+	ACLPermissionStart = "START"
+	// ACLPermissionStop defines permission to stop the server
+	ACLPermissionStop = "STOP"
+	// ACLPermissionClone defines permission to clone the resource
+	ACLPermissionClone = "CLONE"
+)
+
+// An ACLRule grants permission to user account, given by uuid
+type ACLRule struct {
+	Permission string
+	Grantee    string
+}
+
+func makeACLRules(rr []data.ACLRule) []ACLRule {
+	result := make([]ACLRule, 0, len(rr))
+	for _, r := range rr {
+		var grantee string
+		if r.User != nil {
+			grantee = r.User.UUID
+		}
+		result = append(result, ACLRule{Permission: r.Permission, Grantee: grantee})
+	}
+	return result
+}
+
+func makeACLRulesData(rr []ACLRule) []data.ACLRule {
+	result := make([]data.ACLRule, 0, len(rr))
+	for _, r := range rr {
+		result = append(result, data.ACLRule{
+			Permission: r.Permission,
+			User:       data.MakeUserResource(r.Grantee),
+		})
+	}
+	return result
+}
+
+// An ACL interface represents access control list in CloudSigma account, used
+// to share drives and servers with other accounts
+type ACL interface {
+	// CloudSigma resource
+	Resource
+
+	// Get meta-information value stored in the access control list
+	Get(key string) (v string, ok bool)
+
+	// Name of access control list
+	Name() string
+
+	// Owner of access control list, the account sharing resources
+	Owner() Resource
+
+	// Rules of access control list
+	Rules() []ACLRule
+
+	// Resources shared with the access control list
+	Resources() []Resource
+
+	// Refresh information about access control list
+	Refresh() error
+
+	// Update name and rules of access control list
+	Update(name string, rules []ACLRule) error
+
+	// Remove access control list
+	Remove() error
+}
+
+// An acl implements access control list in CloudSigma account
+type acl struct {
+	client *Client
+	obj    *data.ACL
+}
+
+var _ ACL = (*acl)(nil)
+
+// String method is used to print values passed as an operand to any format that
+// accepts a string or to an unformatted printer such as Print.
+func (a acl) String() string {
+	return fmt.Sprintf(`{UUID: %q, Name: %q, Rules: %v}`, a.UUID(), a.Name(), a.Rules())
+}
+
+// URI of access control list
+func (a acl) URI() string { return a.obj.URI }
+
+// UUID of access control list
+func (a acl) UUID() string { return a.obj.UUID }
+
+// Get meta-information value stored in the access control list
+func (a acl) Get(key string) (v string, ok bool) {
+	v, ok = a.obj.Meta[key]
+	return
+}
+
+// Name of access control list
+func (a acl) Name() string { return a.obj.Name }
+
+// Owner of access control list, the account sharing resources
+func (a acl) Owner() Resource {
+	if a.obj.Owner == nil {
+		return nil
+	}
+	return &resource{a.obj.Owner}
+}
+
+// Rules of access control list
+func (a acl) Rules() []ACLRule { return makeACLRules(a.obj.Rules) }
+
+// Resources shared with the access control list
+func (a acl) Resources() []Resource {
+	result := make([]Resource, 0, len(a.obj.Resources))
+	for i := range a.obj.Resources {
+		result = append(result, &resource{&a.obj.Resources[i]})
+	}
+	return result
+}
+
+// Refresh information about access control list
+func (a *acl) Refresh() error {
+	obj, err := a.client.getACL(a.UUID())
+	if err != nil {
+		return err
+	}
+	a.obj = obj
+	return nil
+}
+
+// Update name and rules of access control list
+func (a *acl) Update(name string, rules []ACLRule) error {
+	obj, err := a.client.updateACL(a.UUID(), name, rules)
+	if err != nil {
+		return err
+	}
+	a.obj = obj
+	return nil
+}
+
+// Remove access control list
+func (a *acl) Remove() error {
+	return a.client.RemoveACL(a.UUID())
+}
@@ -0,0 +1,185 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"testing"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/mock"
+)
+
+var testACLRules = []ACLRule{
+	{Permission: ACLPermissionList, Grantee: "f4c6b3a2-1f71-4d8d-9a61-0c4e4b3b8e10"},
+	{Permission: ACLPermissionClone, Grantee: "f4c6b3a2-1f71-4d8d-9a61-0c4e4b3b8e10"},
+}
+
+func TestClientACLs(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := cli.CreateACL("golden images", testACLRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.UUID() == "" || a.Name() != "golden images" {
+		t.Errorf("invalid ACL %v", a)
+	}
+	if rr := a.Rules(); len(rr) != 2 || rr[0] != testACLRules[0] || rr[1] != testACLRules[1] {
+		t.Errorf("ACL.Rules: %v, wants %v", rr, testACLRules)
+	}
+
+	aa, err := cli.ACLs(RequestDetail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aa) != 1 || aa[0].UUID() != a.UUID() {
+		t.Errorf("Client.ACLs: %v", aa)
+	}
+
+	if err := a.Update("list only", testACLRules[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err = cli.ACL(a.UUID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name() != "list only" || len(a.Rules()) != 1 {
+		t.Errorf("ACL is not updated: %v", a)
+	}
+
+	if err := a.Remove(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cli.ACL(a.UUID()); err == nil {
+		t.Error("removed ACL must not be found")
+	} else if e, ok := err.(*Error); !ok || e.StatusCode != 404 {
+		t.Errorf("invalid error %v", err)
+	}
+}
+
+func TestClientACLValidation(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(name string, rules []ACLRule) {
+		if _, err := cli.CreateACL(name, rules); err == nil {
+			t.Errorf("%q %v: CreateACL must fail", name, rules)
+		} else if e, ok := err.(*Error); !ok || e.StatusCode != 400 {
+			t.Errorf("%q %v: invalid error %v", name, rules, err)
+		}
+	}
+
+	check("", nil)
+	check("test", []ACLRule{{Permission: "READ", Grantee: "user"}})
+	check("test", []ACLRule{{Permission: ACLPermissionList}})
+}
+
+func TestClientACLShare(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := cli.CreateACL("golden images", testACLRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("golden"), Name: "golden", Size: 1073741824, Media: MediaDisk})
+
+	dd, err := cli.Drives(false, LibraryAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dd) != 1 {
+		t.Fatalf("invalid drives %v", dd)
+	}
+	d := dd[0]
+	if err := d.Share(a.UUID()); err != nil {
+		t.Fatal(err)
+	}
+	if rr := d.ACLs(); len(rr) != 1 || rr[0].UUID() != a.UUID() {
+		t.Errorf("Drive.ACLs: %v, wants %s", rr, a.UUID())
+	}
+	if d.Name() != "golden" || d.Size() != 1073741824 {
+		t.Errorf("drive properties must be kept: %v", d)
+	}
+
+	var c Components
+	c.SetName("test")
+	c.SetCPU(2000)
+	c.SetMem(2147483648)
+	c.SetVNCPassword("test")
+
+	s, err := cli.CreateServer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Share(a.UUID()); err != nil {
+		t.Fatal(err)
+	}
+	if rr := s.ACLs(); len(rr) != 1 || rr[0].UUID() != a.UUID() {
+		t.Errorf("Server.ACLs: %v, wants %s", rr, a.UUID())
+	}
+
+	if err := a.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if rr := a.Resources(); len(rr) != 2 || rr[0].UUID() != "golden" || rr[1].UUID() != s.UUID() {
+		t.Errorf("ACL.Resources: %v", rr)
+	}
+
+	if err := d.Unshare(a.UUID()); err != nil {
+		t.Fatal(err)
+	}
+	if rr := d.ACLs(); len(rr) != 0 {
+		t.Errorf("Drive.ACLs: %v, wants empty", rr)
+	}
+
+	if err := s.Unshare(a.UUID()); err != nil {
+		t.Fatal(err)
+	}
+	if rr := s.ACLs(); len(rr) != 0 {
+		t.Errorf("Server.ACLs: %v, wants empty", rr)
+	}
+	if err := s.Share(a.UUID()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Share("missing"); err == nil {
+		t.Error("Share with missing ACL must fail")
+	} else if e, ok := err.(*Error); !ok || e.StatusCode != 400 {
+		t.Errorf("invalid error %v", err)
+	}
+
+	if err := a.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if rr := s.ACLs(); len(rr) != 0 {
+		t.Errorf("removed ACL must be unshared: %v", rr)
+	}
+
+	if err := d.Share(""); err != errEmptyUUID {
+		t.Errorf("Share with empty uuid: %v", err)
+	}
+}
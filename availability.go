@@ -0,0 +1,79 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+// AvailabilityGroups holds groups of servers sharing physical host or drives
+// sharing storage system, the failure domains. Every group lists uuids of two
+// or more objects, objects not listed share failure domain with nothing.
+type AvailabilityGroups [][]string
+
+// Group returns uuids of objects sharing failure domain with given one
+func (g AvailabilityGroups) Group(uuid string) []string {
+	for _, group := range g {
+		if !containsString(group, uuid) {
+			continue
+		}
+		result := make([]string, 0, len(group)-1)
+		for _, v := range group {
+			if v != uuid {
+				result = append(result, v)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// Colocated returns subsets of given objects sharing failure domain, so the
+// set is not spread across failure domains if the result is not empty
+func (g AvailabilityGroups) Colocated(uuids ...string) [][]string {
+	var result [][]string
+	for _, group := range g {
+		var subset []string
+		for _, uuid := range uuids {
+			if containsString(group, uuid) && !containsString(subset, uuid) {
+				subset = append(subset, uuid)
+			}
+		}
+		if len(subset) > 1 {
+			result = append(result, subset)
+		}
+	}
+	return result
+}
+
+// Avoid returns avoid list to start server or clone drive with, placing the
+// object with given uuid apart from failure domains of its peers. The list
+// contains peers and objects sharing failure domain with them, except the
+// object itself. To spread a set of servers, start every server avoiding the
+// ones started before it:
+//
+//	for i, uuid := range uuids {
+//		err := cli.StartServer(uuid, groups.Avoid(uuid, uuids[:i]...))
+//		...
+//	}
+func (g AvailabilityGroups) Avoid(uuid string, peers ...string) []string {
+	var result []string
+	add := func(v string) {
+		if v != uuid && !containsString(result, v) {
+			result = append(result, v)
+		}
+	}
+	for _, peer := range peers {
+		add(peer)
+		for _, v := range g.Group(peer) {
+			add(v)
+		}
+	}
+	return result
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/mock"
+)
+
+func TestAvailabilityGroups(t *testing.T) {
+	g := AvailabilityGroups{{"a", "b", "c"}, {"d", "e"}}
+
+	if v := g.Group("b"); !reflect.DeepEqual(v, []string{"a", "c"}) {
+		t.Errorf("Group(b) = %v", v)
+	}
+	if v := g.Group("x"); v != nil {
+		t.Errorf("Group(x) = %v, wants nil", v)
+	}
+
+	if v := g.Colocated("a", "d", "x"); len(v) != 0 {
+		t.Errorf("Colocated of spread set = %v", v)
+	}
+	if v := g.Colocated("e", "a", "d", "c", "a"); !reflect.DeepEqual(v, [][]string{{"a", "c"}, {"e", "d"}}) {
+		t.Errorf("Colocated = %v", v)
+	}
+
+	if v := g.Avoid("a"); len(v) != 0 {
+		t.Errorf("Avoid without peers = %v", v)
+	}
+	if v := g.Avoid("a", "b", "x"); !reflect.DeepEqual(v, []string{"b", "c", "x"}) {
+		t.Errorf("Avoid = %v", v)
+	}
+	if v := g.Avoid("x", "d", "a", "e"); !reflect.DeepEqual(v, []string{"d", "e", "a", "b", "c"}) {
+		t.Errorf("Avoid = %v", v)
+	}
+}
+
+func TestClientServerAvailabilityGroups(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uuids := srv.AddServers([]data.Server{{}, {}, {}})
+	sort.Strings(uuids)
+
+	// pair started without avoid list lands on the same host
+	for _, uuid := range uuids[:2] {
+		if err := cli.StartServer(uuid, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups, err := cli.ServerAvailabilityGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := groups.Colocated(uuids...); len(v) != 1 || len(v[0]) != 2 {
+		t.Errorf("Colocated = %v", v)
+	}
+
+	group, err := cli.ServerAvailabilityGroup(uuids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(group, []string{uuids[1]}) {
+		t.Errorf("Client.ServerAvailabilityGroup: %v", group)
+	}
+
+	// restart the second server of the pair and the third one apart
+	srv.SetServerStatus(uuids[1], "stopped")
+	for i, uuid := range uuids[1:] {
+		if err := cli.StartServer(uuid, groups.Avoid(uuid, uuids[:i+1]...)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups, err = cli.ServerAvailabilityGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 0 {
+		t.Errorf("servers are not spread across hosts: %v", groups)
+	}
+
+	if _, err := cli.ServerAvailabilityGroup(""); err != errEmptyUUID {
+		t.Errorf("empty uuid, error %v", err)
+	}
+	if _, err := cli.ServerAvailabilityGroup("unknown"); err == nil {
+		t.Error("unknown server must fail")
+	}
+}
+
+func TestClientDriveAvailabilityGroups(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj := &data.Drive{Name: "data", Size: 1024, StorageType: "dssd"}
+	if err := srv.Drives.Add(obj); err != nil {
+		t.Fatal(err)
+	}
+
+	drv, err := cli.Drive(obj.UUID, LibraryAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := cli.DriveAvailabilityGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 0 {
+		t.Errorf("single drive groups: %v", groups)
+	}
+
+	replica, err := drv.Clone(CloneParams{Name: "replica"}, groups.Avoid("", drv.UUID()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	group, err := cli.DriveAvailabilityGroup(replica.UUID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(group) != 0 {
+		t.Errorf("replica shares storage with %v", group)
+	}
+
+	near, err := cli.CloneDrive(drv.UUID(), LibraryAccount, CloneParams{Name: "copy"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err = cli.DriveAvailabilityGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := groups.Colocated(drv.UUID(), replica.UUID(), near.UUID()); len(v) != 1 || len(v[0]) != 2 {
+		t.Errorf("Colocated = %v", v)
+	}
+}
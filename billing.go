@@ -0,0 +1,185 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+)
+
+const (
+	// BillingCPU defines billable resource of CPU, measured in MHz
+	BillingCPU = "cpu"
+	// BillingMem defines billable resource of RAM, measured in bytes
+	BillingMem = "mem"
+	// BillingDSSD defines billable resource of SSD storage, measured in bytes
+	BillingDSSD = "dssd"
+	// BillingIP defines billable resource of static IP addresses
+	BillingIP = "ip"
+	// BillingVLan defines billable resource of private networks
+	BillingVLan = "vlan"
+	// BillingTX defines billable resource of outgoing traffic, measured in bytes
+	BillingTX = "tx"
+)
+
+// ledgerTimeLayout defines format of time filters of ledger requests
+const ledgerTimeLayout = "2006-01-02T15:04:05"
+
+// An Amount holds exact decimal amount of money. Zero value is zero amount.
+type Amount struct {
+	r *big.Rat
+}
+
+// ParseAmount parses decimal amount of money, like "149.5600". Empty string is
+// parsed as zero amount.
+func ParseAmount(s string) (Amount, error) {
+	if s == "" {
+		return Amount{}, nil
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+	return Amount{r}, nil
+}
+
+// rat returns value of the amount, the result must not be modified
+func (a Amount) rat() *big.Rat {
+	if a.r == nil {
+		return new(big.Rat)
+	}
+	return a.r
+}
+
+// Rat returns exact value of the amount
+func (a Amount) Rat() *big.Rat {
+	return new(big.Rat).Set(a.rat())
+}
+
+// Cmp compares amounts and returns -1, 0 or +1 if a is less than, equal to
+// or greater than b
+func (a Amount) Cmp(b Amount) int {
+	return a.rat().Cmp(b.rat())
+}
+
+// Sign returns -1, 0 or +1 if the amount is negative, zero or positive
+func (a Amount) Sign() int {
+	return a.rat().Sign()
+}
+
+// Float64 returns the nearest float64 value of the amount, for display
+// purposes only
+func (a Amount) Float64() float64 {
+	f, _ := a.rat().Float64()
+	return f
+}
+
+// FloatString returns the amount in decimal form rounded to prec digits after
+// the point, halves are rounded away from zero
+func (a Amount) FloatString(prec int) string {
+	return a.rat().FloatString(prec)
+}
+
+// String returns the amount in decimal form without rounding and trailing
+// zeros. Amount without finite decimal form is written as a fraction.
+func (a Amount) String() string {
+	r := a.rat()
+	ten := big.NewInt(10)
+	d := new(big.Int).Set(r.Denom())
+	for prec := 0; prec <= 64; prec++ {
+		if d.Cmp(big.NewInt(1)) == 0 {
+			return r.FloatString(prec)
+		}
+		if new(big.Int).GCD(nil, nil, d, ten).Cmp(big.NewInt(1)) == 0 {
+			break
+		}
+		d.Quo(d, new(big.Int).GCD(nil, nil, d, ten))
+	}
+	return r.RatString()
+}
+
+// A Balance describes balance of the account
+type Balance struct {
+	Amount   Amount
+	Currency string
+}
+
+// A Usage describes usage of billable resource. Burst is the part of usage
+// not covered by subscriptions, it is charged by burst prices.
+type Usage struct {
+	Burst      uint64
+	Subscribed uint64
+	Using      uint64
+}
+
+// A CurrentUsage describes balance and usage of the account by billable
+// resource, see Billing* constants for resource names
+type CurrentUsage struct {
+	Balance Balance
+	Usage   map[string]Usage
+}
+
+// A LedgerEntry describes single record of account ledger. Negative amount
+// means charge, positive one means payment.
+type LedgerEntry struct {
+	ID           string
+	Time         time.Time
+	Start        time.Time
+	End          time.Time
+	Amount       Amount
+	BillingCycle int
+	Interval     int
+	Reason       string
+}
+
+func parseAmount(n json.Number) (Amount, error) {
+	return ParseAmount(string(n))
+}
+
+func makeBalance(obj data.Balance) (Balance, error) {
+	amount, err := parseAmount(obj.Balance)
+	if err != nil {
+		return Balance{}, err
+	}
+	return Balance{Amount: amount, Currency: obj.Currency}, nil
+}
+
+func makeCurrentUsage(obj *data.CurrentUsage) (CurrentUsage, error) {
+	balance, err := makeBalance(obj.Balance)
+	if err != nil {
+		return CurrentUsage{}, err
+	}
+
+	usage := make(map[string]Usage, len(obj.Usage))
+	for k, v := range obj.Usage {
+		usage[k] = Usage{Burst: v.Burst, Subscribed: v.Subscribed, Using: v.Using}
+	}
+
+	return CurrentUsage{Balance: balance, Usage: usage}, nil
+}
+
+func makeLedger(objs []data.LedgerEntry) ([]LedgerEntry, error) {
+	result := make([]LedgerEntry, 0, len(objs))
+	for _, e := range objs {
+		amount, err := parseAmount(e.Amount)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, LedgerEntry{
+			ID:           e.ID,
+			Time:         e.Time,
+			Start:        e.Start,
+			End:          e.End,
+			Amount:       amount,
+			BillingCycle: e.BillingCycle,
+			Interval:     e.Interval,
+			Reason:       e.Reason,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/mock"
+)
+
+func TestClientBalance(t *testing.T) {
+	srv := mock.New(mock.WithBalance(data.Balance{Balance: "149.5600", Currency: "EUR"}))
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := cli.Balance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Amount.String() != "149.56" || b.Currency != "EUR" {
+		t.Errorf("Client.Balance: %#v", b)
+	}
+
+	srv.SetBalance(data.Balance{Balance: "invalid", Currency: "EUR"})
+	if _, err := cli.Balance(); err == nil {
+		t.Error("invalid balance amount must fail")
+	}
+}
+
+func TestClientCurrentUsage(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.AddServer(&data.Server{Resource: *data.MakeServerResource("running"), CPU: 2000, Mem: 1073741824, Status: "running"})
+	srv.AddServer(&data.Server{Resource: *data.MakeServerResource("stopped"), CPU: 1000, Mem: 1073741824, Status: "stopped"})
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("drive"), Size: 10737418240})
+
+	u, err := cli.CurrentUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Balance.Currency != "USD" {
+		t.Errorf("CurrentUsage.Balance: %#v", u.Balance)
+	}
+	if v := u.Usage[BillingCPU]; v != (Usage{Burst: 2000, Using: 2000}) {
+		t.Errorf("cpu usage %#v", v)
+	}
+	if v := u.Usage[BillingMem]; v != (Usage{Burst: 1073741824, Using: 1073741824}) {
+		t.Errorf("mem usage %#v", v)
+	}
+	if v := u.Usage[BillingDSSD]; v != (Usage{Burst: 10737418240, Using: 10737418240}) {
+		t.Errorf("dssd usage %#v", v)
+	}
+}
+
+func TestClientLedger(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(hour int) time.Time { return time.Date(2014, 2, 10, hour, 0, 0, 0, time.UTC) }
+	srv.AddLedgerEntries([]data.LedgerEntry{
+		{Amount: "-0.5", Reason: "Burst: 1000 of cpu for 3600 seconds", Start: at(10), End: at(11), Time: at(11), Interval: 3600},
+		{Amount: "100", Reason: "Payment", Time: at(12)},
+		{Amount: "-0.25", Reason: "Burst: 1000 of cpu for 1800 seconds", Time: at(13)},
+	})
+
+	ee, err := cli.Ledger(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ee) != 3 {
+		t.Fatalf("Client.Ledger: %v", ee)
+	}
+	wants := LedgerEntry{ID: "1", Time: at(11), Start: at(10), End: at(11), Amount: Amount{big.NewRat(-1, 2)}, Interval: 3600, Reason: "Burst: 1000 of cpu for 3600 seconds"}
+	if e := ee[0]; e.ID != wants.ID || !e.Time.Equal(wants.Time) || !e.Start.Equal(wants.Start) || !e.End.Equal(wants.End) ||
+		e.Amount.Cmp(wants.Amount) != 0 || e.Interval != wants.Interval || e.Reason != wants.Reason {
+		t.Errorf("LedgerEntry: %#v, wants %#v", e, wants)
+	}
+
+	ee, err = cli.Ledger(at(11), at(13))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ee) != 1 || ee[0].Reason != "Payment" || ee[0].Amount.String() != "100" {
+		t.Errorf("Client.Ledger with interval: %v", ee)
+	}
+
+	ee, err = cli.Ledger(at(11).In(time.FixedZone("UTC+2", 2*60*60)), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ee) != 2 {
+		t.Errorf("Client.Ledger in other time zone: %v", ee)
+	}
+}
+
+func TestAmount(t *testing.T) {
+	for s, wants := range map[string]string{
+		"":                     "0",
+		"149.5600":             "149.56",
+		"-0.25":                "-0.25",
+		"0.1":                  "0.1",
+		"12345678901234.56789": "12345678901234.56789",
+		"1/3":                  "1/3",
+	} {
+		a, err := ParseAmount(s)
+		if err != nil {
+			t.Errorf("ParseAmount(%q): %v", s, err)
+			continue
+		}
+		if v := a.String(); v != wants {
+			t.Errorf("ParseAmount(%q) = %s, wants %s", s, v, wants)
+		}
+	}
+
+	if _, err := ParseAmount("invalid"); err == nil {
+		t.Error("invalid amount must fail")
+	}
+
+	a, _ := ParseAmount("0.1")
+	b, _ := ParseAmount("0.2")
+	sum := Amount{new(big.Rat).Add(a.Rat(), b.Rat())}
+	if c, _ := ParseAmount("0.3"); sum.Cmp(c) != 0 {
+		t.Errorf("0.1 + 0.2 = %s", sum)
+	}
+	if v := sum.FloatString(2); v != "0.30" {
+		t.Errorf("FloatString = %s", v)
+	}
+	if (Amount{}).Sign() != 0 || b.Sign() != 1 || b.Float64() != 0.2 {
+		t.Error("Amount of zero value check failed")
+	}
+}
@@ -4,11 +4,17 @@
 package gosigma
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
+	"io"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/altoros/gosigma/data"
 	"github.com/altoros/gosigma/https"
+	"github.com/altoros/gosigma/sshkey"
 )
 
 // A RequestSpec defines the type of client request
@@ -31,10 +37,14 @@ const (
 	LibraryMedia LibrarySpec = true
 )
 
-// A Client sends and receives requests to CloudSigma endpoint
+// A Client sends and receives requests to CloudSigma endpoint.
+// Client is safe for concurrent use by multiple goroutines. Its configuration
+// should be supplied with Option values at construction time.
 type Client struct {
 	endpoint         string
+	direct           string
 	https            *https.Client
+	mu               sync.RWMutex
 	logger           https.Logger
 	operationTimeout time.Duration
 }
@@ -42,10 +52,45 @@ type Client struct {
 var errEmptyUsername = errors.New("username is not allowed to be empty")
 var errEmptyPassword = errors.New("password is not allowed to be empty")
 var errEmptyUUID = errors.New("uuid is not allowed to be empty")
+var errEmptyPublicKey = errors.New("public key is not allowed to be empty")
+var errEmptyID = errors.New("id is not allowed to be empty")
+
+// New returns new CloudSigma client object configured with given options.
+// Username, password and region default to values of CLOUDSIGMA_USERNAME,
+// CLOUDSIGMA_PASSWORD and CLOUDSIGMA_REGION environment variables. Unlike NewClient,
+// the server certificate is verified unless TLS configuration says otherwise.
+func New(opts ...Option) (*Client, error) {
+	o := envOptions()
+	if err := o.apply(opts); err != nil {
+		return nil, err
+	}
+	return newRegionClient(o)
+}
+
+func newRegionClient(o options) (*Client, error) {
+	if o.regionOption && o.endpoint != "" {
+		return nil, errRegionEndpoint
+	}
+	if o.endpoint == "" {
+		r, err := LookupRegion(o.region)
+		if err != nil {
+			return nil, err
+		}
+		o.endpoint = r.Endpoint
+	}
 
-// NewClient returns new CloudSigma client object
+	if o.tlsConfig == nil {
+		o.tlsConfig = &tls.Config{}
+	}
+
+	return newClient(o)
+}
+
+// NewClient returns new CloudSigma client object. Unlike New, the server
+// certificate is not verified if tlsConfig is nil. WithRegion option is not
+// allowed, the region is selected with endpoint.
 func NewClient(endpoint string, username, password string,
-	tlsConfig *tls.Config) (*Client, error) {
+	tlsConfig *tls.Config, opts ...Option) (*Client, error) {
 
 	endpoint = ResolveEndpoint(endpoint)
 
@@ -57,50 +102,99 @@ func NewClient(endpoint string, username, password string,
 		return nil, errEmptyPassword
 	}
 
+	o := options{
+		endpoint:  endpoint,
+		username:  username,
+		password:  password,
+		tlsConfig: tlsConfig,
+	}
+	if err := o.apply(opts); err != nil {
+		return nil, err
+	}
+	if o.regionOption {
+		return nil, errRegionEndpoint
+	}
+
+	return newClient(o)
+}
+
+func newClient(o options) (*Client, error) {
+	if o.authenticator == nil {
+		if len(o.username) == 0 {
+			return nil, errEmptyUsername
+		}
+		if len(o.password) == 0 {
+			return nil, errEmptyPassword
+		}
+	}
+
 	client := &Client{
-		endpoint: endpoint,
-		https:    https.NewAuthClient(username, password, tlsConfig),
+		endpoint:         o.endpoint,
+		direct:           DirectEndpoint(o.endpoint),
+		https:            https.NewClient(o.tlsConfig, o.httpsOptions()...),
+		logger:           o.logger,
+		operationTimeout: o.operationTimeout,
 	}
 
 	return client, nil
 }
 
-// ConnectTimeout sets connection timeout
-func (c Client) ConnectTimeout(timeout time.Duration) {
+// ConnectTimeout sets connection timeout.
+//
+// Deprecated: use WithConnectTimeout option at construction time.
+func (c *Client) ConnectTimeout(timeout time.Duration) {
 	c.https.ConnectTimeout(timeout)
 }
 
 // GetConnectTimeout returns connection timeout for the object
-func (c Client) GetConnectTimeout() time.Duration {
+func (c *Client) GetConnectTimeout() time.Duration {
 	return c.https.GetConnectTimeout()
 }
 
-// ReadWriteTimeout sets read-write timeout
-func (c Client) ReadWriteTimeout(timeout time.Duration) {
+// ReadWriteTimeout sets read-write timeout.
+//
+// Deprecated: use WithReadWriteTimeout option at construction time.
+func (c *Client) ReadWriteTimeout(timeout time.Duration) {
 	c.https.ReadWriteTimeout(timeout)
 }
 
 // GetReadWriteTimeout returns connection timeout for the object
-func (c Client) GetReadWriteTimeout() time.Duration {
+func (c *Client) GetReadWriteTimeout() time.Duration {
 	return c.https.GetReadWriteTimeout()
 }
 
-// OperationTimeout sets timeout for cloud operations (like cloning, starting, stopping etc)
+// OperationTimeout sets timeout for cloud operations (like cloning, starting, stopping etc).
+//
+// Deprecated: use WithOperationTimeout option at construction time.
 func (c *Client) OperationTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.operationTimeout = timeout
 }
 
 // GetOperationTimeout gets timeout for cloud operations (like cloning, starting, stopping etc)
-func (c Client) GetOperationTimeout() time.Duration {
+func (c *Client) GetOperationTimeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.operationTimeout
 }
 
-// Logger sets logger for http traces
+// Logger sets logger for http traces.
+//
+// Deprecated: use WithLogger option at construction time.
 func (c *Client) Logger(logger https.Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.logger = logger
 	c.https.Logger(logger)
 }
 
+func (c *Client) getLogger() https.Logger {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.logger
+}
+
 // Servers in current account
 func (c *Client) Servers(rqspec RequestSpec) ([]Server, error) {
 	objs, err := c.getServers(rqspec)
@@ -175,21 +269,44 @@ func (c *Client) CreateServer(components Components) (Server, error) {
 }
 
 // StartServer by uuid of server instance.
-func (c Client) StartServer(uuid string, avoid []string) error {
+func (c *Client) StartServer(uuid string, avoid []string) error {
 	return c.startServer(uuid, avoid)
 }
 
 // StopServer by uuid of server instance
-func (c Client) StopServer(uuid string) error {
+func (c *Client) StopServer(uuid string) error {
 	return c.stopServer(uuid)
 }
 
+// ShutdownServer by uuid of server instance. Unlike StopServer, this sends ACPI
+// shutdown signal to the guest operating system.
+func (c *Client) ShutdownServer(uuid string) error {
+	return c.shutdownServer(uuid)
+}
+
+// RestartServer by uuid of server instance
+func (c *Client) RestartServer(uuid string) error {
+	return c.restartServer(uuid)
+}
+
 // RemoveServer by uuid of server instance with an option recursively removing attached drives.
 // See RecurseXXX constants in server.go file.
-func (c Client) RemoveServer(uuid, recurse string) error {
+func (c *Client) RemoveServer(uuid, recurse string) error {
 	return c.removeServer(uuid, recurse)
 }
 
+// ServerAvailabilityGroups returns groups of running servers sharing physical
+// host, see AvailabilityGroups.Avoid for spreading servers across hosts
+func (c *Client) ServerAvailabilityGroups() (AvailabilityGroups, error) {
+	return c.getAvailabilityGroups("servers")
+}
+
+// ServerAvailabilityGroup returns uuids of servers sharing physical host with
+// given server by uuid
+func (c *Client) ServerAvailabilityGroup(uuid string) ([]string, error) {
+	return c.getAvailabilityGroup("servers", uuid)
+}
+
 // Drives returns list of drives
 func (c *Client) Drives(rqspec RequestSpec, libspec LibrarySpec) ([]Drive, error) {
 	objs, err := c.getDrives(rqspec, libspec)
@@ -246,6 +363,46 @@ func (c *Client) RemoveDrive(uuid string, libspec LibrarySpec) error {
 	return c.removeDrive(uuid, libspec)
 }
 
+// UploadDrive uploads drive image of given size from the reader and returns
+// drive created from it. Image is sent in chunks to the direct endpoint of the
+// region, checksum of every chunk is verified and failed chunks are retried.
+// Interrupted upload is resumed by calling UploadDrive again with the same name
+// and size: chunks already received by the endpoint are not sent again. Empty
+// media leaves default media of uploaded drive, MediaDisk.
+func (c *Client) UploadDrive(ctx context.Context, name, media string, r io.Reader, size int64, opts ...TransferOption) (Drive, error) {
+	obj, err := c.uploadDrive(ctx, name, r, size, newTransfer(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	if media != "" && obj.Media != media {
+		obj.Media = media
+		if obj, err = c.updateDrive(*obj); err != nil {
+			return nil, err
+		}
+	}
+
+	drv := &drive{
+		client:  c,
+		obj:     obj,
+		library: LibraryAccount,
+	}
+
+	return drv, nil
+}
+
+// DriveAvailabilityGroups returns groups of drives sharing storage system, see
+// AvailabilityGroups.Avoid for spreading drives across storage systems
+func (c *Client) DriveAvailabilityGroups() (AvailabilityGroups, error) {
+	return c.getAvailabilityGroups("drives")
+}
+
+// DriveAvailabilityGroup returns uuids of drives sharing storage system with
+// given drive by uuid
+func (c *Client) DriveAvailabilityGroup(uuid string) ([]string, error) {
+	return c.getAvailabilityGroup("drives", uuid)
+}
+
 // Job returns job object by uuid
 func (c *Client) Job(uuid string) (Job, error) {
 	obj, err := c.getJob(uuid)
@@ -261,14 +418,416 @@ func (c *Client) Job(uuid string) (Job, error) {
 	return j, nil
 }
 
+// FirewallPolicies returns list of firewall policies in current account
+func (c *Client) FirewallPolicies(rqspec RequestSpec) ([]FirewallPolicy, error) {
+	objs, err := c.getFirewallPolicies(rqspec)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]FirewallPolicy, len(objs))
+	for i := 0; i < len(objs); i++ {
+		policies[i] = &firewallPolicy{
+			client: c,
+			obj:    &objs[i],
+		}
+	}
+
+	return policies, nil
+}
+
+// FirewallPolicy returns given firewall policy by uuid
+func (c *Client) FirewallPolicy(uuid string) (FirewallPolicy, error) {
+	obj, err := c.getFirewallPolicy(uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &firewallPolicy{
+		client: c,
+		obj:    obj,
+	}
+
+	return p, nil
+}
+
+// CreateFirewallPolicy creates firewall policy with given name and rules
+func (c *Client) CreateFirewallPolicy(name string, rules []FirewallRule) (FirewallPolicy, error) {
+	obj, err := c.createFirewallPolicy(name, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &firewallPolicy{
+		client: c,
+		obj:    obj,
+	}
+
+	return p, nil
+}
+
+// UpdateFirewallPolicy replaces name and rules of given firewall policy by uuid
+func (c *Client) UpdateFirewallPolicy(uuid, name string, rules []FirewallRule) (FirewallPolicy, error) {
+	obj, err := c.updateFirewallPolicy(uuid, name, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &firewallPolicy{
+		client: c,
+		obj:    obj,
+	}
+
+	return p, nil
+}
+
+// RemoveFirewallPolicy removes given firewall policy by uuid
+func (c *Client) RemoveFirewallPolicy(uuid string) error {
+	return c.removeFirewallPolicy(uuid)
+}
+
+// KeyPairs returns list of SSH key pairs in current account
+func (c *Client) KeyPairs(rqspec RequestSpec) ([]KeyPair, error) {
+	objs, err := c.getKeyPairs(rqspec)
+	if err != nil {
+		return nil, err
+	}
+
+	keypairs := make([]KeyPair, len(objs))
+	for i := 0; i < len(objs); i++ {
+		keypairs[i] = &keyPair{
+			client: c,
+			obj:    &objs[i],
+		}
+	}
+
+	return keypairs, nil
+}
+
+// KeyPair returns given SSH key pair by uuid
+func (c *Client) KeyPair(uuid string) (KeyPair, error) {
+	obj, err := c.getKeyPair(uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &keyPair{
+		client: c,
+		obj:    obj,
+	}
+
+	return k, nil
+}
+
+// CreateKeyPair creates SSH key pair with given name, generated by the endpoint.
+// Private key of the pair is available from the result only, it is not stored
+// in the account.
+func (c *Client) CreateKeyPair(name string) (KeyPair, error) {
+	obj, err := c.createKeyPair(data.KeyPair{Name: name})
+	if err != nil {
+		return nil, err
+	}
+
+	k := &keyPair{
+		client: c,
+		obj:    obj,
+	}
+
+	return k, nil
+}
+
+// ImportKeyPair creates SSH key pair with given name from public key in
+// OpenSSH authorized_keys format
+func (c *Client) ImportKeyPair(name, publicKey string) (KeyPair, error) {
+	publicKey = strings.TrimSpace(publicKey)
+	if publicKey == "" {
+		return nil, errEmptyPublicKey
+	}
+
+	obj, err := c.createKeyPair(data.KeyPair{Name: name, PublicKey: publicKey})
+	if err != nil {
+		return nil, err
+	}
+
+	k := &keyPair{
+		client: c,
+		obj:    obj,
+	}
+
+	return k, nil
+}
+
+// GenerateKeyPair generates SSH key pair locally and imports its public key
+// with given name. PEM encoded private key is returned to the caller only.
+func (c *Client) GenerateKeyPair(name string) (KeyPair, []byte, error) {
+	key, err := sshkey.Generate(0, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	k, err := c.ImportKeyPair(name, key.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return k, key.PrivateKey, nil
+}
+
+// RemoveKeyPair removes given SSH key pair by uuid
+func (c *Client) RemoveKeyPair(uuid string) error {
+	return c.removeKeyPair(uuid)
+}
+
+// ACLs returns list of access control lists in current account
+func (c *Client) ACLs(rqspec RequestSpec) ([]ACL, error) {
+	objs, err := c.getACLs(rqspec)
+	if err != nil {
+		return nil, err
+	}
+
+	acls := make([]ACL, len(objs))
+	for i := 0; i < len(objs); i++ {
+		acls[i] = &acl{
+			client: c,
+			obj:    &objs[i],
+		}
+	}
+
+	return acls, nil
+}
+
+// ACL returns given access control list by uuid
+func (c *Client) ACL(uuid string) (ACL, error) {
+	obj, err := c.getACL(uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &acl{
+		client: c,
+		obj:    obj,
+	}
+
+	return a, nil
+}
+
+// CreateACL creates access control list with given name and rules
+func (c *Client) CreateACL(name string, rules []ACLRule) (ACL, error) {
+	obj, err := c.createACL(name, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &acl{
+		client: c,
+		obj:    obj,
+	}
+
+	return a, nil
+}
+
+// UpdateACL replaces name and rules of given access control list by uuid
+func (c *Client) UpdateACL(uuid, name string, rules []ACLRule) (ACL, error) {
+	obj, err := c.updateACL(uuid, name, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &acl{
+		client: c,
+		obj:    obj,
+	}
+
+	return a, nil
+}
+
+// RemoveACL removes given access control list by uuid. Resources shared with
+// the access control list are no longer shared.
+func (c *Client) RemoveACL(uuid string) error {
+	return c.removeACL(uuid)
+}
+
+// RemoteSnapshots returns list of remote snapshots in current account
+func (c *Client) RemoteSnapshots(rqspec RequestSpec) ([]RemoteSnapshot, error) {
+	objs, err := c.getRemoteSnapshots(rqspec)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]RemoteSnapshot, len(objs))
+	for i := 0; i < len(objs); i++ {
+		snapshots[i] = &remoteSnapshot{
+			client: c,
+			obj:    &objs[i],
+		}
+	}
+
+	return snapshots, nil
+}
+
+// RemoteSnapshot returns given remote snapshot by uuid
+func (c *Client) RemoteSnapshot(uuid string) (RemoteSnapshot, error) {
+	obj, err := c.getRemoteSnapshot(uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &remoteSnapshot{
+		client: c,
+		obj:    obj,
+	}
+
+	return s, nil
+}
+
+// CreateRemoteSnapshot creates snapshot of given drive by uuid stored in the
+// location with given region code, see Regions. Empty name makes the server
+// use name of the drive. Snapshot is copied to the location asynchronously,
+// use RemoteSnapshot.Wait to wait for it becomes available.
+func (c *Client) CreateRemoteSnapshot(drive, location, name string) (RemoteSnapshot, error) {
+	obj, err := c.createRemoteSnapshot(drive, location, name)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &remoteSnapshot{
+		client: c,
+		obj:    obj,
+	}
+
+	return s, nil
+}
+
+// RemoveRemoteSnapshot removes given remote snapshot by uuid
+func (c *Client) RemoveRemoteSnapshot(uuid string) error {
+	return c.removeRemoteSnapshot(uuid)
+}
+
+// Balance returns balance of current account
+func (c *Client) Balance() (Balance, error) {
+	obj, err := c.getBalance()
+	if err != nil {
+		return Balance{}, err
+	}
+	return makeBalance(*obj)
+}
+
+// CurrentUsage returns balance and usage of current account by billable resource
+func (c *Client) CurrentUsage() (CurrentUsage, error) {
+	obj, err := c.getCurrentUsage()
+	if err != nil {
+		return CurrentUsage{}, err
+	}
+	return makeCurrentUsage(obj)
+}
+
+// Subscriptions returns list of subscriptions in current account
+func (c *Client) Subscriptions() ([]Subscription, error) {
+	objs, err := c.getSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]Subscription, len(objs))
+	for i := 0; i < len(objs); i++ {
+		subscriptions[i] = &subscription{
+			client: c,
+			obj:    &objs[i],
+		}
+	}
+
+	return subscriptions, nil
+}
+
+// Subscription returns given subscription by id
+func (c *Client) Subscription(id string) (Subscription, error) {
+	obj, err := c.getSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &subscription{
+		client: c,
+		obj:    obj,
+	}
+
+	return s, nil
+}
+
+// CreateSubscription subscribes to given amount of billable resource for the
+// period, starting now. See Billing* constants for resources and Period*
+// constants for periods.
+func (c *Client) CreateSubscription(resource string, amount uint64, period string, autoRenew bool) (Subscription, error) {
+	obj, err := c.createSubscription(resource, amount, period, autoRenew)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &subscription{
+		client: c,
+		obj:    obj,
+	}
+
+	return s, nil
+}
+
+// ExtendSubscription extends given subscription by id for the period
+func (c *Client) ExtendSubscription(id, period string) (Subscription, error) {
+	obj, err := c.extendSubscription(id, period)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &subscription{
+		client: c,
+		obj:    obj,
+	}
+
+	return s, nil
+}
+
+// SetSubscriptionAutoRenew enables or disables auto-renewal of given subscription by id
+func (c *Client) SetSubscriptionAutoRenew(id string, autoRenew bool) (Subscription, error) {
+	obj, err := c.setSubscriptionAutoRenew(id, autoRenew)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &subscription{
+		client: c,
+		obj:    obj,
+	}
+
+	return s, nil
+}
+
+// Pricing returns pricing catalogue of billable resources
+func (c *Client) Pricing() (Pricing, error) {
+	obj, err := c.getPricing()
+	if err != nil {
+		return Pricing{}, err
+	}
+	return makePricing(obj)
+}
+
+// Ledger returns records of current account ledger made in given time interval,
+// exclusive. Zero from or to time leaves the interval open.
+func (c *Client) Ledger(from, to time.Time) ([]LedgerEntry, error) {
+	objs, err := c.getLedger(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return makeLedger(objs)
+}
+
 // ReadContext reads and returns context of current server
-func (c Client) ReadContext() (Context, error) {
+func (c *Client) ReadContext() (Context, error) {
 	obj, err := c.readContext()
 	if err != nil {
 		return nil, err
 	}
 
-	ctx := context{obj: obj}
+	ctx := serverContext{obj: obj}
 
 	return ctx, nil
 }
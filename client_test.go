@@ -4,6 +4,8 @@
 package gosigma
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -40,6 +42,19 @@ func createTestClient(t *testing.T) (*Client, error) {
 	return cli, nil
 }
 
+func createIsolatedTestClient(t *testing.T, srv *mock.Server) (*Client, error) {
+	cli, err := NewClient(srv.Endpoint(""), srv.Username(), srv.Password(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if *trace {
+		cli.Logger(t)
+	}
+
+	return cli, nil
+}
+
 type testLog struct{ written int }
 
 func (l *testLog) Log(args ...interface{})                 { l.written++ }
@@ -92,6 +107,166 @@ func TestClientTimeouts(t *testing.T) {
 	}
 }
 
+func TestClientOptions(t *testing.T) {
+	var log testLog
+	cli, err := NewClient(mockEndpoint, mock.TestUser, mock.TestPassword, nil,
+		WithConnectTimeout(100*time.Millisecond),
+		WithReadWriteTimeout(200*time.Millisecond),
+		WithOperationTimeout(300*time.Millisecond),
+		WithLogger(&log))
+	if err != nil || cli == nil {
+		t.Error("NewClient() failed:", err, cli)
+		return
+	}
+
+	if v := cli.GetConnectTimeout(); v != 100*time.Millisecond {
+		t.Error("WithConnectTimeout check failed")
+	}
+	if v := cli.GetReadWriteTimeout(); v != 200*time.Millisecond {
+		t.Error("WithReadWriteTimeout check failed")
+	}
+	if v := cli.GetOperationTimeout(); v != 300*time.Millisecond {
+		t.Error("WithOperationTimeout check failed")
+	}
+
+	if _, err := cli.Servers(RequestShort); err != nil {
+		t.Error(err)
+	}
+	if log.written == 0 {
+		t.Error("no writes to log")
+	}
+}
+
+func TestClientConcurrent(t *testing.T) {
+	mock.ResetServers()
+	mock.ResetDrives()
+	defer mock.ResetServers()
+	defer mock.ResetDrives()
+
+	const count = 8
+
+	for i := 0; i < count; i++ {
+		ds := newDataServer()
+		ds.UUID = fmt.Sprintf("uuid-%d", i)
+		ds.Status = ServerStopped
+		mock.AddServer(ds)
+	}
+	mock.LibDrives.Add(newDataDrive("uuid"))
+
+	cli, err := createTestClient(t)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	run := func(f func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := f(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		uuid := fmt.Sprintf("uuid-%d", i)
+		timeout := time.Duration(i+1) * time.Second
+		run(func() error {
+			_, err := cli.Servers(RequestDetail)
+			return err
+		})
+		run(func() error {
+			s, err := cli.Server(uuid)
+			if err != nil {
+				return err
+			}
+			return s.StartWait()
+		})
+		run(func() error {
+			_, err := cli.Drives(RequestDetail, LibraryMedia)
+			return err
+		})
+		run(func() error {
+			d, err := cli.Drive("uuid", LibraryMedia)
+			if err != nil {
+				return err
+			}
+			_, err = d.CloneWait(CloneParams{Name: uuid}, nil)
+			return err
+		})
+		run(func() error {
+			cli.OperationTimeout(timeout)
+			cli.GetOperationTimeout()
+			cli.ReadWriteTimeout(timeout)
+			cli.GetReadWriteTimeout()
+			return nil
+		})
+	}
+
+	wg.Wait()
+
+	ss, err := cli.Servers(RequestDetail)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, s := range ss {
+		if s.Status() != ServerRunning {
+			t.Errorf("server %s status %q, wants running", s.UUID(), s.Status())
+		}
+	}
+
+	dd, err := cli.Drives(RequestShort, LibraryAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(dd) != count {
+		t.Errorf("cloned drives count %d, wants %d", len(dd), count)
+	}
+}
+
+func TestClientIsolatedMock(t *testing.T) {
+
+	t.Parallel()
+
+	srv := mock.New()
+	defer srv.Close()
+
+	ds := newDataServer()
+	ds.Status = ServerStopped
+	srv.AddServer(ds)
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	s, err := cli.Server("uuid")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := s.StartWait(); err != nil {
+		t.Error(err)
+	}
+
+	srv.Reset()
+
+	ss, err := cli.Servers(RequestShort)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(ss) != 0 {
+		t.Errorf("servers must be empty after reset: %v", ss)
+	}
+}
+
 func TestClientEmptyUUID(t *testing.T) {
 	cli, err := createTestClient(t)
 	if err != nil || cli == nil {
@@ -108,6 +283,12 @@ func TestClientEmptyUUID(t *testing.T) {
 	if err := cli.StopServer(""); err != errEmptyUUID {
 		t.Error("StopServer('') must fail with errEmptyUUID")
 	}
+	if err := cli.ShutdownServer(""); err != errEmptyUUID {
+		t.Error("ShutdownServer('') must fail with errEmptyUUID")
+	}
+	if err := cli.RestartServer(""); err != errEmptyUUID {
+		t.Error("RestartServer('') must fail with errEmptyUUID")
+	}
 	if err := cli.RemoveServer("", RecurseAllDrives); err != errEmptyUUID {
 		t.Error("RemoveServer('') must fail with errEmptyUUID")
 	}
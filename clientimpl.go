@@ -6,18 +6,20 @@ package gosigma
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/altoros/gosigma/data"
 )
 
-func (c Client) getServers(rqspec RequestSpec) ([]data.Server, error) {
+func (c *Client) getServers(rqspec RequestSpec) ([]data.Server, error) {
 	u := c.endpoint + "servers"
 	if rqspec == RequestDetail {
 		u += "/detail"
@@ -36,7 +38,7 @@ func (c Client) getServers(rqspec RequestSpec) ([]data.Server, error) {
 	return data.ReadServers(r.Body)
 }
 
-func (c Client) getServer(uuid string) (*data.Server, error) {
+func (c *Client) getServer(uuid string) (*data.Server, error) {
 	uuid = strings.TrimSpace(uuid)
 	if uuid == "" {
 		return nil, errEmptyUUID
@@ -57,35 +59,27 @@ func (c Client) getServer(uuid string) (*data.Server, error) {
 	return data.ReadServer(r.Body)
 }
 
-func (c Client) startServer(uuid string, avoid []string) error {
-	uuid = strings.TrimSpace(uuid)
-	if uuid == "" {
-		return errEmptyUUID
-	}
-
-	u := c.endpoint + "servers/" + uuid + "/action/"
-
-	var qq = make(url.Values)
-	qq["do"] = []string{"start"}
-
+func (c *Client) startServer(uuid string, avoid []string) error {
+	var qq url.Values
 	if len(avoid) > 0 {
-		qq["avoid"] = []string{strings.Join(avoid, ",")}
+		qq = url.Values{"avoid": {strings.Join(avoid, ",")}}
 	}
+	return c.serverAction(uuid, "start", qq)
+}
 
-	r, err := c.https.Post(u, qq, nil)
-	if err != nil {
-		return err
-	}
-	defer r.Body.Close()
+func (c *Client) stopServer(uuid string) error {
+	return c.serverAction(uuid, "stop", nil)
+}
 
-	if err := r.VerifyJSON(202); err != nil {
-		return NewError(r, err)
-	}
+func (c *Client) shutdownServer(uuid string) error {
+	return c.serverAction(uuid, "shutdown", nil)
+}
 
-	return nil
+func (c *Client) restartServer(uuid string) error {
+	return c.serverAction(uuid, "restart", nil)
 }
 
-func (c Client) stopServer(uuid string) error {
+func (c *Client) serverAction(uuid, action string, qq url.Values) error {
 	uuid = strings.TrimSpace(uuid)
 	if uuid == "" {
 		return errEmptyUUID
@@ -93,8 +87,10 @@ func (c Client) stopServer(uuid string) error {
 
 	u := c.endpoint + "servers/" + uuid + "/action/"
 
-	var qq = make(url.Values)
-	qq["do"] = []string{"stop"}
+	if qq == nil {
+		qq = make(url.Values)
+	}
+	qq["do"] = []string{action}
 
 	r, err := c.https.Post(u, qq, nil)
 	if err != nil {
@@ -109,7 +105,7 @@ func (c Client) stopServer(uuid string) error {
 	return nil
 }
 
-func (c Client) removeServer(uuid, recurse string) error {
+func (c *Client) removeServer(uuid, recurse string) error {
 	uuid = strings.TrimSpace(uuid)
 	if uuid == "" {
 		return errEmptyUUID
@@ -137,7 +133,7 @@ func (c Client) removeServer(uuid, recurse string) error {
 	return nil
 }
 
-func (c Client) createServer(components Components) ([]data.Server, error) {
+func (c *Client) createServer(components Components) ([]data.Server, error) {
 	// serialize
 	rr, err := components.marshal()
 	if err != nil {
@@ -159,7 +155,7 @@ func (c Client) createServer(components Components) ([]data.Server, error) {
 	return data.ReadServers(r.Body)
 }
 
-func (c Client) getDrives(rqspec RequestSpec, libspec LibrarySpec) ([]data.Drive, error) {
+func (c *Client) getDrives(rqspec RequestSpec, libspec LibrarySpec) ([]data.Drive, error) {
 	u := c.endpoint
 	if libspec == LibraryMedia {
 		u += "libdrives"
@@ -183,7 +179,7 @@ func (c Client) getDrives(rqspec RequestSpec, libspec LibrarySpec) ([]data.Drive
 	return data.ReadDrives(r.Body)
 }
 
-func (c Client) getDrive(uuid string, libspec LibrarySpec) (*data.Drive, error) {
+func (c *Client) getDrive(uuid string, libspec LibrarySpec) (*data.Drive, error) {
 	uuid = strings.TrimSpace(uuid)
 	if uuid == "" {
 		return nil, errEmptyUUID
@@ -210,7 +206,7 @@ func (c Client) getDrive(uuid string, libspec LibrarySpec) (*data.Drive, error)
 	return data.ReadDrive(r.Body)
 }
 
-func (c Client) cloneDrive(uuid string, libspec LibrarySpec, params CloneParams, avoid []string) (*data.Drive, error) {
+func (c *Client) cloneDrive(uuid string, libspec LibrarySpec, params CloneParams, avoid []string) (*data.Drive, error) {
 	uuid = strings.TrimSpace(uuid)
 	if uuid == "" {
 		return nil, errEmptyUUID
@@ -292,7 +288,44 @@ func (c *Client) removeDrive(uuid string, libspec LibrarySpec) error {
 	return nil
 }
 
-func (c Client) getJob(uuid string) (*data.Job, error) {
+func (c *Client) getAvailabilityGroups(section string) (AvailabilityGroups, error) {
+	u := c.endpoint + section + "/availability_groups/"
+
+	r, err := c.https.Get(u, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadAvailabilityGroups(r.Body)
+}
+
+func (c *Client) getAvailabilityGroup(section, uuid string) ([]string, error) {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return nil, errEmptyUUID
+	}
+
+	u := c.endpoint + section + "/availability_groups/" + uuid + "/"
+
+	r, err := c.https.Get(u, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadAvailabilityGroup(r.Body)
+}
+
+func (c *Client) getJob(uuid string) (*data.Job, error) {
 	uuid = strings.TrimSpace(uuid)
 	if uuid == "" {
 		return nil, errEmptyUUID
@@ -313,7 +346,777 @@ func (c Client) getJob(uuid string) (*data.Job, error) {
 	return data.ReadJob(r.Body)
 }
 
-func (c Client) readContext() (*data.Context, error) {
+func (c *Client) getFirewallPolicies(rqspec RequestSpec) ([]data.FirewallPolicy, error) {
+	u := c.endpoint + "fwpolicies"
+	if rqspec == RequestDetail {
+		u += "/detail"
+	}
+
+	r, err := c.https.Get(u, url.Values{"limit": {"0"}})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadFirewallPolicies(r.Body)
+}
+
+func (c *Client) getFirewallPolicy(uuid string) (*data.FirewallPolicy, error) {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return nil, errEmptyUUID
+	}
+
+	u := c.endpoint + "fwpolicies/" + uuid + "/"
+
+	r, err := c.https.Get(u, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadFirewallPolicy(r.Body)
+}
+
+func (c *Client) createFirewallPolicy(name string, rules []FirewallRule) (*data.FirewallPolicy, error) {
+	obj := data.FirewallPolicies{
+		Objects: []data.FirewallPolicy{{
+			Name:  strings.TrimSpace(name),
+			Rules: makeFirewallRulesData(rules),
+		}},
+	}
+
+	bb, err := json.Marshal(&obj)
+	if err != nil {
+		return nil, err
+	}
+
+	u := c.endpoint + "fwpolicies/"
+	r, err := c.https.Post(u, nil, bytes.NewReader(bb))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(201); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	objs, err := data.ReadFirewallPolicies(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objs) == 0 {
+		return nil, errors.New("no object was returned from server")
+	}
+
+	return &objs[0], nil
+}
+
+func (c *Client) updateFirewallPolicy(uuid, name string, rules []FirewallRule) (*data.FirewallPolicy, error) {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return nil, errEmptyUUID
+	}
+
+	rr, err := data.WriteFirewallPolicy(&data.FirewallPolicy{
+		Name:  strings.TrimSpace(name),
+		Rules: makeFirewallRulesData(rules),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u := c.endpoint + "fwpolicies/" + uuid + "/"
+	r, err := c.https.Put(u, nil, rr)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadFirewallPolicy(r.Body)
+}
+
+func (c *Client) removeFirewallPolicy(uuid string) error {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return errEmptyUUID
+	}
+
+	u := c.endpoint + "fwpolicies/" + uuid + "/"
+
+	r, err := c.https.Delete(u, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyCode(204); err != nil {
+		return NewError(r, err)
+	}
+
+	return nil
+}
+
+func (c *Client) getKeyPairs(rqspec RequestSpec) ([]data.KeyPair, error) {
+	u := c.endpoint + "keypairs"
+	if rqspec == RequestDetail {
+		u += "/detail"
+	}
+
+	r, err := c.https.Get(u, url.Values{"limit": {"0"}})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadKeyPairs(r.Body)
+}
+
+func (c *Client) getKeyPair(uuid string) (*data.KeyPair, error) {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return nil, errEmptyUUID
+	}
+
+	u := c.endpoint + "keypairs/" + uuid + "/"
+
+	r, err := c.https.Get(u, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadKeyPair(r.Body)
+}
+
+func (c *Client) createKeyPair(obj data.KeyPair) (*data.KeyPair, error) {
+	obj.Name = strings.TrimSpace(obj.Name)
+
+	rr, err := data.WriteKeyPairs([]data.KeyPair{obj})
+	if err != nil {
+		return nil, err
+	}
+
+	u := c.endpoint + "keypairs/"
+	r, err := c.https.Post(u, nil, rr)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(201); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	objs, err := data.ReadKeyPairs(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objs) == 0 {
+		return nil, errors.New("no object was returned from server")
+	}
+
+	return &objs[0], nil
+}
+
+func (c *Client) removeKeyPair(uuid string) error {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return errEmptyUUID
+	}
+
+	u := c.endpoint + "keypairs/" + uuid + "/"
+
+	r, err := c.https.Delete(u, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyCode(204); err != nil {
+		return NewError(r, err)
+	}
+
+	return nil
+}
+
+func (c *Client) getACLs(rqspec RequestSpec) ([]data.ACL, error) {
+	u := c.endpoint + "acls"
+	if rqspec == RequestDetail {
+		u += "/detail"
+	}
+
+	r, err := c.https.Get(u, url.Values{"limit": {"0"}})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadACLs(r.Body)
+}
+
+func (c *Client) getACL(uuid string) (*data.ACL, error) {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return nil, errEmptyUUID
+	}
+
+	u := c.endpoint + "acls/" + uuid + "/"
+
+	r, err := c.https.Get(u, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadACL(r.Body)
+}
+
+func (c *Client) createACL(name string, rules []ACLRule) (*data.ACL, error) {
+	rr, err := data.WriteACLs([]data.ACL{{
+		Name:  strings.TrimSpace(name),
+		Rules: makeACLRulesData(rules),
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	u := c.endpoint + "acls/"
+	r, err := c.https.Post(u, nil, rr)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(201); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	objs, err := data.ReadACLs(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objs) == 0 {
+		return nil, errors.New("no object was returned from server")
+	}
+
+	return &objs[0], nil
+}
+
+func (c *Client) updateACL(uuid, name string, rules []ACLRule) (*data.ACL, error) {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return nil, errEmptyUUID
+	}
+
+	rr, err := data.WriteACL(&data.ACL{
+		Name:  strings.TrimSpace(name),
+		Rules: makeACLRulesData(rules),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u := c.endpoint + "acls/" + uuid + "/"
+	r, err := c.https.Put(u, nil, rr)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadACL(r.Body)
+}
+
+func (c *Client) removeACL(uuid string) error {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return errEmptyUUID
+	}
+
+	u := c.endpoint + "acls/" + uuid + "/"
+
+	r, err := c.https.Delete(u, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyCode(204); err != nil {
+		return NewError(r, err)
+	}
+
+	return nil
+}
+
+func (c *Client) getRemoteSnapshots(rqspec RequestSpec) ([]data.RemoteSnapshot, error) {
+	u := c.endpoint + "remotesnapshots"
+	if rqspec == RequestDetail {
+		u += "/detail"
+	}
+
+	r, err := c.https.Get(u, url.Values{"limit": {"0"}})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadRemoteSnapshots(r.Body)
+}
+
+func (c *Client) getRemoteSnapshot(uuid string) (*data.RemoteSnapshot, error) {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return nil, errEmptyUUID
+	}
+
+	u := c.endpoint + "remotesnapshots/" + uuid + "/"
+
+	r, err := c.https.Get(u, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadRemoteSnapshot(r.Body)
+}
+
+func (c *Client) createRemoteSnapshot(drive, location, name string) (*data.RemoteSnapshot, error) {
+	drive = strings.TrimSpace(drive)
+	if drive == "" {
+		return nil, errEmptyUUID
+	}
+
+	location = strings.ToLower(strings.TrimSpace(location))
+	if err := VerifyRegion(location); err != nil {
+		return nil, err
+	}
+
+	rr, err := data.WriteRemoteSnapshots([]data.RemoteSnapshot{{
+		Drive:    data.MakeDriveResource(drive),
+		Location: location,
+		Name:     strings.TrimSpace(name),
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	u := c.endpoint + "remotesnapshots/"
+	r, err := c.https.Post(u, nil, rr)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(201); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	objs, err := data.ReadRemoteSnapshots(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objs) == 0 {
+		return nil, errors.New("no object was returned from server")
+	}
+
+	return &objs[0], nil
+}
+
+func (c *Client) cloneRemoteSnapshot(uuid string, params CloneParams) (*data.Drive, error) {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return nil, errEmptyUUID
+	}
+
+	rr, err := params.makeJSONReader()
+	if err != nil {
+		return nil, err
+	}
+
+	u := c.endpoint + "remotesnapshots/" + uuid + "/action/"
+	r, err := c.https.Post(u, url.Values{"do": {"clone"}}, rr)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(202); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	objs, err := data.ReadDrives(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objs) == 0 {
+		return nil, errors.New("no object was returned from server")
+	}
+
+	return &objs[0], nil
+}
+
+func (c *Client) restoreRemoteSnapshot(uuid string) (*data.Job, error) {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return nil, errEmptyUUID
+	}
+
+	u := c.endpoint + "remotesnapshots/" + uuid + "/action/"
+	r, err := c.https.Post(u, url.Values{"do": {"restore"}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(202); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadJob(r.Body)
+}
+
+func (c *Client) removeRemoteSnapshot(uuid string) error {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return errEmptyUUID
+	}
+
+	u := c.endpoint + "remotesnapshots/" + uuid + "/"
+
+	r, err := c.https.Delete(u, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyCode(204); err != nil {
+		return NewError(r, err)
+	}
+
+	return nil
+}
+
+func (c *Client) getBalance() (*data.Balance, error) {
+	u := c.endpoint + "balance/"
+
+	r, err := c.https.Get(u, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadBalance(r.Body)
+}
+
+func (c *Client) getCurrentUsage() (*data.CurrentUsage, error) {
+	u := c.endpoint + "currentusage/"
+
+	r, err := c.https.Get(u, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadCurrentUsage(r.Body)
+}
+
+func (c *Client) getPricing() (*data.Pricing, error) {
+	u := c.endpoint + "pricing/"
+
+	r, err := c.https.Get(u, url.Values{"limit": {"0"}})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadPricing(r.Body)
+}
+
+func (c *Client) getLedger(from, to time.Time) ([]data.LedgerEntry, error) {
+	u := c.endpoint + "ledger/"
+
+	filter := url.Values{"limit": {"0"}}
+	if !from.IsZero() {
+		filter.Set("time__gt", from.UTC().Format(ledgerTimeLayout))
+	}
+	if !to.IsZero() {
+		filter.Set("time__lt", to.UTC().Format(ledgerTimeLayout))
+	}
+
+	r, err := c.https.Get(u, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadLedger(r.Body)
+}
+
+func (c *Client) getSubscriptions() ([]data.Subscription, error) {
+	u := c.endpoint + "subscriptions/"
+
+	r, err := c.https.Get(u, url.Values{"limit": {"0"}})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadSubscriptions(r.Body)
+}
+
+func (c *Client) getSubscription(id string) (*data.Subscription, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, errEmptyID
+	}
+
+	u := c.endpoint + "subscriptions/" + id + "/"
+
+	r, err := c.https.Get(u, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadSubscription(r.Body)
+}
+
+func (c *Client) createSubscription(resource string, amount uint64, period string, autoRenew bool) (*data.Subscription, error) {
+	rr, err := data.WriteSubscriptions([]data.Subscription{{
+		Amount:    json.Number(strconv.FormatUint(amount, 10)),
+		AutoRenew: autoRenew,
+		Period:    strings.TrimSpace(period),
+		Resource:  strings.TrimSpace(resource),
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	u := c.endpoint + "subscriptions/"
+	r, err := c.https.Post(u, nil, rr)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(201); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	objs, err := data.ReadSubscriptions(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objs) == 0 {
+		return nil, errors.New("no object was returned from server")
+	}
+
+	return &objs[0], nil
+}
+
+func (c *Client) extendSubscription(id, period string) (*data.Subscription, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, errEmptyID
+	}
+
+	bb, err := json.Marshal(map[string]string{"period": strings.TrimSpace(period)})
+	if err != nil {
+		return nil, err
+	}
+
+	u := c.endpoint + "subscriptions/" + id + "/action/"
+	r, err := c.https.Post(u, url.Values{"do": {"extend"}}, bytes.NewReader(bb))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadSubscription(r.Body)
+}
+
+func (c *Client) setSubscriptionAutoRenew(id string, autoRenew bool) (*data.Subscription, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, errEmptyID
+	}
+
+	bb, err := json.Marshal(map[string]bool{"auto_renew": autoRenew})
+	if err != nil {
+		return nil, err
+	}
+
+	u := c.endpoint + "subscriptions/" + id + "/"
+	r, err := c.https.Put(u, nil, bytes.NewReader(bb))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadSubscription(r.Body)
+}
+
+// shareACLs returns access control lists with given one added or removed
+func shareACLs(acls []data.Resource, uuid string, share bool) []data.Resource {
+	result := make([]data.Resource, 0, len(acls)+1)
+	for _, r := range acls {
+		if r.UUID != uuid {
+			result = append(result, r)
+		}
+	}
+	if share {
+		result = append(result, *data.MakeACLResource(uuid))
+	}
+	return result
+}
+
+func (c *Client) shareDrive(obj data.Drive, uuid string, share bool) (*data.Drive, error) {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return nil, errEmptyUUID
+	}
+
+	obj.ACLs = shareACLs(obj.ACLs, uuid, share)
+
+	rr, err := data.WriteSharedDrive(&obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.putDrive(obj.UUID, rr)
+}
+
+func (c *Client) updateDrive(obj data.Drive) (*data.Drive, error) {
+	rr, err := data.WriteDrive(&obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.putDrive(obj.UUID, rr)
+}
+
+func (c *Client) putDrive(uuid string, rr io.Reader) (*data.Drive, error) {
+	u := c.endpoint + "drives/" + uuid + "/"
+	r, err := c.https.Put(u, nil, rr)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadDrive(r.Body)
+}
+
+func (c *Client) shareServer(obj data.Server, uuid string, share bool) (*data.Server, error) {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return nil, errEmptyUUID
+	}
+
+	obj.ACLs = shareACLs(obj.ACLs, uuid, share)
+
+	rr, err := data.WriteSharedServer(&obj)
+	if err != nil {
+		return nil, err
+	}
+
+	u := c.endpoint + "servers/" + obj.UUID + "/"
+	r, err := c.https.Put(u, nil, rr)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyJSON(200); err != nil {
+		return nil, NewError(r, err)
+	}
+
+	return data.ReadServer(r.Body)
+}
+
+func (c *Client) readContext() (*data.Context, error) {
 
 	const (
 		DEVICE  = "/dev/ttyS1"
@@ -321,7 +1124,7 @@ func (c Client) readContext() (*data.Context, error) {
 		EOT     = '\x04'
 	)
 
-	logger := c.logger
+	logger := c.getLogger()
 
 	// open server ctx device
 	f, err := os.OpenFile(DEVICE, os.O_RDWR, 0)
@@ -380,7 +1183,7 @@ func (c Client) readContext() (*data.Context, error) {
 	return data.ReadContext(rr)
 }
 
-func (c Client) resizeDrive(obj data.Drive, newSize uint64) (*data.Drive, error) {
+func (c *Client) resizeDrive(obj data.Drive, newSize uint64) (*data.Drive, error) {
 
 	// prepare endpoint URL
 	u := c.endpoint + "drives/" + obj.UUID + "/action/"
@@ -0,0 +1,43 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/altoros/gosigma/mock"
+	"gopkg.in/yaml.v3"
+)
+
+// readFixtureFile reads fixture from JSON or YAML file, format is chosen by
+// file extension
+func readFixtureFile(path string) (*mock.Fixture, error) {
+	bb, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if bb, err = yamlToJSON(bb); err != nil {
+			return nil, err
+		}
+	}
+
+	return mock.ReadFixture(bytes.NewReader(bb))
+}
+
+// yamlToJSON converts YAML document to JSON, so fixture objects are decoded
+// by their JSON field names in both formats
+func yamlToJSON(bb []byte) ([]byte, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(bb, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
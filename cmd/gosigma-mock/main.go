@@ -0,0 +1,94 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+// Command gosigma-mock runs standalone CloudSigma mock server, a local stand-in
+// of CloudSigma endpoint for integration tests of tools written in any language.
+//
+// Usage:
+//
+//	gosigma-mock [-addr host:port] [-fixture file.json|file.yaml] [-cert-out cert.pem]
+//
+// Server listens with TLS using self-signed certificate generated at startup;
+// the certificate may be saved in PEM format to trust it at the client side.
+// Journal of requests and state management are exposed at '/admin/'.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/altoros/gosigma/mock"
+)
+
+var (
+	addr     = flag.String("addr", "127.0.0.1:8443", "address to listen on")
+	fixture  = flag.String("fixture", "", "JSON or YAML file with seed servers, drives, libdrives and jobs")
+	certOut  = flag.String("cert-out", "", "file to write generated TLS certificate to, in PEM format")
+	username = flag.String("username", mock.TestUser, "account name for log into the server")
+	password = flag.String("password", mock.TestPassword, "password for log into the server")
+)
+
+func main() {
+	flag.Parse()
+
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	opts := []mock.Option{mock.WithCredentials(*username, *password)}
+
+	var f *mock.Fixture
+	if *fixture != "" {
+		var err error
+		if f, err = readFixtureFile(*fixture); err != nil {
+			return err
+		}
+	}
+
+	host, _, err := net.SplitHostPort(*addr)
+	if err != nil {
+		return err
+	}
+
+	cert, certPEM, err := generateCertificate(host)
+	if err != nil {
+		return err
+	}
+	if *certOut != "" {
+		if err := ioutil.WriteFile(*certOut, certPEM, 0644); err != nil {
+			return err
+		}
+	}
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+
+	opts = append(opts, mock.WithListener(l), mock.WithCertificate(cert))
+
+	srv := mock.New(opts...)
+	defer srv.Close()
+
+	if f != nil {
+		if err := srv.Load(f); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println(srv.Endpoint(""))
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	<-ch
+
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/altoros/gosigma"
+	"github.com/altoros/gosigma/mock"
+)
+
+const yamlFixture = `
+servers:
+  - uuid: s1
+    name: server 1
+    status: running
+    mem: 1073741824
+drives:
+  - uuid: d1
+    name: drive 1
+`
+
+func TestReadFixtureFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gosigma-mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "fixture.yaml")
+	if err := ioutil.WriteFile(path, []byte(yamlFixture), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := readFixtureFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(f.Servers) != 1 || f.Servers[0].UUID != "s1" || f.Servers[0].Mem != 1073741824 {
+		t.Errorf("invalid servers: %#v", f.Servers)
+	}
+	if len(f.Drives) != 1 || f.Drives[0].Name != "drive 1" {
+		t.Errorf("invalid drives: %#v", f.Drives)
+	}
+
+	if _, err := readFixtureFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("readFixtureFile must fail for missing file")
+	}
+}
+
+func TestGeneratedCertificate(t *testing.T) {
+	cert, certPEM, err := generateCertificate("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := mock.New(mock.WithListener(l), mock.WithCertificate(cert))
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(certPEM) {
+		t.Fatal("invalid PEM certificate")
+	}
+
+	cli, err := gosigma.New(
+		gosigma.WithEndpoint(srv.Endpoint("")),
+		gosigma.WithCredentials(srv.Username(), srv.Password()),
+		gosigma.WithTLSConfig(&tls.Config{RootCAs: pool}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cli.Servers(gosigma.RequestShort); err != nil {
+		t.Error(err)
+	}
+}
@@ -86,10 +86,18 @@ func (c *Components) SetDescription(description string) {
 }
 
 // SetSSHPublicKey sets public SSH key for new server. To unset, call this function with empty string.
+// To refer key pairs managed in the account, use AttachKeyPair.
 func (c *Components) SetSSHPublicKey(description string) {
 	c.SetMeta("ssh_public_key", description)
 }
 
+// AttachKeyPair attaches SSH key pair with given uuid to components, the public
+// key is installed to the server by its guest tools.
+func (c *Components) AttachKeyPair(uuid string) {
+	c.init()
+	c.data.PubKeys = append(c.data.PubKeys, *data.MakeKeyPairResource(strings.TrimSpace(uuid)))
+}
+
 // AttachDrive attaches drive to components from drive data.
 func (c *Components) AttachDrive(bootOrder int, channel, device, uuid string) {
 	c.init()
@@ -103,19 +111,31 @@ func (c *Components) AttachDrive(bootOrder int, channel, device, uuid string) {
 	c.data.Drives = append(c.data.Drives, sd)
 }
 
+// A NICOption configures NIC attached to components
+type NICOption func(n *data.NIC)
+
+// NICFirewallPolicy returns NICOption attaching firewall policy with given uuid to the NIC
+func NICFirewallPolicy(uuid string) NICOption {
+	return func(n *data.NIC) {
+		if uuid = strings.TrimSpace(uuid); uuid != "" {
+			n.FirewallPolicy = data.MakeFirewallPolicyResource(uuid)
+		}
+	}
+}
+
 // NetworkDHCP4 attaches NIC, configured with IPv4 DHCP
-func (c *Components) NetworkDHCP4(model string) {
-	c.network4(model, "dhcp", "")
+func (c *Components) NetworkDHCP4(model string, opts ...NICOption) {
+	c.network4(model, "dhcp", "", opts)
 }
 
 // NetworkStatic4 attaches NIC, configured with IPv4 static address
-func (c *Components) NetworkStatic4(model, address string) {
-	c.network4(model, "static", address)
+func (c *Components) NetworkStatic4(model, address string, opts ...NICOption) {
+	c.network4(model, "static", address, opts)
 }
 
 // NetworkManual4 attaches NIC, configured with IPv4 manual settings
-func (c *Components) NetworkManual4(model string) {
-	c.network4(model, "manual", "")
+func (c *Components) NetworkManual4(model string, opts ...NICOption) {
+	c.network4(model, "manual", "", opts)
 }
 
 // NetworkVLan attaches NIC, configured with private VLan
@@ -130,7 +150,7 @@ func (c *Components) NetworkVLan(model, uuid string) {
 	c.data.NICs = append(c.data.NICs, n)
 }
 
-func (c *Components) network4(model, conf, address string) {
+func (c *Components) network4(model, conf, address string, opts []NICOption) {
 	c.init()
 
 	var n data.NIC
@@ -140,6 +160,9 @@ func (c *Components) network4(model, conf, address string) {
 	if address = strings.TrimSpace(address); address != "" {
 		n.IPv4.IP = data.MakeIPResource(address)
 	}
+	for _, opt := range opts {
+		opt(&n)
+	}
 
 	c.data.NICs = append(c.data.NICs, n)
 }
@@ -117,3 +117,19 @@ func TestComponentsNetworkVLan(t *testing.T) {
 	testMarshalComponents(t, c, `NetworkVLan("virtio", "vlanuuid")`,
 		`{"nics":[{"model":"virtio","vlan":{"resource_uri":"/api/2.0/vlans/vlanuuid/","uuid":"vlanuuid"}}]}`)
 }
+
+func TestComponentsNetworkFirewallPolicy(t *testing.T) {
+	var c Components
+	c.NetworkDHCP4("virtio", NICFirewallPolicy("fwuuid"))
+	c.NetworkStatic4("virtio", "ipaddr", NICFirewallPolicy(""))
+	testMarshalComponents(t, c, `NetworkDHCP4("virtio", NICFirewallPolicy("fwuuid"))`,
+		`{"nics":[{"firewall_policy":{"resource_uri":"/api/2.0/fwpolicies/fwuuid/","uuid":"fwuuid"},"ip_v4_conf":{"conf":"dhcp"},"model":"virtio"},`+
+			`{"ip_v4_conf":{"conf":"static","ip":{"resource_uri":"/api/2.0/ips/ipaddr/","uuid":"ipaddr"}},"model":"virtio"}]}`)
+}
+
+func TestComponentsAttachKeyPair(t *testing.T) {
+	var c Components
+	c.AttachKeyPair(" keyuuid ")
+	testMarshalComponents(t, c, `AttachKeyPair("keyuuid")`,
+		`{"pubkeys":[{"resource_uri":"/api/2.0/keypairs/keyuuid/","uuid":"keyuuid"}]}`)
+}
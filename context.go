@@ -33,42 +33,42 @@ type Context interface {
 	VNCPassword() string
 }
 
-// A context implements server instance context in CloudSigma account
-type context struct {
+// A serverContext implements server instance context in CloudSigma account
+type serverContext struct {
 	obj *data.Context
 }
 
-var _ Context = context{}
+var _ Context = serverContext{}
 
 // String method is used to print values passed as an operand to any format that
 // accepts a string or to an unformatted printer such as Print.
-func (c context) String() string {
+func (c serverContext) String() string {
 	return fmt.Sprintf("{Name: %q\nUUID: %q}", c.Name(), c.UUID())
 }
 
 // URI of instance
-func (c context) URI() string { return fmt.Sprintf("/api/2.0/servers/%s/", c.UUID()) }
+func (c serverContext) URI() string { return fmt.Sprintf("/api/2.0/servers/%s/", c.UUID()) }
 
 // UUID of server instance
-func (c context) UUID() string { return c.obj.UUID }
+func (c serverContext) UUID() string { return c.obj.UUID }
 
 // CPU frequency in MHz
-func (c context) CPU() int64 { return c.obj.CPU }
+func (c serverContext) CPU() int64 { return c.obj.CPU }
 
 // Get meta-information value stored in the server instance
-func (c context) Get(key string) (v string, ok bool) {
+func (c serverContext) Get(key string) (v string, ok bool) {
 	v, ok = c.obj.Meta[key]
 	return
 }
 
 // Mem capacity in bytes
-func (c context) Mem() int64 { return c.obj.Mem }
+func (c serverContext) Mem() int64 { return c.obj.Mem }
 
 // Name of server instance
-func (c context) Name() string { return c.obj.Name }
+func (c serverContext) Name() string { return c.obj.Name }
 
 // NICs for this context instance
-func (c context) NICs() []ContextNIC {
+func (c serverContext) NICs() []ContextNIC {
 	r := make([]ContextNIC, 0, len(c.obj.NICs))
 	for i := range c.obj.NICs {
 		nic := contextNIC{&c.obj.NICs[i]}
@@ -78,4 +78,4 @@ func (c context) NICs() []ContextNIC {
 }
 
 // VNCPassword to access the server
-func (c context) VNCPassword() string { return c.obj.VNCPassword }
+func (c serverContext) VNCPassword() string { return c.obj.VNCPassword }
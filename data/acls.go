@@ -0,0 +1,68 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// ACLRule contains properties of access control rule, granting permission to user
+type ACLRule struct {
+	Permission string    `json:"permission,omitempty"`
+	User       *Resource `json:"user,omitempty"`
+}
+
+// ACL contains properties of access control list
+type ACL struct {
+	Resource
+	Meta      map[string]string `json:"meta,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Owner     *Resource         `json:"owner,omitempty"`
+	Resources []Resource        `json:"resources,omitempty"`
+	Rules     []ACLRule         `json:"rules"`
+}
+
+// ACLs holds collection of ACL objects
+type ACLs struct {
+	Meta    Meta  `json:"meta"`
+	Objects []ACL `json:"objects"`
+}
+
+// ReadACLs reads and unmarshalls information about access control lists from JSON stream
+func ReadACLs(r io.Reader) ([]ACL, error) {
+	var acls ACLs
+	if err := ReadJSON(r, &acls); err != nil {
+		return nil, err
+	}
+	return acls.Objects, nil
+}
+
+// ReadACL reads and unmarshalls information about single access control list from JSON stream
+func ReadACL(r io.Reader) (*ACL, error) {
+	var acl ACL
+	if err := ReadJSON(r, &acl); err != nil {
+		return nil, err
+	}
+	return &acl, nil
+}
+
+// WriteACLs marshals collection of access control list objects to JSON stream
+func WriteACLs(objs []ACL) (io.Reader, error) {
+	bb, err := json.Marshal(&ACLs{Objects: objs})
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(bb), nil
+}
+
+// WriteACL marshals single access control list object to JSON stream
+func WriteACL(obj *ACL) (io.Reader, error) {
+	bb, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(bb), nil
+}
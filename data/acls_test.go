@@ -0,0 +1,140 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+const jsonACLsData = `{
+    "meta": {
+        "limit": 0,
+        "offset": 0,
+        "total_count": 1
+    },
+    "objects": [
+        {
+            "meta": {},
+            "name": "golden images",
+            "owner": {
+                "resource_uri": "/api/2.0/user/5b4a69a3-8e78-4c45-a8ba-8b13f0895e23/",
+                "uuid": "5b4a69a3-8e78-4c45-a8ba-8b13f0895e23"
+            },
+            "resource_uri": "/api/2.0/acls/8d5b1d92-a98d-4a2e-8fba-e1f2d0cb0a2b/",
+            "resources": [
+                {
+                    "resource_uri": "/api/2.0/drives/2ef7b7c7-7ec4-47a7-9b69-087c9417c0ff/",
+                    "uuid": "2ef7b7c7-7ec4-47a7-9b69-087c9417c0ff"
+                }
+            ],
+            "rules": [
+                {
+                    "permission": "LIST",
+                    "user": {
+                        "resource_uri": "/api/2.0/user/f4c6b3a2-1f71-4d8d-9a61-0c4e4b3b8e10/",
+                        "uuid": "f4c6b3a2-1f71-4d8d-9a61-0c4e4b3b8e10"
+                    }
+                },
+                {
+                    "permission": "CLONE",
+                    "user": {
+                        "resource_uri": "/api/2.0/user/f4c6b3a2-1f71-4d8d-9a61-0c4e4b3b8e10/",
+                        "uuid": "f4c6b3a2-1f71-4d8d-9a61-0c4e4b3b8e10"
+                    }
+                }
+            ],
+            "uuid": "8d5b1d92-a98d-4a2e-8fba-e1f2d0cb0a2b"
+        }
+    ]
+}
+`
+
+var aclData = ACL{
+	Resource:  *MakeACLResource("8d5b1d92-a98d-4a2e-8fba-e1f2d0cb0a2b"),
+	Meta:      map[string]string{},
+	Name:      "golden images",
+	Owner:     MakeUserResource("5b4a69a3-8e78-4c45-a8ba-8b13f0895e23"),
+	Resources: []Resource{*MakeDriveResource("2ef7b7c7-7ec4-47a7-9b69-087c9417c0ff")},
+	Rules: []ACLRule{
+		{Permission: "LIST", User: MakeUserResource("f4c6b3a2-1f71-4d8d-9a61-0c4e4b3b8e10")},
+		{Permission: "CLONE", User: MakeUserResource("f4c6b3a2-1f71-4d8d-9a61-0c4e4b3b8e10")},
+	},
+}
+
+func TestDataACLReaderFail(t *testing.T) {
+	r := failReader{}
+
+	if _, err := ReadACLs(r); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+
+	if _, err := ReadACL(r); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+}
+
+func TestDataACLReadWrite(t *testing.T) {
+	aa, err := ReadACLs(strings.NewReader(jsonACLsData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aa) != 1 {
+		t.Fatalf("Wrong ACLs count: %d, wants 1", len(aa))
+	}
+	compareACLs(t, &aa[0], &aclData)
+
+	r, err := WriteACLs(aa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aa, err = ReadACLs(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aa) != 1 {
+		t.Fatalf("Wrong ACLs count: %d, wants 1", len(aa))
+	}
+	compareACLs(t, &aa[0], &aclData)
+
+	r, err = WriteACL(&aclData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := ReadACL(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareACLs(t, a, &aclData)
+}
+
+func compareACLs(t *testing.T, value, wants *ACL) {
+	if value.Resource != wants.Resource {
+		t.Errorf("ACL.Resource error: found %#v, wants %#v", value.Resource, wants.Resource)
+	}
+	compareMeta(t, "ACL.Meta", value.Meta, wants.Meta)
+	if value.Name != wants.Name {
+		t.Errorf("ACL.Name error: found %#v, wants %#v", value.Name, wants.Name)
+	}
+	if (value.Owner == nil) != (wants.Owner == nil) || value.Owner != nil && *value.Owner != *wants.Owner {
+		t.Errorf("ACL.Owner error: found %#v, wants %#v", value.Owner, wants.Owner)
+	}
+	if len(value.Resources) != len(wants.Resources) {
+		t.Errorf("ACL.Resources error: found %#v, wants %#v", value.Resources, wants.Resources)
+	}
+	for i := 0; i < len(value.Resources) && i < len(wants.Resources); i++ {
+		if value.Resources[i] != wants.Resources[i] {
+			t.Errorf("ACL.Resources error [%d]: found %#v, wants %#v", i, value.Resources[i], wants.Resources[i])
+		}
+	}
+	if len(value.Rules) != len(wants.Rules) {
+		t.Errorf("ACL.Rules error: found %#v, wants %#v", value.Rules, wants.Rules)
+	}
+	for i := 0; i < len(value.Rules) && i < len(wants.Rules); i++ {
+		v, w := value.Rules[i], wants.Rules[i]
+		if v.Permission != w.Permission || v.User == nil || w.User == nil || *v.User != *w.User {
+			t.Errorf("ACL.Rules error [%d]: found %#v, wants %#v", i, v, w)
+		}
+	}
+}
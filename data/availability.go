@@ -0,0 +1,26 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import "io"
+
+// ReadAvailabilityGroups reads and unmarshalls groups of servers or drives
+// sharing physical host or storage from JSON stream
+func ReadAvailabilityGroups(r io.Reader) ([][]string, error) {
+	var groups [][]string
+	if err := ReadJSON(r, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// ReadAvailabilityGroup reads and unmarshalls uuids of servers or drives
+// sharing physical host or storage with given one from JSON stream
+func ReadAvailabilityGroup(r io.Reader) ([]string, error) {
+	var group []string
+	if err := ReadJSON(r, &group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
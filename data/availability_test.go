@@ -0,0 +1,54 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+const jsonAvailabilityGroupsData = `[
+    [
+        "313e73a4-dd09-4b4f-b0b6-0e1a3d3a7b2f",
+        "08c92dd5-70a0-4f51-83d2-835919d254df"
+    ],
+    [
+        "3f8bd40d-8c73-4f4d-9b7b-7c1e3b2c9c11",
+        "4b5ec2f6-1a8d-4f7e-b1a6-2c3d4e5f6a7b",
+        "9b7e5c3a-2d4f-4e6a-8c1b-3d5e7f9a1b2c"
+    ]
+]`
+
+func TestDataAvailabilityGroupsReaderFail(t *testing.T) {
+	r := failReader{}
+
+	if _, err := ReadAvailabilityGroups(r); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+
+	if _, err := ReadAvailabilityGroup(r); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+}
+
+func TestDataAvailabilityGroupsReader(t *testing.T) {
+	groups, err := ReadAvailabilityGroups(strings.NewReader(jsonAvailabilityGroupsData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || len(groups[0]) != 2 || len(groups[1]) != 3 {
+		t.Errorf("invalid groups %v", groups)
+	}
+	if groups[0][1] != "08c92dd5-70a0-4f51-83d2-835919d254df" {
+		t.Errorf("invalid group %v", groups[0])
+	}
+
+	group, err := ReadAvailabilityGroup(strings.NewReader(`["313e73a4-dd09-4b4f-b0b6-0e1a3d3a7b2f"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(group) != 1 || group[0] != "313e73a4-dd09-4b4f-b0b6-0e1a3d3a7b2f" {
+		t.Errorf("invalid group %v", group)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Balance contains balance of the account. Amount is decimal number, the
+// endpoint sends it as a string.
+type Balance struct {
+	Balance  json.Number `json:"balance"`
+	Currency string      `json:"currency"`
+}
+
+// Usage contains usage of billable resource in its units
+type Usage struct {
+	Burst      uint64 `json:"burst"`
+	Subscribed uint64 `json:"subscribed"`
+	Using      uint64 `json:"using"`
+}
+
+// CurrentUsage contains balance and usage of the account by billable resource
+type CurrentUsage struct {
+	Balance Balance          `json:"balance"`
+	Usage   map[string]Usage `json:"usage"`
+}
+
+// LedgerEntry contains properties of single account ledger record
+type LedgerEntry struct {
+	Resource
+	Amount       json.Number `json:"amount"`
+	BillingCycle int         `json:"billing_cycle"`
+	End          time.Time   `json:"end"`
+	ID           string      `json:"id"`
+	Interval     int         `json:"interval"`
+	Reason       string      `json:"reason"`
+	Start        time.Time   `json:"start"`
+	Time         time.Time   `json:"time"`
+}
+
+// Ledger holds collection of LedgerEntry objects
+type Ledger struct {
+	Meta    Meta          `json:"meta"`
+	Objects []LedgerEntry `json:"objects"`
+}
+
+// ReadBalance reads and unmarshalls balance of the account from JSON stream
+func ReadBalance(r io.Reader) (*Balance, error) {
+	var balance Balance
+	if err := ReadJSON(r, &balance); err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
+// ReadCurrentUsage reads and unmarshalls current usage of the account from JSON stream
+func ReadCurrentUsage(r io.Reader) (*CurrentUsage, error) {
+	var usage CurrentUsage
+	if err := ReadJSON(r, &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// ReadLedger reads and unmarshalls ledger records of the account from JSON stream
+func ReadLedger(r io.Reader) ([]LedgerEntry, error) {
+	var ledger Ledger
+	if err := ReadJSON(r, &ledger); err != nil {
+		return nil, err
+	}
+	return ledger.Objects, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const jsonBalanceData = `{"balance": "149.5600", "currency": "USD"}`
+
+const jsonCurrentUsageData = `{
+    "balance": {
+        "balance": "149.5600",
+        "currency": "USD"
+    },
+    "usage": {
+        "cpu": {
+            "burst": 1000,
+            "subscribed": 2000,
+            "using": 3000
+        },
+        "dssd": {
+            "burst": 0,
+            "subscribed": 53687091200,
+            "using": 10737418240
+        }
+    }
+}`
+
+const jsonLedgerData = `{
+    "meta": {
+        "limit": 0,
+        "offset": 0,
+        "total_count": 1
+    },
+    "objects": [
+        {
+            "amount": "-0.0124",
+            "billing_cycle": 75,
+            "end": "2014-02-10T12:05:00+00:00",
+            "id": "1031",
+            "interval": 300,
+            "reason": "Burst: 1000 of cpu for 300 seconds",
+            "resource_uri": "/api/2.0/ledger/1031/",
+            "start": "2014-02-10T12:00:00+00:00",
+            "time": "2014-02-10T12:05:03+00:00"
+        }
+    ]
+}`
+
+func TestDataBillingReaderFail(t *testing.T) {
+	r := failReader{}
+
+	if _, err := ReadBalance(r); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+
+	if _, err := ReadCurrentUsage(r); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+
+	if _, err := ReadLedger(r); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+}
+
+func TestDataBalance(t *testing.T) {
+	b, err := ReadBalance(strings.NewReader(jsonBalanceData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Balance != "149.5600" || b.Currency != "USD" {
+		t.Errorf("invalid balance %#v", b)
+	}
+
+	b, err = ReadBalance(strings.NewReader(`{"balance": 12.5, "currency": "EUR"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Balance != "12.5" || b.Currency != "EUR" {
+		t.Errorf("invalid balance %#v", b)
+	}
+}
+
+func TestDataCurrentUsage(t *testing.T) {
+	u, err := ReadCurrentUsage(strings.NewReader(jsonCurrentUsageData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Balance.Balance != "149.5600" || u.Balance.Currency != "USD" {
+		t.Errorf("invalid balance %#v", u.Balance)
+	}
+	if v := u.Usage["cpu"]; v != (Usage{Burst: 1000, Subscribed: 2000, Using: 3000}) {
+		t.Errorf("invalid cpu usage %#v", v)
+	}
+	if v := u.Usage["dssd"]; v != (Usage{Subscribed: 53687091200, Using: 10737418240}) {
+		t.Errorf("invalid dssd usage %#v", v)
+	}
+}
+
+func TestDataLedger(t *testing.T) {
+	ee, err := ReadLedger(strings.NewReader(jsonLedgerData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ee) != 1 {
+		t.Fatalf("Wrong ledger entries count: %d, wants 1", len(ee))
+	}
+
+	e := ee[0]
+	if e.Resource != *MakeLedgerResource("1031") || e.ID != "1031" {
+		t.Errorf("invalid ledger entry resource %#v", e.Resource)
+	}
+	if e.Amount != "-0.0124" || e.BillingCycle != 75 || e.Interval != 300 {
+		t.Errorf("invalid ledger entry %#v", e)
+	}
+	if e.Reason != "Burst: 1000 of cpu for 300 seconds" {
+		t.Errorf("invalid ledger entry reason %q", e.Reason)
+	}
+	if !e.Start.Equal(time.Date(2014, 2, 10, 12, 0, 0, 0, time.UTC)) ||
+		!e.End.Equal(time.Date(2014, 2, 10, 12, 5, 0, 0, time.UTC)) ||
+		!e.Time.Equal(time.Date(2014, 2, 10, 12, 5, 3, 0, time.UTC)) {
+		t.Errorf("invalid ledger entry times %#v", e)
+	}
+}
@@ -26,6 +26,11 @@ func MakeResource(t, uuid string) *Resource {
 	}
 }
 
+// MakeACLResource returns access control list Resource structure for given UUID
+func MakeACLResource(uuid string) *Resource {
+	return MakeResource("acls", uuid)
+}
+
 // MakeDriveResource returns drive Resource structure for given UUID
 func MakeDriveResource(uuid string) *Resource {
 	return MakeResource("drives", uuid)
@@ -36,21 +41,46 @@ func MakeJobResource(uuid string) *Resource {
 	return MakeResource("jobs", uuid)
 }
 
+// MakeFirewallPolicyResource returns firewall policy Resource structure for given UUID
+func MakeFirewallPolicyResource(uuid string) *Resource {
+	return MakeResource("fwpolicies", uuid)
+}
+
 // MakeIPResource returns IP Resource structure for given IP address
 func MakeIPResource(ip string) *Resource {
 	return MakeResource("ips", ip)
 }
 
+// MakeKeyPairResource returns SSH key pair Resource structure for given UUID
+func MakeKeyPairResource(uuid string) *Resource {
+	return MakeResource("keypairs", uuid)
+}
+
+// MakeLedgerResource returns ledger record Resource structure for given ID
+func MakeLedgerResource(id string) *Resource {
+	return &Resource{URI: fmt.Sprintf("/api/2.0/ledger/%s/", id)}
+}
+
 // MakeLibDriveResource returns library drive Resource structure for given UUID
 func MakeLibDriveResource(uuid string) *Resource {
 	return MakeResource("libdrives", uuid)
 }
 
+// MakeRemoteSnapshotResource returns remote snapshot Resource structure for given UUID
+func MakeRemoteSnapshotResource(uuid string) *Resource {
+	return MakeResource("remotesnapshots", uuid)
+}
+
 // MakeServerResource returns server Resource structure for given UUID
 func MakeServerResource(uuid string) *Resource {
 	return MakeResource("servers", uuid)
 }
 
+// MakeSubscriptionURI returns URI of subscription for given ID
+func MakeSubscriptionURI(id string) string {
+	return fmt.Sprintf("/api/2.0/subscriptions/%s/", id)
+}
+
 // MakeUserResource returns user Resource structure for given UUID
 func MakeUserResource(uuid string) *Resource {
 	return MakeResource("user", uuid)
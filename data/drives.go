@@ -22,11 +22,13 @@ type LibraryDrive struct {
 type Drive struct {
 	Resource
 	LibraryDrive
+	ACLs            []Resource        `json:"acls,omitempty"`
 	Affinities      []string          `json:"affinities,omitempty"`
 	AllowMultimount bool              `json:"allow_multimount,omitempty"`
 	Jobs            []Resource        `json:"jobs,omitempty"`
 	Media           string            `json:"media,omitempty"`
 	Meta            map[string]string `json:"meta,omitempty"`
+	MountedOn       []Resource        `json:"mounted_on,omitempty"`
 	Name            string            `json:"name,omitempty"`
 	Owner           *Resource         `json:"owner,omitempty"`
 	Size            uint64            `json:"size,omitempty"`
@@ -66,3 +68,20 @@ func WriteDrive(obj *Drive) (io.Reader, error) {
 	}
 	return bytes.NewReader(bb), nil
 }
+
+// WriteSharedDrive marshals single drive object to JSON stream, the ACLs are
+// written even if empty, so the drive can be unshared from the last ACL
+func WriteSharedDrive(obj *Drive) (io.Reader, error) {
+	acls := obj.ACLs
+	if acls == nil {
+		acls = []Resource{}
+	}
+	bb, err := json.Marshal(struct {
+		*Drive
+		ACLs []Resource `json:"acls"`
+	}{obj, acls})
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(bb), nil
+}
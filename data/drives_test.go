@@ -75,6 +75,36 @@ func TestDataDrivesReadDrive(t *testing.T) {
 	compareDrives(t, 0, d, &driveData)
 }
 
+func TestDataDrivesWriteSharedDrive(t *testing.T) {
+	d, err := ReadDrive(strings.NewReader(jsonDriveData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := WriteSharedDrive(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, err := ReadDrive(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareDrives(t, 0, shared, &driveData)
+
+	d.ACLs = nil
+	r, err = WriteSharedDrive(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := m["acls"]; !ok || string(v) != "[]" {
+		t.Errorf("empty ACLs must be written, got %q", v)
+	}
+}
+
 func TestDataDrivesLibDrive(t *testing.T) {
 	d, err := ReadDrive(strings.NewReader(jsonLibraryDriveData))
 	if err != nil {
@@ -123,6 +153,18 @@ func compareDrives(t *testing.T, i int, value, wants *Drive) {
 
 	compareMeta(t, fmt.Sprintf("Drive.Meta error [%d]", i), value.Meta, wants.Meta)
 
+	if len(value.MountedOn) != len(wants.MountedOn) {
+		t.Errorf("Drive.MountedOn error [%d]: found %#v, wants %#v", i, value.MountedOn, wants.MountedOn)
+	} else {
+		for j := 0; j < len(value.MountedOn); j++ {
+			v := value.MountedOn[j]
+			w := wants.MountedOn[j]
+			if v != w {
+				t.Errorf("Drive.MountedOn error [%d]: at %d found %#v, wants %#v", i, j, v, w)
+			}
+		}
+	}
+
 	if value.Name != wants.Name {
 		t.Errorf("Drive.Name error [%d]: found %#v, wants %#v", i, value.Name, wants.Name)
 	}
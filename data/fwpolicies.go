@@ -0,0 +1,65 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// FirewallRule describes properties of firewall policy rule
+type FirewallRule struct {
+	Action    string `json:"action,omitempty"`
+	Comment   string `json:"comment,omitempty"`
+	Direction string `json:"direction,omitempty"`
+	DstIP     string `json:"dst_ip,omitempty"`
+	DstPort   string `json:"dst_port,omitempty"`
+	IPProto   string `json:"ip_proto,omitempty"`
+	SrcIP     string `json:"src_ip,omitempty"`
+	SrcPort   string `json:"src_port,omitempty"`
+}
+
+// FirewallPolicy contains properties of firewall policy
+type FirewallPolicy struct {
+	Resource
+	Meta    map[string]string `json:"meta,omitempty"`
+	Name    string            `json:"name,omitempty"`
+	Owner   *Resource         `json:"owner,omitempty"`
+	Rules   []FirewallRule    `json:"rules"`
+	Servers []Resource        `json:"servers,omitempty"`
+}
+
+// FirewallPolicies holds collection of FirewallPolicy objects
+type FirewallPolicies struct {
+	Meta    Meta             `json:"meta"`
+	Objects []FirewallPolicy `json:"objects"`
+}
+
+// ReadFirewallPolicies reads and unmarshalls information about firewall policies from JSON stream
+func ReadFirewallPolicies(r io.Reader) ([]FirewallPolicy, error) {
+	var policies FirewallPolicies
+	if err := ReadJSON(r, &policies); err != nil {
+		return nil, err
+	}
+	return policies.Objects, nil
+}
+
+// ReadFirewallPolicy reads and unmarshalls information about single firewall policy from JSON stream
+func ReadFirewallPolicy(r io.Reader) (*FirewallPolicy, error) {
+	var policy FirewallPolicy
+	if err := ReadJSON(r, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// WriteFirewallPolicy marshals single firewall policy object to JSON stream
+func WriteFirewallPolicy(obj *FirewallPolicy) (io.Reader, error) {
+	bb, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(bb), nil
+}
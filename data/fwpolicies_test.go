@@ -0,0 +1,94 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestDataFirewallPolicyReaderFail(t *testing.T) {
+	r := failReader{}
+
+	if _, err := ReadFirewallPolicies(r); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+
+	if _, err := ReadFirewallPolicy(r); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+}
+
+func TestDataFirewallPolicyReadPolicies(t *testing.T) {
+	pp, err := ReadFirewallPolicies(strings.NewReader(jsonFirewallPoliciesData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pp) != len(fwpoliciesData) {
+		t.Fatalf("Wrong firewall policies count: %d, wants %d", len(pp), len(fwpoliciesData))
+	}
+
+	for i := range pp {
+		compareFirewallPolicies(t, i, &pp[i], &fwpoliciesData[i])
+	}
+}
+
+func TestDataFirewallPolicyWrite(t *testing.T) {
+	r, err := WriteFirewallPolicy(&fwpoliciesData[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bb, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := ReadFirewallPolicy(strings.NewReader(string(bb)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compareFirewallPolicies(t, 0, p, &fwpoliciesData[0])
+}
+
+func compareFirewallPolicies(t *testing.T, i int, value, wants *FirewallPolicy) {
+	if value.Resource != wants.Resource {
+		t.Errorf("FirewallPolicy.Resource error [%d]: found %#v, wants %#v", i, value.Resource, wants.Resource)
+	}
+
+	compareMeta(t, "FirewallPolicy.Meta", value.Meta, wants.Meta)
+
+	if value.Name != wants.Name {
+		t.Errorf("FirewallPolicy.Name error [%d]: found %#v, wants %#v", i, value.Name, wants.Name)
+	}
+
+	if value.Owner != nil && wants.Owner != nil {
+		if *value.Owner != *wants.Owner {
+			t.Errorf("FirewallPolicy.Owner error [%d]: found %#v, wants %#v", i, value.Owner, wants.Owner)
+		}
+	} else if value.Owner != nil || wants.Owner != nil {
+		t.Errorf("FirewallPolicy.Owner error [%d]: found %#v, wants %#v", i, value.Owner, wants.Owner)
+	}
+
+	if len(value.Rules) != len(wants.Rules) {
+		t.Errorf("FirewallPolicy.Rules error [%d]: found %#v, wants %#v", i, value.Rules, wants.Rules)
+	}
+	for j := 0; j < len(value.Rules) && j < len(wants.Rules); j++ {
+		if value.Rules[j] != wants.Rules[j] {
+			t.Errorf("FirewallPolicy.Rules error [%d][%d]: found %#v, wants %#v", i, j, value.Rules[j], wants.Rules[j])
+		}
+	}
+
+	if len(value.Servers) != len(wants.Servers) {
+		t.Errorf("FirewallPolicy.Servers error [%d]: found %#v, wants %#v", i, value.Servers, wants.Servers)
+	}
+	for j := 0; j < len(value.Servers) && j < len(wants.Servers); j++ {
+		if value.Servers[j] != wants.Servers[j] {
+			t.Errorf("FirewallPolicy.Servers error [%d][%d]: found %#v, wants %#v", i, j, value.Servers[j], wants.Servers[j])
+		}
+	}
+}
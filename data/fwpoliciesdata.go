@@ -0,0 +1,137 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+var fwpoliciesData = []FirewallPolicy{
+	FirewallPolicy{
+		Resource: *MakeFirewallPolicyResource("0bb2ad4c-1d4a-4fc0-b3e6-8ec54e1a3fb0"),
+		Meta:     map[string]string{},
+		Name:     "web",
+		Owner:    MakeUserResource("80cb30fb-0ea3-43db-b27b-a125752cc0bf"),
+		Rules: []FirewallRule{
+			FirewallRule{
+				Action:    "accept",
+				Comment:   "allow http",
+				Direction: "in",
+				DstPort:   "80",
+				IPProto:   "tcp",
+			},
+			FirewallRule{
+				Action:    "accept",
+				Direction: "in",
+				DstPort:   "22",
+				IPProto:   "tcp",
+				SrcIP:     "10.0.0.0/8",
+			},
+			FirewallRule{
+				Action:    "drop",
+				Comment:   "drop everything else",
+				Direction: "in",
+			},
+		},
+		Servers: []Resource{
+			*MakeServerResource("472835d5-2bbb-4d87-9d08-7364bc373691"),
+		},
+	},
+	FirewallPolicy{
+		Resource: *MakeFirewallPolicyResource("6d2d9e5e-9d1e-47f3-a1a0-4b5e1c1e3b7a"),
+		Meta:     map[string]string{"description": "outbound dns"},
+		Name:     "dns",
+		Owner:    MakeUserResource("80cb30fb-0ea3-43db-b27b-a125752cc0bf"),
+		Rules: []FirewallRule{
+			FirewallRule{
+				Action:    "accept",
+				Direction: "out",
+				DstIP:     "8.8.8.8",
+				DstPort:   "53",
+				IPProto:   "udp",
+				SrcPort:   "1024:65535",
+			},
+		},
+	},
+}
+
+const jsonFirewallPoliciesData = `{
+    "meta": {
+        "limit": 0,
+        "offset": 0,
+        "total_count": 2
+    },
+    "objects": [
+        {
+            "meta": {},
+            "name": "web",
+            "owner": {
+                "resource_uri": "/api/2.0/user/80cb30fb-0ea3-43db-b27b-a125752cc0bf/",
+                "uuid": "80cb30fb-0ea3-43db-b27b-a125752cc0bf"
+            },
+            "resource_uri": "/api/2.0/fwpolicies/0bb2ad4c-1d4a-4fc0-b3e6-8ec54e1a3fb0/",
+            "rules": [
+                {
+                    "action": "accept",
+                    "comment": "allow http",
+                    "direction": "in",
+                    "dst_ip": null,
+                    "dst_port": "80",
+                    "ip_proto": "tcp",
+                    "src_ip": null,
+                    "src_port": null
+                },
+                {
+                    "action": "accept",
+                    "comment": null,
+                    "direction": "in",
+                    "dst_ip": null,
+                    "dst_port": "22",
+                    "ip_proto": "tcp",
+                    "src_ip": "10.0.0.0/8",
+                    "src_port": null
+                },
+                {
+                    "action": "drop",
+                    "comment": "drop everything else",
+                    "direction": "in",
+                    "dst_ip": null,
+                    "dst_port": null,
+                    "ip_proto": null,
+                    "src_ip": null,
+                    "src_port": null
+                }
+            ],
+            "servers": [
+                {
+                    "resource_uri": "/api/2.0/servers/472835d5-2bbb-4d87-9d08-7364bc373691/",
+                    "uuid": "472835d5-2bbb-4d87-9d08-7364bc373691"
+                }
+            ],
+            "uuid": "0bb2ad4c-1d4a-4fc0-b3e6-8ec54e1a3fb0"
+        },
+        {
+            "meta": {
+                "description": "outbound dns"
+            },
+            "name": "dns",
+            "owner": {
+                "resource_uri": "/api/2.0/user/80cb30fb-0ea3-43db-b27b-a125752cc0bf/",
+                "uuid": "80cb30fb-0ea3-43db-b27b-a125752cc0bf"
+            },
+            "resource_uri": "/api/2.0/fwpolicies/6d2d9e5e-9d1e-47f3-a1a0-4b5e1c1e3b7a/",
+            "rules": [
+                {
+                    "action": "accept",
+                    "comment": null,
+                    "direction": "out",
+                    "dst_ip": "8.8.8.8",
+                    "dst_port": "53",
+                    "ip_proto": "udp",
+                    "src_ip": null,
+                    "src_port": "1024:65535"
+                }
+            ],
+            "servers": [],
+            "uuid": "6d2d9e5e-9d1e-47f3-a1a0-4b5e1c1e3b7a"
+        }
+    ]
+}
+`
@@ -0,0 +1,53 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// KeyPair contains properties of SSH key pair
+type KeyPair struct {
+	Resource
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Meta        map[string]string `json:"meta,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	PrivateKey  string            `json:"private_key,omitempty"`
+	PublicKey   string            `json:"public_key,omitempty"`
+}
+
+// KeyPairs holds collection of KeyPair objects
+type KeyPairs struct {
+	Meta    Meta      `json:"meta"`
+	Objects []KeyPair `json:"objects"`
+}
+
+// ReadKeyPairs reads and unmarshalls information about SSH key pairs from JSON stream
+func ReadKeyPairs(r io.Reader) ([]KeyPair, error) {
+	var keypairs KeyPairs
+	if err := ReadJSON(r, &keypairs); err != nil {
+		return nil, err
+	}
+	return keypairs.Objects, nil
+}
+
+// ReadKeyPair reads and unmarshalls information about single SSH key pair from JSON stream
+func ReadKeyPair(r io.Reader) (*KeyPair, error) {
+	var keypair KeyPair
+	if err := ReadJSON(r, &keypair); err != nil {
+		return nil, err
+	}
+	return &keypair, nil
+}
+
+// WriteKeyPairs marshals collection of SSH key pair objects to JSON stream
+func WriteKeyPairs(objs []KeyPair) (io.Reader, error) {
+	bb, err := json.Marshal(&KeyPairs{Objects: objs})
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(bb), nil
+}
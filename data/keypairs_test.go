@@ -0,0 +1,92 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+const jsonKeyPairsData = `{
+    "meta": {
+        "limit": 0,
+        "offset": 0,
+        "total_count": 1
+    },
+    "objects": [
+        {
+            "fingerprint": "a6:3c:8e:1f:58:57:8e:20:0a:4d:b4:27:23:d1:6d:4f",
+            "meta": {},
+            "name": "deploy",
+            "private_key": "",
+            "public_key": "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQC deploy@example.com",
+            "resource_uri": "/api/2.0/keypairs/d6b8b5b5-29a1-4c3c-8e1f-9d0b1b8b2c4f/",
+            "uuid": "d6b8b5b5-29a1-4c3c-8e1f-9d0b1b8b2c4f"
+        }
+    ]
+}
+`
+
+var keyPairData = KeyPair{
+	Resource:    *MakeKeyPairResource("d6b8b5b5-29a1-4c3c-8e1f-9d0b1b8b2c4f"),
+	Fingerprint: "a6:3c:8e:1f:58:57:8e:20:0a:4d:b4:27:23:d1:6d:4f",
+	Meta:        map[string]string{},
+	Name:        "deploy",
+	PublicKey:   "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQC deploy@example.com",
+}
+
+func TestDataKeyPairReaderFail(t *testing.T) {
+	r := failReader{}
+
+	if _, err := ReadKeyPairs(r); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+
+	if _, err := ReadKeyPair(r); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+}
+
+func TestDataKeyPairReadWrite(t *testing.T) {
+	kk, err := ReadKeyPairs(strings.NewReader(jsonKeyPairsData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kk) != 1 {
+		t.Fatalf("Wrong key pairs count: %d, wants 1", len(kk))
+	}
+	compareKeyPairs(t, &kk[0], &keyPairData)
+
+	r, err := WriteKeyPairs(kk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kk, err = ReadKeyPairs(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kk) != 1 {
+		t.Fatalf("Wrong key pairs count: %d, wants 1", len(kk))
+	}
+	compareKeyPairs(t, &kk[0], &keyPairData)
+}
+
+func compareKeyPairs(t *testing.T, value, wants *KeyPair) {
+	if value.Resource != wants.Resource {
+		t.Errorf("KeyPair.Resource error: found %#v, wants %#v", value.Resource, wants.Resource)
+	}
+	if value.Fingerprint != wants.Fingerprint {
+		t.Errorf("KeyPair.Fingerprint error: found %#v, wants %#v", value.Fingerprint, wants.Fingerprint)
+	}
+	compareMeta(t, "KeyPair.Meta", value.Meta, wants.Meta)
+	if value.Name != wants.Name {
+		t.Errorf("KeyPair.Name error: found %#v, wants %#v", value.Name, wants.Name)
+	}
+	if value.PrivateKey != wants.PrivateKey {
+		t.Errorf("KeyPair.PrivateKey error: found %#v, wants %#v", value.PrivateKey, wants.PrivateKey)
+	}
+	if value.PublicKey != wants.PublicKey {
+		t.Errorf("KeyPair.PublicKey error: found %#v, wants %#v", value.PublicKey, wants.PublicKey)
+	}
+}
@@ -26,9 +26,10 @@ type RuntimeNetwork struct {
 
 // NIC describes properties of network interface card
 type NIC struct {
-	IPv4    *IPv4           `json:"ip_v4_conf,omitempty"`
-	Model   string          `json:"model,omitempty"`
-	MAC     string          `json:"mac,omitempty"`
-	VLAN    *Resource       `json:"vlan,omitempty"`
-	Runtime *RuntimeNetwork `json:"runtime,omitempty"`
+	FirewallPolicy *Resource       `json:"firewall_policy,omitempty"`
+	IPv4           *IPv4           `json:"ip_v4_conf,omitempty"`
+	Model          string          `json:"model,omitempty"`
+	MAC            string          `json:"mac,omitempty"`
+	VLAN           *Resource       `json:"vlan,omitempty"`
+	Runtime        *RuntimeNetwork `json:"runtime,omitempty"`
 }
@@ -0,0 +1,40 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Price contains price of billable resource at given level. Level 0 is the
+// subscription price, other levels are burst prices. Multiplier converts usage
+// of the resource in its units multiplied by seconds to the unit of the price.
+type Price struct {
+	Currency   string      `json:"currency"`
+	ID         string      `json:"id,omitempty"`
+	Level      int         `json:"level"`
+	Multiplier uint64      `json:"multiplier"`
+	Price      json.Number `json:"price"`
+	Resource   string      `json:"resource"`
+	Unit       string      `json:"unit,omitempty"`
+}
+
+// Pricing holds catalogue of prices with current and next burst levels by
+// billable resource
+type Pricing struct {
+	Current map[string]int `json:"current,omitempty"`
+	Meta    Meta           `json:"meta"`
+	Next    map[string]int `json:"next,omitempty"`
+	Objects []Price        `json:"objects"`
+}
+
+// ReadPricing reads and unmarshalls pricing catalogue from JSON stream
+func ReadPricing(r io.Reader) (*Pricing, error) {
+	var pricing Pricing
+	if err := ReadJSON(r, &pricing); err != nil {
+		return nil, err
+	}
+	return &pricing, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+const jsonPricingData = `{
+    "current": {
+        "cpu": 5,
+        "dssd": 1
+    },
+    "meta": {
+        "limit": 0,
+        "offset": 0,
+        "total_count": 2
+    },
+    "next": {
+        "cpu": 4,
+        "dssd": 1
+    },
+    "objects": [
+        {
+            "currency": "USD",
+            "id": "18",
+            "level": 0,
+            "multiplier": 2783138807808000,
+            "price": "0.14000000000000000000",
+            "resource": "dssd",
+            "unit": "GB/month"
+        },
+        {
+            "currency": "USD",
+            "id": "42",
+            "level": 5,
+            "multiplier": 3600000,
+            "price": 0.0095,
+            "resource": "cpu",
+            "unit": "GHz/hour"
+        }
+    ]
+}`
+
+func TestDataPricingReaderFail(t *testing.T) {
+	if _, err := ReadPricing(failReader{}); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+}
+
+func TestDataPricing(t *testing.T) {
+	p, err := ReadPricing(strings.NewReader(jsonPricingData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Current["cpu"] != 5 || p.Current["dssd"] != 1 || p.Next["cpu"] != 4 {
+		t.Errorf("invalid burst levels %v, %v", p.Current, p.Next)
+	}
+
+	if len(p.Objects) != 2 {
+		t.Fatalf("Wrong prices count: %d, wants 2", len(p.Objects))
+	}
+
+	wants := Price{Currency: "USD", ID: "18", Multiplier: 2783138807808000, Price: "0.14000000000000000000", Resource: "dssd", Unit: "GB/month"}
+	if p.Objects[0] != wants {
+		t.Errorf("Price error: found %#v, wants %#v", p.Objects[0], wants)
+	}
+
+	wants = Price{Currency: "USD", ID: "42", Level: 5, Multiplier: 3600000, Price: "0.0095", Resource: "cpu", Unit: "GHz/hour"}
+	if p.Objects[1] != wants {
+		t.Errorf("Price error: found %#v, wants %#v", p.Objects[1], wants)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// RemoteSnapshot contains properties of drive snapshot stored in another location
+type RemoteSnapshot struct {
+	Resource
+	AllocatedSize uint64            `json:"allocated_size,omitempty"`
+	Drive         *Resource         `json:"drive,omitempty"`
+	Jobs          []Resource        `json:"jobs,omitempty"`
+	Location      string            `json:"location,omitempty"`
+	Meta          map[string]string `json:"meta,omitempty"`
+	Name          string            `json:"name,omitempty"`
+	Owner         *Resource         `json:"owner,omitempty"`
+	Status        string            `json:"status,omitempty"`
+	Timestamp     *time.Time        `json:"timestamp,omitempty"`
+}
+
+// RemoteSnapshots holds collection of RemoteSnapshot objects
+type RemoteSnapshots struct {
+	Meta    Meta             `json:"meta"`
+	Objects []RemoteSnapshot `json:"objects"`
+}
+
+// ReadRemoteSnapshots reads and unmarshalls information about remote snapshots from JSON stream
+func ReadRemoteSnapshots(r io.Reader) ([]RemoteSnapshot, error) {
+	var snapshots RemoteSnapshots
+	if err := ReadJSON(r, &snapshots); err != nil {
+		return nil, err
+	}
+	return snapshots.Objects, nil
+}
+
+// ReadRemoteSnapshot reads and unmarshalls information about single remote snapshot from JSON stream
+func ReadRemoteSnapshot(r io.Reader) (*RemoteSnapshot, error) {
+	var snapshot RemoteSnapshot
+	if err := ReadJSON(r, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// WriteRemoteSnapshots marshals collection of remote snapshot objects to JSON stream
+func WriteRemoteSnapshots(objs []RemoteSnapshot) (io.Reader, error) {
+	bb, err := json.Marshal(&RemoteSnapshots{Objects: objs})
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(bb), nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const jsonRemoteSnapshotsData = `{
+    "meta": {
+        "limit": 0,
+        "offset": 0,
+        "total_count": 1
+    },
+    "objects": [
+        {
+            "allocated_size": 1073741824,
+            "drive": {
+                "resource_uri": "/api/2.0/drives/2ef7b7c7-7ec4-47a7-9b69-087c9417c0ff/",
+                "uuid": "2ef7b7c7-7ec4-47a7-9b69-087c9417c0ff"
+            },
+            "jobs": [
+                {
+                    "resource_uri": "/api/2.0/jobs/6b0b8e1f-66f1-4c2f-9f76-5e0d0a5f2c4e/",
+                    "uuid": "6b0b8e1f-66f1-4c2f-9f76-5e0d0a5f2c4e"
+                }
+            ],
+            "location": "fra",
+            "meta": {},
+            "name": "nightly",
+            "owner": {
+                "resource_uri": "/api/2.0/user/5b4a69a3-8e78-4c45-a8ba-8b13f0895e23/",
+                "uuid": "5b4a69a3-8e78-4c45-a8ba-8b13f0895e23"
+            },
+            "resource_uri": "/api/2.0/remotesnapshots/0d3a6ec9-b4b8-4c54-9d4c-5c8d3e6cb3a1/",
+            "status": "available",
+            "timestamp": "2014-02-10T12:00:00+00:00",
+            "uuid": "0d3a6ec9-b4b8-4c54-9d4c-5c8d3e6cb3a1"
+        }
+    ]
+}
+`
+
+var remoteSnapshotTimestamp = time.Date(2014, 2, 10, 12, 0, 0, 0, time.UTC)
+
+var remoteSnapshotData = RemoteSnapshot{
+	Resource:      *MakeRemoteSnapshotResource("0d3a6ec9-b4b8-4c54-9d4c-5c8d3e6cb3a1"),
+	AllocatedSize: 1073741824,
+	Drive:         MakeDriveResource("2ef7b7c7-7ec4-47a7-9b69-087c9417c0ff"),
+	Jobs:          []Resource{*MakeJobResource("6b0b8e1f-66f1-4c2f-9f76-5e0d0a5f2c4e")},
+	Location:      "fra",
+	Meta:          map[string]string{},
+	Name:          "nightly",
+	Owner:         MakeUserResource("5b4a69a3-8e78-4c45-a8ba-8b13f0895e23"),
+	Status:        "available",
+	Timestamp:     &remoteSnapshotTimestamp,
+}
+
+func TestDataRemoteSnapshotReaderFail(t *testing.T) {
+	r := failReader{}
+
+	if _, err := ReadRemoteSnapshots(r); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+
+	if _, err := ReadRemoteSnapshot(r); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+}
+
+func TestDataRemoteSnapshotReadWrite(t *testing.T) {
+	ss, err := ReadRemoteSnapshots(strings.NewReader(jsonRemoteSnapshotsData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 1 {
+		t.Fatalf("Wrong remote snapshots count: %d, wants 1", len(ss))
+	}
+	compareRemoteSnapshots(t, &ss[0], &remoteSnapshotData)
+
+	r, err := WriteRemoteSnapshots(ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss, err = ReadRemoteSnapshots(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 1 {
+		t.Fatalf("Wrong remote snapshots count: %d, wants 1", len(ss))
+	}
+	compareRemoteSnapshots(t, &ss[0], &remoteSnapshotData)
+}
+
+func compareRemoteSnapshots(t *testing.T, value, wants *RemoteSnapshot) {
+	if value.Resource != wants.Resource {
+		t.Errorf("RemoteSnapshot.Resource error: found %#v, wants %#v", value.Resource, wants.Resource)
+	}
+	if value.AllocatedSize != wants.AllocatedSize {
+		t.Errorf("RemoteSnapshot.AllocatedSize error: found %#v, wants %#v", value.AllocatedSize, wants.AllocatedSize)
+	}
+	if value.Drive == nil || *value.Drive != *wants.Drive {
+		t.Errorf("RemoteSnapshot.Drive error: found %#v, wants %#v", value.Drive, wants.Drive)
+	}
+	if len(value.Jobs) != len(wants.Jobs) || len(value.Jobs) > 0 && value.Jobs[0] != wants.Jobs[0] {
+		t.Errorf("RemoteSnapshot.Jobs error: found %#v, wants %#v", value.Jobs, wants.Jobs)
+	}
+	if value.Location != wants.Location {
+		t.Errorf("RemoteSnapshot.Location error: found %#v, wants %#v", value.Location, wants.Location)
+	}
+	compareMeta(t, "RemoteSnapshot.Meta", value.Meta, wants.Meta)
+	if value.Name != wants.Name {
+		t.Errorf("RemoteSnapshot.Name error: found %#v, wants %#v", value.Name, wants.Name)
+	}
+	if value.Owner == nil || *value.Owner != *wants.Owner {
+		t.Errorf("RemoteSnapshot.Owner error: found %#v, wants %#v", value.Owner, wants.Owner)
+	}
+	if value.Status != wants.Status {
+		t.Errorf("RemoteSnapshot.Status error: found %#v, wants %#v", value.Status, wants.Status)
+	}
+	if value.Timestamp == nil || !value.Timestamp.Equal(*wants.Timestamp) {
+		t.Errorf("RemoteSnapshot.Timestamp error: found %v, wants %v", value.Timestamp, wants.Timestamp)
+	}
+}
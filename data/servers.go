@@ -3,7 +3,11 @@
 
 package data
 
-import "io"
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
 
 // ServerDrive describe properties of disk drive
 type ServerDrive struct {
@@ -16,6 +20,7 @@ type ServerDrive struct {
 // Server contains detail properties of cloud server instance
 type Server struct {
 	Resource
+	ACLs               []Resource        `json:"acls,omitempty"`
 	Context            bool              `json:"context,omitempty"`
 	CPU                uint64            `json:"cpu,omitempty"`
 	CPUsInsteadOfCores bool              `json:"cpus_instead_of_cores,omitempty"`
@@ -25,6 +30,7 @@ type Server struct {
 	Meta               map[string]string `json:"meta,omitempty"`
 	Name               string            `json:"name,omitempty"`
 	NICs               []NIC             `json:"nics,omitempty"`
+	PubKeys            []Resource        `json:"pubkeys,omitempty"`
 	SMP                uint64            `json:"smp,omitempty"`
 	Status             string            `json:"status,omitempty"`
 	VNCPassword        string            `json:"vnc_password,omitempty"`
@@ -53,3 +59,29 @@ func ReadServer(r io.Reader) (*Server, error) {
 	}
 	return &server, nil
 }
+
+// WriteServer marshals single server instance object to JSON stream
+func WriteServer(obj *Server) (io.Reader, error) {
+	bb, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(bb), nil
+}
+
+// WriteSharedServer marshals single server instance object to JSON stream, the
+// ACLs are written even if empty, so the server can be unshared from the last ACL
+func WriteSharedServer(obj *Server) (io.Reader, error) {
+	acls := obj.ACLs
+	if acls == nil {
+		acls = []Resource{}
+	}
+	bb, err := json.Marshal(struct {
+		*Server
+		ACLs []Resource `json:"acls"`
+	}{obj, acls})
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(bb), nil
+}
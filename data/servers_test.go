@@ -84,9 +84,43 @@ func TestDataServersReadServerDetail(t *testing.T) {
 		t.Error(err)
 	}
 	compareServers(t, 0, s, &serverData)
+
+	r, err := WriteServer(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = ReadServer(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareServers(t, 0, s, &serverData)
+
+	s.ACLs = nil
+	r, err = WriteSharedServer(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := m["acls"]; !ok || string(v) != "[]" {
+		t.Errorf("empty ACLs must be written, got %q", v)
+	}
+	if string(m["name"]) != `"`+serverData.Name+`"` {
+		t.Errorf("server properties must be written, got %s", m["name"])
+	}
 }
 
 func compareNICs(t *testing.T, i int, value, wants *NIC) {
+	if value.FirewallPolicy != nil && wants.FirewallPolicy != nil {
+		if *value.FirewallPolicy != *wants.FirewallPolicy {
+			t.Errorf("NIC.FirewallPolicy error [%d]: found %#v, wants %#v", i, value.FirewallPolicy, wants.FirewallPolicy)
+		}
+	} else if value.FirewallPolicy != nil || wants.FirewallPolicy != nil {
+		t.Errorf("NIC.FirewallPolicy error [%d]: found %#v, wants %#v", i, value.FirewallPolicy, wants.FirewallPolicy)
+	}
+
 	if value.IPv4 != nil && wants.IPv4 != nil {
 		if value.IPv4.Conf != wants.IPv4.Conf {
 			t.Errorf("NIC.IPv4.Conf error [%d]: found %#v, wants %#v", i, value.IPv4.Conf, wants.IPv4.Conf)
@@ -162,6 +196,15 @@ func compareServers(t *testing.T, i int, value, wants *Server) {
 		compareNICs(t, i, &value.NICs[i], &wants.NICs[i])
 	}
 
+	if len(value.PubKeys) != len(wants.PubKeys) {
+		t.Errorf("Server.PubKeys error [%d]: found %#v, wants %#v", i, value.PubKeys, wants.PubKeys)
+	}
+	for i := 0; i < len(value.PubKeys) && i < len(wants.PubKeys); i++ {
+		if value.PubKeys[i] != wants.PubKeys[i] {
+			t.Errorf("Server.PubKeys error [%d]: found %#v, wants %#v", i, value.PubKeys[i], wants.PubKeys[i])
+		}
+	}
+
 	if value.SMP != wants.SMP {
 		t.Errorf("Server.SMP error [%d]: found %#v, wants %#v", i, value.SMP, wants.SMP)
 	}
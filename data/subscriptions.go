@@ -0,0 +1,59 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Subscription contains properties of subscription to billable resource.
+// Subscriptions are identified by ID, not UUID.
+type Subscription struct {
+	Amount           json.Number `json:"amount,omitempty"`
+	AutoRenew        bool        `json:"auto_renew"`
+	EndTime          *time.Time  `json:"end_time,omitempty"`
+	ID               string      `json:"id,omitempty"`
+	Period           string      `json:"period,omitempty"`
+	Resource         string      `json:"resource,omitempty"`
+	StartTime        *time.Time  `json:"start_time,omitempty"`
+	Status           string      `json:"status,omitempty"`
+	SubscribedObject string      `json:"subscribed_object,omitempty"`
+	URI              string      `json:"resource_uri,omitempty"`
+}
+
+// Subscriptions holds collection of Subscription objects
+type Subscriptions struct {
+	Meta    Meta           `json:"meta"`
+	Objects []Subscription `json:"objects"`
+}
+
+// ReadSubscriptions reads and unmarshalls information about subscriptions from JSON stream
+func ReadSubscriptions(r io.Reader) ([]Subscription, error) {
+	var subscriptions Subscriptions
+	if err := ReadJSON(r, &subscriptions); err != nil {
+		return nil, err
+	}
+	return subscriptions.Objects, nil
+}
+
+// ReadSubscription reads and unmarshalls information about single subscription from JSON stream
+func ReadSubscription(r io.Reader) (*Subscription, error) {
+	var subscription Subscription
+	if err := ReadJSON(r, &subscription); err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// WriteSubscriptions marshals collection of subscription objects to JSON stream
+func WriteSubscriptions(objs []Subscription) (io.Reader, error) {
+	bb, err := json.Marshal(&Subscriptions{Objects: objs})
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(bb), nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package data
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const jsonSubscriptionsData = `{
+    "meta": {
+        "limit": 0,
+        "offset": 0,
+        "total_count": 1
+    },
+    "objects": [
+        {
+            "amount": "1",
+            "auto_renew": true,
+            "end_time": "2014-03-10T12:00:00+00:00",
+            "id": "7272",
+            "period": "1 month",
+            "resource": "vlan",
+            "resource_uri": "/api/2.0/subscriptions/7272/",
+            "start_time": "2014-02-10T12:00:00+00:00",
+            "status": "active",
+            "subscribed_object": "96537817-f4b6-496b-a861-e74192d3ccb0"
+        }
+    ]
+}`
+
+func TestDataSubscriptionReaderFail(t *testing.T) {
+	r := failReader{}
+
+	if _, err := ReadSubscriptions(r); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+
+	if _, err := ReadSubscription(r); err == nil || err.Error() != "test error" {
+		t.Error("Fail")
+	}
+}
+
+func TestDataSubscriptionReadWrite(t *testing.T) {
+	check := func(ss []Subscription) {
+		if len(ss) != 1 {
+			t.Fatalf("Wrong subscriptions count: %d, wants 1", len(ss))
+		}
+		s := ss[0]
+		if s.ID != "7272" || s.URI != MakeSubscriptionURI("7272") {
+			t.Errorf("invalid subscription resource %#v", s)
+		}
+		if s.Amount != "1" || !s.AutoRenew || s.Period != "1 month" || s.Resource != "vlan" || s.Status != "active" {
+			t.Errorf("invalid subscription %#v", s)
+		}
+		if s.SubscribedObject != "96537817-f4b6-496b-a861-e74192d3ccb0" {
+			t.Errorf("invalid subscribed object %q", s.SubscribedObject)
+		}
+		if s.StartTime == nil || !s.StartTime.Equal(time.Date(2014, 2, 10, 12, 0, 0, 0, time.UTC)) ||
+			s.EndTime == nil || !s.EndTime.Equal(time.Date(2014, 3, 10, 12, 0, 0, 0, time.UTC)) {
+			t.Errorf("invalid subscription times %v - %v", s.StartTime, s.EndTime)
+		}
+	}
+
+	ss, err := ReadSubscriptions(strings.NewReader(jsonSubscriptionsData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(ss)
+
+	r, err := WriteSubscriptions(ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss, err = ReadSubscriptions(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(ss)
+}
@@ -0,0 +1,100 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+func (c *Client) downloadDrive(ctx context.Context, uuid string, size int64, w io.WriterAt, t *transfer) error {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return errEmptyUUID
+	}
+	if size <= 0 {
+		return errInvalidSize
+	}
+
+	u := c.direct + "drives/" + uuid + "/download/"
+	chunks := t.chunks(size)
+
+	// the first failed chunk cancels the others
+	rctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indexes := make(chan int64)
+	errs := make(chan error, t.parallel)
+
+	var wg sync.WaitGroup
+	for i := 0; i < t.parallel && int64(i) < chunks; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, t.chunkSize)
+			for index := range indexes {
+				offset, n := t.chunk(size, index)
+				chunk := buf[:n]
+				err := t.retry(rctx, func() error {
+					return c.downloadChunk(rctx, u, offset, chunk)
+				})
+				if err == nil {
+					_, err = w.WriteAt(chunk, offset)
+				}
+				if err != nil {
+					errs <- err
+					cancel()
+					return
+				}
+				t.report(n, size)
+			}
+		}()
+	}
+
+feed:
+	for index := int64(0); index < chunks; index++ {
+		select {
+		case indexes <- index:
+		case <-rctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+	}
+
+	return ctx.Err()
+}
+
+// downloadChunk reads chunk of the drive at given offset into the buffer
+func (c *Client) downloadChunk(ctx context.Context, u string, offset int64, buf []byte) error {
+	last := offset + int64(len(buf)) - 1
+	h := http.Header{"Range": {fmt.Sprintf("bytes=%d-%d", offset, last)}}
+
+	r, err := c.https.Do(ctx, "GET", u, nil, h, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if err := r.VerifyCode(206); err != nil {
+		return NewError(r, err)
+	}
+
+	if cr := r.Header.Get("Content-Range"); !strings.HasPrefix(cr, fmt.Sprintf("bytes %d-%d/", offset, last)) {
+		return fmt.Errorf("invalid Content-Range %q of chunk at offset %d", cr, offset)
+	}
+
+	_, err = io.ReadFull(r.Body, buf)
+	return err
+}
@@ -0,0 +1,109 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/mock"
+)
+
+// memWriter implements io.WriterAt over byte slice of fixed size
+type memWriter struct {
+	mu sync.Mutex
+	bb []byte
+}
+
+func (w *memWriter) WriteAt(p []byte, off int64) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return copy(w.bb[off:], p), nil
+}
+
+func TestDriveDownload(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	image := testImage(10000)
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("uuid"), Size: uint64(len(image))})
+	srv.SetDriveImage("uuid", image)
+
+	d, err := cli.Drive("uuid", LibraryAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.AddFault(mock.Fault{Method: "GET", Path: "drives/uuid/download/", EveryNth: 3, Drop: true, Count: 1})
+
+	var progress []int64
+	w := &memWriter{bb: make([]byte, len(image))}
+	err = d.Download(context.Background(), w,
+		TransferChunkSize(1000), TransferParallel(3), TransferRetries(1, 0),
+		TransferProgress(func(done, total int64) { progress = append(progress, done) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(w.bb, image) {
+		t.Error("downloaded image does not match")
+	}
+	if len(progress) != 10 || progress[9] != 10000 {
+		t.Errorf("invalid progress %v", progress)
+	}
+}
+
+func TestDriveDownloadErrors(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("uuid"), Size: 3000})
+	srv.LibDrives.Add(&data.Drive{Resource: *data.MakeLibDriveResource("libuuid"), Size: 3000})
+
+	ctx := context.Background()
+	w := &memWriter{bb: make([]byte, 3000)}
+
+	d, err := cli.Drive("libuuid", LibraryMedia)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Download(ctx, w); err == nil {
+		t.Error("download of library drive must fail")
+	}
+
+	d, err = cli.Drive("uuid", LibraryAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.AddFault(mock.Fault{Method: "GET", Path: "drives/uuid/download/", Code: 500})
+	err = d.Download(ctx, w, TransferChunkSize(1000), TransferRetries(1, 0))
+	if e, ok := err.(*Error); !ok || e.StatusCode != 500 {
+		t.Errorf("invalid error %v", err)
+	}
+	srv.ResetFaults()
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := d.Download(canceled, w, TransferChunkSize(1000)); err != context.Canceled {
+		t.Errorf("invalid error %v", err)
+	}
+
+	if err := cli.downloadDrive(ctx, " ", 1, w, newTransfer(nil)); err != errEmptyUUID {
+		t.Errorf("invalid error %v", err)
+	}
+}
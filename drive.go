@@ -4,7 +4,9 @@
 package gosigma
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/altoros/gosigma/data"
@@ -34,6 +36,9 @@ type Drive interface {
 	// CloudSigma resource
 	Resource
 
+	// ACLs returns access control lists the drive instance is shared with
+	ACLs() []Resource
+
 	// Affinities
 	Affinities() []string
 
@@ -46,6 +51,9 @@ type Drive interface {
 	// Media of drive instance
 	Media() string
 
+	// MountedOn returns servers the drive instance is attached to
+	MountedOn() []Resource
+
 	// Name of drive instance
 	Name() string
 
@@ -76,6 +84,10 @@ type Drive interface {
 	// ImageType returns type of drive image (defined for library drives)
 	ImageType() string
 
+	// EstimateCost returns cost of storing the drive for the period in every
+	// currency of the pricing catalogue
+	EstimateCost(pricing Pricing, period time.Duration) ([]Cost, error)
+
 	// Clone drive instance
 	Clone(params CloneParams, avoid []string) (Drive, error)
 
@@ -91,6 +103,15 @@ type Drive interface {
 	// Refresh information about drive instance
 	Refresh() error
 
+	// Share drive instance with access control list, given by uuid
+	Share(acl string) error
+
+	// Unshare drive instance from access control list, given by uuid
+	Unshare(acl string) error
+
+	// Download writes raw contents of drive instance to the writer
+	Download(ctx context.Context, w io.WriterAt, opts ...TransferOption) error
+
 	// Resize drive instance
 	Resize(newSize uint64) error
 
@@ -126,6 +147,15 @@ func (d drive) URI() string { return d.obj.URI }
 // UUID of drive instance
 func (d drive) UUID() string { return d.obj.UUID }
 
+// ACLs returns access control lists the drive instance is shared with
+func (d drive) ACLs() []Resource {
+	result := make([]Resource, 0, len(d.obj.ACLs))
+	for i := range d.obj.ACLs {
+		result = append(result, &resource{&d.obj.ACLs[i]})
+	}
+	return result
+}
+
 // Affinities
 func (d drive) Affinities() []string { return d.obj.Affinities }
 
@@ -141,6 +171,15 @@ func (d drive) Get(key string) (v string, ok bool) {
 // Media of drive instance
 func (d drive) Media() string { return d.obj.Media }
 
+// MountedOn returns servers the drive instance is attached to
+func (d drive) MountedOn() []Resource {
+	result := make([]Resource, 0, len(d.obj.MountedOn))
+	for i := range d.obj.MountedOn {
+		result = append(result, &resource{&d.obj.MountedOn[i]})
+	}
+	return result
+}
+
 // Name of drive instance
 func (d drive) Name() string { return d.obj.Name }
 
@@ -176,6 +215,17 @@ func (d drive) Paid() bool { return d.obj.Paid }
 // ImageType returns type of drive image (defined for library drives)
 func (d drive) ImageType() string { return d.obj.ImageType }
 
+// EstimateCost returns cost of storing the drive for the period in every
+// currency of the pricing catalogue. Drives without storage type are priced
+// as SSD storage.
+func (d drive) EstimateCost(pricing Pricing, period time.Duration) ([]Cost, error) {
+	storageType := d.StorageType()
+	if storageType == "" {
+		storageType = BillingDSSD
+	}
+	return pricing.estimate(map[string]uint64{storageType: d.Size()}, period)
+}
+
 // Clone drive instance.
 func (d drive) Clone(params CloneParams, avoid []string) (Drive, error) {
 	obj, err := d.clone(params, avoid)
@@ -250,6 +300,23 @@ func (d *drive) Refresh() error {
 	return nil
 }
 
+// Share drive instance with access control list, given by uuid
+func (d *drive) Share(acl string) error {
+	return d.share(acl, true)
+}
+
+// Unshare drive instance from access control list, given by uuid
+func (d *drive) Unshare(acl string) error {
+	return d.share(acl, false)
+}
+
+// Download writes raw contents of drive instance to the writer. Contents are
+// read in chunks with parallel ranged requests to the direct endpoint of the
+// region, failed chunks are retried.
+func (d *drive) Download(ctx context.Context, w io.WriterAt, opts ...TransferOption) error {
+	return d.download(ctx, w, newTransfer(opts))
+}
+
 // Resize drive instance
 func (d *drive) Resize(newSize uint64) error {
 	return d.resize(newSize)
@@ -270,20 +337,20 @@ func (d *drive) ResizeWait(newSize uint64) error {
 
 // Wait for user-defined event
 func (d *drive) Wait(stop func(Drive) bool) error {
-	var timedout = false
-
+	var timer <-chan time.Time
 	timeout := d.client.GetOperationTimeout()
 	if timeout > 0 {
-		timer := time.AfterFunc(timeout, func() { timedout = true })
-		defer timer.Stop()
+		timer = time.After(timeout)
 	}
 
 	for !stop(d) {
-		if err := d.Refresh(); err != nil {
-			return err
-		}
-		if timedout {
+		select {
+		case <-timer:
 			return ErrOperationTimeout
+		default:
+			if err := d.Refresh(); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -247,7 +247,7 @@ func TestDriveClone(t *testing.T) {
 		return
 	}
 
-	params := CloneParams{Name: "test-name", Media: "ssd"}
+	params := CloneParams{Name: "test-name", Media: MediaDisk}
 	newDrive, err := d.Clone(params, []string{"avoid-uuid-0", "avoid-uuid-1"})
 	if err != nil {
 		t.Error("Drive clone fail:", err)
@@ -266,7 +266,7 @@ func TestDriveClone(t *testing.T) {
 	if newDrive.Name() != "test-name" {
 		t.Errorf("Drive.Clone(), invalid name %q", newDrive.Name())
 	}
-	if newDrive.Media() != "ssd" {
+	if newDrive.Media() != MediaDisk {
 		t.Errorf("Drive.Clone(), invalid media %q", newDrive.Media())
 	}
 
@@ -290,7 +290,7 @@ func TestDriveCloneWait(t *testing.T) {
 		return
 	}
 
-	params := CloneParams{Name: "test-name", Media: "ssd"}
+	params := CloneParams{Name: "test-name", Media: MediaDisk}
 	newDrive, err := d.CloneWait(params, nil)
 	if err != nil {
 		t.Error("Drive clone fail:", err)
@@ -309,7 +309,7 @@ func TestDriveCloneWait(t *testing.T) {
 	if newDrive.Name() != "test-name" {
 		t.Errorf("Drive.Clone(), invalid name %q", newDrive.Name())
 	}
-	if newDrive.Media() != "ssd" {
+	if newDrive.Media() != MediaDisk {
 		t.Errorf("Drive.Clone(), invalid media %q", newDrive.Media())
 	}
 
@@ -335,7 +335,7 @@ func TestDriveCloneFail(t *testing.T) {
 
 	mock.ResetDrives()
 
-	params := CloneParams{Name: "test-name", Media: "ssd"}
+	params := CloneParams{Name: "test-name", Media: MediaDisk}
 	newDrive, err := d.Clone(params, nil)
 	if err == nil || newDrive != nil {
 		t.Errorf("Drive clone must fail err=%v, rc=%v", err, newDrive)
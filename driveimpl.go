@@ -4,7 +4,9 @@
 package gosigma
 
 import (
+	"context"
 	"errors"
+	"io"
 
 	"github.com/altoros/gosigma/data"
 )
@@ -56,3 +58,42 @@ func (d *drive) resize(newSize uint64) error {
 
 	return nil
 }
+
+func (d *drive) share(acl string, share bool) error {
+	// check the library
+	if d.Library() == LibraryMedia {
+		return errors.New("can not share drive from media library")
+	}
+
+	// drive object is sent back as a whole, so it must be complete
+	if d.Size() == 0 {
+		if err := d.Refresh(); err != nil {
+			return err
+		}
+	}
+
+	obj, err := d.client.shareDrive(*d.obj, acl, share)
+	if err != nil {
+		return err
+	}
+
+	d.obj = obj
+
+	return nil
+}
+
+func (d *drive) download(ctx context.Context, w io.WriterAt, t *transfer) error {
+	// check the library
+	if d.Library() == LibraryMedia {
+		return errors.New("can not download drive from media library")
+	}
+
+	// size of drive is required to split it into chunks
+	if d.Size() == 0 {
+		if err := d.Refresh(); err != nil {
+			return err
+		}
+	}
+
+	return d.client.downloadDrive(ctx, d.UUID(), int64(d.Size()), w, t)
+}
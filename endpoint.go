@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"strings"
 )
 
 // Default CloudSigma region
@@ -17,14 +18,33 @@ var errHttpsRequired = errors.New("endpoint must use https scheme")
 var errInvalidAuth = errors.New("auth information is not allowed in the endpoint string")
 var errEndpointWithQuery = errors.New("query information is not allowed in the endpoint string")
 
-// ResolveEndpoint returns endpoint for given region code
+// ResolveEndpoint returns endpoint for given region code. Endpoint for region
+// unknown to the catalogue (see Regions) is made up from the code.
 func ResolveEndpoint(endpoint string) string {
 	if err := VerifyEndpoint(endpoint); err == nil {
 		return endpoint
 	}
+	if r, err := LookupRegion(endpoint); err == nil {
+		return r.Endpoint
+	}
 	return fmt.Sprintf("https://%s.cloudsigma.com/api/2.0/", endpoint)
 }
 
+// DirectEndpoint returns endpoint serving drive image uploads and downloads for
+// the given one. CloudSigma serves them from 'direct.' host of the region,
+// endpoints of other hosts are returned unchanged.
+func DirectEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	if !strings.HasSuffix(u.Host, ".cloudsigma.com") || strings.HasPrefix(u.Host, "direct.") {
+		return endpoint
+	}
+	u.Host = "direct." + u.Host
+	return u.String()
+}
+
 // VerifyEndpoint verifies CloudSigma endpoint URL
 func VerifyEndpoint(e string) error {
 	if len(e) == 0 {
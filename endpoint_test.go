@@ -15,4 +15,19 @@ func TestResolveEndpoint(t *testing.T) {
 
 	check("zrh", "https://zrh.cloudsigma.com/api/2.0/")
 	check("lvs", "https://lvs.cloudsigma.com/api/2.0/")
+	check("xyz", "https://xyz.cloudsigma.com/api/2.0/")
+	check("https://example.com/api/2.0/", "https://example.com/api/2.0/")
+}
+
+func TestDirectEndpoint(t *testing.T) {
+	check := func(ep string, url string) {
+		ep = DirectEndpoint(ep)
+		if ep != url {
+			t.Errorf("ep value = '%s', wants '%s'", ep, url)
+		}
+	}
+
+	check("https://zrh.cloudsigma.com/api/2.0/", "https://direct.zrh.cloudsigma.com/api/2.0/")
+	check("https://direct.zrh.cloudsigma.com/api/2.0/", "https://direct.zrh.cloudsigma.com/api/2.0/")
+	check("https://127.0.0.1:8443/api/2.0/", "https://127.0.0.1:8443/api/2.0/")
 }
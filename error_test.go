@@ -10,6 +10,7 @@ import (
 
 	"github.com/altoros/gosigma/https"
 	"github.com/altoros/gosigma/https/httpstest"
+	"github.com/altoros/gosigma/mock"
 )
 
 func TestErrorNilResponse(t *testing.T) {
@@ -152,3 +153,52 @@ func TestErrorHTTPServiceError(t *testing.T) {
 		t.Errorf("Error must return service error message via error interface, ret: %s, wants: %s", e.Error(), emsg)
 	}
 }
+
+func TestErrorMockFault(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	srv.AddFault(mock.Fault{Method: "GET", Path: "servers", Code: 403})
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = cli.Servers(RequestShort)
+	e, ok := err.(*Error)
+	if !ok {
+		t.Errorf("error must be *Error, got %#v", err)
+		return
+	}
+	if e.StatusCode != 403 {
+		t.Errorf("e.StatusCode == %d, wants 403", e.StatusCode)
+	}
+	if e.ServiceError == nil || e.ServiceError.Type != "permission" {
+		t.Errorf("invalid e.ServiceError: %#v", e.ServiceError)
+	}
+}
+
+func TestErrorMockContentType(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	srv.AddFault(mock.Fault{Path: "servers", ContentType: "text/html"})
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = cli.Servers(RequestShort)
+	e, ok := err.(*Error)
+	if !ok {
+		t.Errorf("error must be *Error, got %#v", err)
+		return
+	}
+	if e.StatusCode != 200 || e.SystemError == nil {
+		t.Errorf("invalid error: %#v", e)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"fmt"
+
+	"github.com/altoros/gosigma/data"
+)
+
+const (
+	// FirewallDirectionIn defines constant for rules matching incoming packets
+	FirewallDirectionIn = "in"
+	// FirewallDirectionOut defines constant for rules matching outgoing packets
+	FirewallDirectionOut = "out"
+	// FirewallDirectionBoth defines constant for rules matching packets of both directions
+	FirewallDirectionBoth = "both"
+)
+
+const (
+	// FirewallActionAccept defines constant for rules accepting packets
+	FirewallActionAccept = "accept"
+	// FirewallActionDrop defines constant for rules dropping packets
+	FirewallActionDrop = "drop"
+)
+
+const (
+	// FirewallProtocolTCP defines constant for rules matching TCP packets
+	FirewallProtocolTCP = "tcp"
+	// FirewallProtocolUDP defines constant for rules matching UDP packets
+	FirewallProtocolUDP = "udp"
+)
+
+// A FirewallRule defines rule of firewall policy. Empty protocol, address
+// and port values match any packet.
+type FirewallRule struct {
+	Direction string
+	Action    string
+	Protocol  string
+	SrcIP     string
+	SrcPort   string
+	DstIP     string
+	DstPort   string
+	Comment   string
+}
+
+func makeFirewallRules(rr []data.FirewallRule) []FirewallRule {
+	result := make([]FirewallRule, 0, len(rr))
+	for _, r := range rr {
+		result = append(result, FirewallRule{
+			Direction: r.Direction,
+			Action:    r.Action,
+			Protocol:  r.IPProto,
+			SrcIP:     r.SrcIP,
+			SrcPort:   r.SrcPort,
+			DstIP:     r.DstIP,
+			DstPort:   r.DstPort,
+			Comment:   r.Comment,
+		})
+	}
+	return result
+}
+
+func makeFirewallRulesData(rr []FirewallRule) []data.FirewallRule {
+	result := make([]data.FirewallRule, 0, len(rr))
+	for _, r := range rr {
+		result = append(result, data.FirewallRule{
+			Action:    r.Action,
+			Comment:   r.Comment,
+			Direction: r.Direction,
+			DstIP:     r.DstIP,
+			DstPort:   r.DstPort,
+			IPProto:   r.Protocol,
+			SrcIP:     r.SrcIP,
+			SrcPort:   r.SrcPort,
+		})
+	}
+	return result
+}
+
+// A FirewallPolicy interface represents firewall policy in CloudSigma account
+type FirewallPolicy interface {
+	// CloudSigma resource
+	Resource
+
+	// Get meta-information value stored in the firewall policy
+	Get(key string) (v string, ok bool)
+
+	// Name of firewall policy
+	Name() string
+
+	// Owner of firewall policy
+	Owner() Resource
+
+	// Rules of firewall policy
+	Rules() []FirewallRule
+
+	// Servers, which NICs the firewall policy is attached to
+	Servers() []Resource
+
+	// Refresh information about firewall policy
+	Refresh() error
+
+	// Update name and rules of firewall policy
+	Update(name string, rules []FirewallRule) error
+
+	// Remove firewall policy
+	Remove() error
+}
+
+// A firewallPolicy implements firewall policy in CloudSigma account
+type firewallPolicy struct {
+	client *Client
+	obj    *data.FirewallPolicy
+}
+
+var _ FirewallPolicy = (*firewallPolicy)(nil)
+
+// String method is used to print values passed as an operand to any format that
+// accepts a string or to an unformatted printer such as Print.
+func (p firewallPolicy) String() string {
+	return fmt.Sprintf(`{UUID: %q, Name: %q, Rules: %v}`, p.UUID(), p.Name(), p.Rules())
+}
+
+// URI of firewall policy
+func (p firewallPolicy) URI() string { return p.obj.URI }
+
+// UUID of firewall policy
+func (p firewallPolicy) UUID() string { return p.obj.UUID }
+
+// Get meta-information value stored in the firewall policy
+func (p firewallPolicy) Get(key string) (v string, ok bool) {
+	v, ok = p.obj.Meta[key]
+	return
+}
+
+// Name of firewall policy
+func (p firewallPolicy) Name() string { return p.obj.Name }
+
+// Owner of firewall policy
+func (p firewallPolicy) Owner() Resource {
+	if p.obj.Owner == nil {
+		return nil
+	}
+	return &resource{p.obj.Owner}
+}
+
+// Rules of firewall policy
+func (p firewallPolicy) Rules() []FirewallRule { return makeFirewallRules(p.obj.Rules) }
+
+// Servers, which NICs the firewall policy is attached to
+func (p firewallPolicy) Servers() []Resource {
+	result := make([]Resource, 0, len(p.obj.Servers))
+	for i := range p.obj.Servers {
+		result = append(result, &resource{&p.obj.Servers[i]})
+	}
+	return result
+}
+
+// Refresh information about firewall policy
+func (p *firewallPolicy) Refresh() error {
+	obj, err := p.client.getFirewallPolicy(p.UUID())
+	if err != nil {
+		return err
+	}
+	p.obj = obj
+	return nil
+}
+
+// Update name and rules of firewall policy
+func (p *firewallPolicy) Update(name string, rules []FirewallRule) error {
+	obj, err := p.client.updateFirewallPolicy(p.UUID(), name, rules)
+	if err != nil {
+		return err
+	}
+	p.obj = obj
+	return nil
+}
+
+// Remove firewall policy
+func (p *firewallPolicy) Remove() error {
+	return p.client.RemoveFirewallPolicy(p.UUID())
+}
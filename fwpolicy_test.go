@@ -0,0 +1,143 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"testing"
+
+	"github.com/altoros/gosigma/mock"
+)
+
+var testFirewallRules = []FirewallRule{
+	{Direction: FirewallDirectionIn, Action: FirewallActionAccept, Protocol: FirewallProtocolTCP, DstPort: "22", SrcIP: "10.0.0.0/8"},
+	{Direction: FirewallDirectionIn, Action: FirewallActionDrop, Comment: "drop everything else"},
+}
+
+func TestClientFirewallPolicies(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := cli.CreateFirewallPolicy("ssh", testFirewallRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.UUID() == "" || p.Name() != "ssh" {
+		t.Errorf("invalid firewall policy %v", p)
+	}
+	if rr := p.Rules(); len(rr) != 2 || rr[0] != testFirewallRules[0] || rr[1] != testFirewallRules[1] {
+		t.Errorf("FirewallPolicy.Rules: %v, wants %v", rr, testFirewallRules)
+	}
+
+	pp, err := cli.FirewallPolicies(RequestDetail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pp) != 1 || pp[0].UUID() != p.UUID() {
+		t.Errorf("Client.FirewallPolicies: %v", pp)
+	}
+
+	if err := p.Update("ssh-only", testFirewallRules[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err = cli.FirewallPolicy(p.UUID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name() != "ssh-only" || len(p.Rules()) != 1 {
+		t.Errorf("firewall policy is not updated: %v", p)
+	}
+
+	if err := p.Remove(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cli.FirewallPolicy(p.UUID()); err == nil {
+		t.Error("removed firewall policy must not be found")
+	} else if e, ok := err.(*Error); !ok || e.StatusCode != 404 {
+		t.Errorf("invalid error %v", err)
+	}
+}
+
+func TestClientFirewallPolicyValidation(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(name string, rules []FirewallRule) {
+		if _, err := cli.CreateFirewallPolicy(name, rules); err == nil {
+			t.Errorf("%q %v: CreateFirewallPolicy must fail", name, rules)
+		} else if e, ok := err.(*Error); !ok || e.StatusCode != 400 {
+			t.Errorf("%q %v: invalid error %v", name, rules, err)
+		}
+	}
+
+	check("", nil)
+	check("test", []FirewallRule{{Direction: "sideways", Action: FirewallActionDrop}})
+	check("test", []FirewallRule{{Direction: FirewallDirectionIn, Action: "reject"}})
+	check("test", []FirewallRule{{Direction: FirewallDirectionIn, Action: FirewallActionDrop, Protocol: "icmp"}})
+	check("test", []FirewallRule{{Direction: FirewallDirectionIn, Action: FirewallActionDrop, DstPort: "80"}})
+}
+
+func TestClientServerFirewallPolicy(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := cli.CreateFirewallPolicy("ssh", testFirewallRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var c Components
+	c.SetName("test")
+	c.SetCPU(2000)
+	c.SetMem(2147483648)
+	c.SetVNCPassword("test")
+	c.NetworkDHCP4(ModelVirtio, NICFirewallPolicy(p.UUID()))
+	c.NetworkVLan(ModelVirtio, "vlan")
+
+	s, err := cli.CreateServer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nics := s.NICs()
+	if len(nics) != 2 {
+		t.Fatalf("invalid NICs %v", nics)
+	}
+	if r := nics[0].FirewallPolicy(); r == nil || r.UUID() != p.UUID() {
+		t.Errorf("NIC.FirewallPolicy: %v, wants %s", r, p.UUID())
+	}
+	if r := nics[1].FirewallPolicy(); r != nil {
+		t.Errorf("NIC.FirewallPolicy: %v, wants nil", r)
+	}
+
+	if err := p.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if rr := p.Servers(); len(rr) != 1 || rr[0].UUID() != s.UUID() {
+		t.Errorf("FirewallPolicy.Servers: %v, wants %s", rr, s.UUID())
+	}
+
+	c.NetworkDHCP4(ModelVirtio, NICFirewallPolicy("missing"))
+	if _, err := cli.CreateServer(c); err == nil {
+		t.Error("CreateServer with missing firewall policy must fail")
+	} else if e, ok := err.(*Error); !ok || e.StatusCode != 400 {
+		t.Errorf("invalid error %v", err)
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/altoros/gosigma
 
-go 1.11
+go 1.17
+
+require gopkg.in/yaml.v3 v3.0.1
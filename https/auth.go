@@ -0,0 +1,28 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package https
+
+import "net/http"
+
+// An Authenticator adds authentication information to outgoing HTTP requests
+type Authenticator interface {
+	Authenticate(r *http.Request) error
+}
+
+// BasicAuth implements Authenticator with HTTP basic authentication.
+// Requests are sent without authentication if Username is empty.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+var _ Authenticator = BasicAuth{}
+
+// Authenticate adds basic authentication header to the request
+func (a BasicAuth) Authenticate(r *http.Request) error {
+	if len(a.Username) != 0 {
+		r.SetBasicAuth(a.Username, a.Password)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+// Package cassette records HTTP interactions of https.Client into cassette files
+// and replays them offline.
+//
+// Recorder and Replayer implement http.RoundTripper and are plugged into the
+// client with https.WithTransport or gosigma.WithTransport options.
+package cassette
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"unicode/utf8"
+)
+
+// Request contains serialised form of recorded HTTP request. Text body is kept
+// in Body, binary one in BodyBase64.
+type Request struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 []byte      `json:"body_base64,omitempty"`
+}
+
+// Response contains serialised form of recorded HTTP response. Text body is
+// kept in Body, binary one in BodyBase64.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Status     string      `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 []byte      `json:"body_base64,omitempty"`
+}
+
+// content returns body of the request
+func (r Request) content() []byte {
+	return joinBody(r.Body, r.BodyBase64)
+}
+
+// content returns body of the response
+func (r Response) content() []byte {
+	return joinBody(r.Body, r.BodyBase64)
+}
+
+// splitBody returns body as text, if it is valid UTF-8, or as binary otherwise,
+// since JSON strings cannot hold arbitrary bytes
+func splitBody(bb []byte) (string, []byte) {
+	if utf8.Valid(bb) {
+		return string(bb), nil
+	}
+	return "", bb
+}
+
+func joinBody(text string, binary []byte) []byte {
+	if binary != nil {
+		return binary
+	}
+	return []byte(text)
+}
+
+// Interaction contains single request with its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette holds sequence of recorded interactions
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Load reads cassette from file
+func Load(path string) (*Cassette, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var c Cassette
+	if err := json.NewDecoder(f).Decode(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Save writes cassette to file
+func (c *Cassette) Save(path string) error {
+	bb, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(bb, '\n'), 0644)
+}
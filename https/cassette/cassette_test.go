@@ -0,0 +1,188 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package cassette
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/https"
+	"github.com/altoros/gosigma/mock"
+)
+
+func record(t *testing.T) *Cassette {
+	srv := mock.New()
+	defer srv.Close()
+
+	srv.AddServer(&data.Server{Name: "test"})
+
+	rec := NewRecorder(&http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	})
+	client := https.NewAuthClient(srv.Username(), srv.Password(), nil, https.WithTransport(rec))
+
+	resp, err := client.Get(srv.Endpoint("servers/"), url.Values{"limit": {"0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	resp, err = client.Post(srv.Endpoint("servers/"), nil, strings.NewReader(`{"name": "new", "cpu": 2000, "mem": 536870912, "vnc_password": "test"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	return rec.Cassette()
+}
+
+func TestRecorder(t *testing.T) {
+	c := record(t)
+
+	if len(c.Interactions) != 2 {
+		t.Fatalf("recorded %d interactions, wants 2", len(c.Interactions))
+	}
+
+	for _, i := range c.Interactions {
+		if i.Request.Header.Get("Authorization") != "" {
+			t.Error("credentials must be scrubbed")
+		}
+		if i.Response.Body == "" {
+			t.Error("response body must be recorded")
+		}
+	}
+
+	if i := c.Interactions[0]; i.Request.Method != "GET" || i.Response.StatusCode != 200 {
+		t.Errorf("invalid interaction: %#v", i)
+	}
+	if i := c.Interactions[1]; i.Request.Body != `{"name": "new", "cpu": 2000, "mem": 536870912, "vnc_password": "test"}` || i.Response.StatusCode != 201 {
+		t.Errorf("invalid interaction: %#v", i)
+	}
+}
+
+func TestReplayer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cassette.json")
+	if err := record(t).Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rep := NewReplayer(c)
+	client := https.NewAuthClient("user", "password", nil, https.WithTransport(rep))
+
+	const endpoint = "https://replay.example.com/api/2.0/servers/"
+
+	if _, err := client.Get(endpoint, url.Values{"limit": {"1"}}); err == nil {
+		t.Error("request with different query must not match")
+	}
+	if _, err := client.Post(endpoint, nil, strings.NewReader(`{"name": "other", "cpu": 2000, "mem": 536870912, "vnc_password": "test"}`)); err == nil {
+		t.Error("request with different body must not match")
+	}
+
+	resp, err := client.Get(endpoint, url.Values{"limit": {"0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	servers, err := data.ReadServers(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 1 || servers[0].Name != "test" {
+		t.Errorf("invalid replayed servers: %v", servers)
+	}
+
+	resp, err = client.Post(endpoint, nil, strings.NewReader(`{"name": "new", "cpu": 2000, "mem": 536870912, "vnc_password": "test"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := resp.VerifyJSON(201); err != nil {
+		t.Error(err)
+	}
+
+	if n := rep.Remaining(); n != 0 {
+		t.Errorf("remaining %d interactions, wants 0", n)
+	}
+	if _, err := client.Get(endpoint, url.Values{"limit": {"0"}}); err == nil {
+		t.Error("interaction must be served once")
+	}
+}
+
+type echoTransport struct{}
+
+func (echoTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	bb, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": {"application/octet-stream"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(bb)),
+		Request:    req,
+	}, nil
+}
+
+func TestBinaryBody(t *testing.T) {
+	image := []byte{0x00, 0xff, 0xfe, 0x80, 'a', 0xc3}
+	const u = "https://replay.example.com/api/2.0/drives/uuid/upload/"
+
+	rec := NewRecorder(echoTransport{})
+	req, err := http.NewRequest("POST", u, bytes.NewReader(image))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := rec.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := rec.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := c.Interactions[0]; i.Request.Body != "" || !bytes.Equal(i.Response.BodyBase64, image) {
+		t.Errorf("binary body must be kept as base64: %#v", i)
+	}
+
+	req, err = http.NewRequest("POST", u, bytes.NewReader(image))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = NewReplayer(c).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || !bytes.Equal(bb, image) {
+		t.Errorf("replayed body %v, wants %v, error %v", bb, image, err)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package cassette
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// ScrubHeaders lists headers removed from recorded interactions, as they
+// carry credentials
+var ScrubHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// Recorder implements http.RoundTripper, which performs requests with
+// underlying transport and records them with responses into cassette.
+// Recorder is safe for concurrent use by multiple goroutines.
+type Recorder struct {
+	transport http.RoundTripper
+	mu        sync.Mutex
+	cassette  Cassette
+}
+
+var _ http.RoundTripper = (*Recorder)(nil)
+
+// NewRecorder returns new Recorder object performing requests with given
+// transport. Parameter transport is optional and can be nil, the
+// http.DefaultTransport will be used in this case.
+func NewRecorder(transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{transport: transport}
+}
+
+// RoundTrip performs request and records the interaction
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		bb, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bb
+		req.Body = ioutil.NopCloser(bytes.NewReader(bb))
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	u := *req.URL
+	u.User = nil
+
+	i := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    u.String(),
+			Header: scrub(req.Header),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Header:     scrub(resp.Header),
+		},
+	}
+	i.Request.Body, i.Request.BodyBase64 = splitBody(reqBody)
+	i.Response.Body, i.Response.BodyBase64 = splitBody(respBody)
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// Cassette returns copy of the cassette with interactions recorded so far
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := &Cassette{Interactions: make([]Interaction, len(r.cassette.Interactions))}
+	copy(c.Interactions, r.cassette.Interactions)
+	return c
+}
+
+// Save writes interactions recorded so far to cassette file
+func (r *Recorder) Save(path string) error {
+	return r.Cassette().Save(path)
+}
+
+func scrub(h http.Header) http.Header {
+	result := make(http.Header, len(h))
+	for k, v := range h {
+		result[k] = append([]string(nil), v...)
+	}
+	for _, k := range ScrubHeaders {
+		result.Del(k)
+	}
+	return result
+}
@@ -0,0 +1,109 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+)
+
+// Replayer implements http.RoundTripper, which serves responses from cassette
+// instead of performing requests. Request is matched to recorded interaction
+// by method, path, query and body; every interaction is served once, in
+// order of recording. Replayer is safe for concurrent use by multiple goroutines.
+type Replayer struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+var _ http.RoundTripper = (*Replayer)(nil)
+
+// NewReplayer returns new Replayer object serving interactions of the cassette
+func NewReplayer(c *Cassette) *Replayer {
+	return &Replayer{
+		cassette: c,
+		used:     make([]bool, len(c.Interactions)),
+	}
+}
+
+// RoundTrip serves response of recorded interaction matching the request
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		bb, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = bb
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for n, i := range r.cassette.Interactions {
+		if r.used[n] || !match(i.Request, req, body) {
+			continue
+		}
+		r.used[n] = true
+		return makeResponse(i.Response, req), nil
+	}
+
+	return nil, fmt.Errorf("cassette: no interaction recorded for %s %s", req.Method, req.URL)
+}
+
+// Remaining returns number of interactions not served yet
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+func match(rec Request, req *http.Request, body []byte) bool {
+	if rec.Method != req.Method {
+		return false
+	}
+	u, err := url.Parse(rec.URL)
+	if err != nil {
+		return false
+	}
+	if u.Path != req.URL.Path {
+		return false
+	}
+	if !reflect.DeepEqual(u.Query(), req.URL.Query()) {
+		return false
+	}
+	return bytes.Equal(rec.content(), body)
+}
+
+func makeResponse(rec Response, req *http.Request) *http.Response {
+	body := rec.content()
+	header := make(http.Header, len(rec.Header))
+	for k, v := range rec.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	return &http.Response{
+		Status:        rec.Status,
+		StatusCode:    rec.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -5,6 +5,7 @@ package https
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -23,21 +25,65 @@ type Logger interface {
 	Logf(format string, args ...interface{})
 }
 
-// Client represents HTTPS client connection with optional basic authentication
+// Client represents HTTPS client connection with optional authentication.
+// Client is safe for concurrent use by multiple goroutines. Its configuration
+// should be supplied with Option values at construction time.
 type Client struct {
 	protocol         *http.Client
-	username         string
-	password         string
+	auth             Authenticator
+	retry            RetryPolicy
+	userAgent        string
+	transport        http.RoundTripper
+	mu               sync.RWMutex
 	connectTimeout   time.Duration
 	readWriteTimeout time.Duration
-	transport        *http.Transport
 	logger           Logger
 }
 
+// An Option configures Client at construction time
+type Option func(*Client)
+
+// WithConnectTimeout returns Option setting connection timeout
+func WithConnectTimeout(timeout time.Duration) Option {
+	return func(c *Client) { c.connectTimeout = timeout }
+}
+
+// WithReadWriteTimeout returns Option setting read-write timeout
+func WithReadWriteTimeout(timeout time.Duration) Option {
+	return func(c *Client) { c.readWriteTimeout = timeout }
+}
+
+// WithLogger returns Option setting logger for http traces
+func WithLogger(logger Logger) Option {
+	return func(c *Client) { c.logger = logger }
+}
+
+// WithAuthenticator returns Option setting authentication for requests
+func WithAuthenticator(auth Authenticator) Option {
+	return func(c *Client) { c.auth = auth }
+}
+
+// WithRetryPolicy returns Option setting policy for retrying failed requests
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) { c.retry = policy }
+}
+
+// WithUserAgent returns Option setting User-Agent header for requests
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// WithTransport returns Option replacing https transport of the Client.
+// Parameter tlsConfig of NewClient and connection timeout are not used by
+// custom transport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) { c.transport = transport }
+}
+
 // NewClient returns new Client object with transport configured for https.
 // Parameter tlsConfig is optional and can be nil, the default TLSClientConfig of
 // http.Transport will be used in this case.
-func NewClient(tlsConfig *tls.Config) *Client {
+func NewClient(tlsConfig *tls.Config, opts ...Option) *Client {
 	if tlsConfig == nil {
 		tlsConfig = &tls.Config{InsecureSkipVerify: true}
 	}
@@ -58,56 +104,82 @@ func NewClient(tlsConfig *tls.Config) *Client {
 	}
 
 	https := &Client{
-		protocol: &http.Client{
-			Transport:     tr,
-			CheckRedirect: redirectChecker,
-		},
+		retry:     DefaultRetryPolicy,
 		transport: tr,
 	}
 
 	tr.Dial = https.dialer
 
+	for _, opt := range opts {
+		opt(https)
+	}
+
+	https.protocol = &http.Client{
+		Transport:     https.transport,
+		CheckRedirect: redirectChecker,
+	}
+
 	return https
 }
 
 // NewAuthClient returns new Client object with configured https transport
 // and attached authentication. Parameter tlsConfig is optional and can be nil, the
 // default TLSClientConfig of http.Transport will be used in this case.
-func NewAuthClient(username, password string, tlsConfig *tls.Config) *Client {
-	https := NewClient(tlsConfig)
-	https.username = username
-	https.password = password
-	return https
+func NewAuthClient(username, password string, tlsConfig *tls.Config, opts ...Option) *Client {
+	auth := WithAuthenticator(BasicAuth{username, password})
+	return NewClient(tlsConfig, append([]Option{auth}, opts...)...)
 }
 
-// ConnectTimeout sets connection timeout
+// ConnectTimeout sets connection timeout.
+//
+// Deprecated: use WithConnectTimeout option at construction time.
 func (c *Client) ConnectTimeout(timeout time.Duration) {
+	c.mu.Lock()
 	c.connectTimeout = timeout
-	c.transport.CloseIdleConnections()
+	c.mu.Unlock()
+	c.closeIdleConnections()
 }
 
 // GetConnectTimeout returns connection timeout for the object
-func (c Client) GetConnectTimeout() time.Duration {
+func (c *Client) GetConnectTimeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.connectTimeout
 }
 
-// ReadWriteTimeout sets read-write timeout
+// ReadWriteTimeout sets read-write timeout.
+//
+// Deprecated: use WithReadWriteTimeout option at construction time.
 func (c *Client) ReadWriteTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.readWriteTimeout = timeout
 }
 
 // GetReadWriteTimeout returns connection timeout for the object
-func (c Client) GetReadWriteTimeout() time.Duration {
+func (c *Client) GetReadWriteTimeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.readWriteTimeout
 }
 
-// Logger sets logger for http traces
+// Logger sets logger for http traces.
+//
+// Deprecated: use WithLogger option at construction time.
 func (c *Client) Logger(logger Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.logger = logger
 }
 
+func (c *Client) getLogger() Logger {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.logger
+}
+
 // Get performs get request to the url.
-func (c Client) Get(url string, query url.Values) (*Response, error) {
+func (c *Client) Get(url string, query url.Values) (*Response, error) {
 	if len(query) != 0 {
 		url += "?" + query.Encode()
 	}
@@ -117,24 +189,45 @@ func (c Client) Get(url string, query url.Values) (*Response, error) {
 		return nil, err
 	}
 
-	if len(c.username) != 0 {
-		req.SetBasicAuth(c.username, c.password)
-	}
-
 	return c.do(req)
 }
 
 // Post performs post request to the url.
-func (c Client) Post(url string, query url.Values, body io.Reader) (*Response, error) {
+func (c *Client) Post(url string, query url.Values, body io.Reader) (*Response, error) {
 	return c.perform("POST", url, query, body)
 }
 
+// Put performs put request to the url.
+func (c *Client) Put(url string, query url.Values, body io.Reader) (*Response, error) {
+	return c.perform("PUT", url, query, body)
+}
+
 // Delete performs delete request to the url.
-func (c Client) Delete(url string, query url.Values, body io.Reader) (*Response, error) {
+func (c *Client) Delete(url string, query url.Values, body io.Reader) (*Response, error) {
 	return c.perform("DELETE", url, query, body)
 }
 
-func (c Client) perform(request, url string, query url.Values, body io.Reader) (*Response, error) {
+// Do performs request with given method, headers and body to the url. Request
+// is cancelled when the context is done, broken connections are not retried
+// after that.
+func (c *Client) Do(ctx context.Context, method, url string, query url.Values, header http.Header, body io.Reader) (*Response, error) {
+	if len(query) != 0 {
+		url += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	return c.do(req.WithContext(ctx))
+}
+
+func (c *Client) perform(request, url string, query url.Values, body io.Reader) (*Response, error) {
 	if len(query) != 0 {
 		url += "?" + query.Encode()
 	}
@@ -153,69 +246,167 @@ func (c Client) perform(request, url string, query url.Values, body io.Reader) (
 		h.Add("Content-Type", "application/json; charset=utf-8")
 	}
 
-	if len(c.username) != 0 {
-		req.SetBasicAuth(c.username, c.password)
-	}
-
 	return c.do(req)
 }
 
-func (c Client) do(r *http.Request) (*Response, error) {
-	logger := c.logger
+func (c *Client) do(r *http.Request) (*Response, error) {
+	logger := c.getLogger()
+
+	if c.auth != nil {
+		if err := c.auth.Authenticate(r); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.userAgent != "" {
+		r.Header.Set("User-Agent", c.userAgent)
+	}
 
 	if logger != nil {
-		if buf, err := httputil.DumpRequest(r, true); err == nil {
+		if buf, err := httputil.DumpRequest(r, isTextBody(r.Header)); err == nil {
 			logger.Logf("%s", string(buf))
 			logger.Logf("")
 		}
 	}
 
-	readWriteTimeout := c.readWriteTimeout
-	if readWriteTimeout > 0 {
-		timer := time.AfterFunc(readWriteTimeout, func() {
-			c.transport.CancelRequest(r)
-		})
-		defer timer.Stop()
+	// the timeout covers reading of the response body, so the context is
+	// cancelled when the body is closed
+	ctx, cancel := r.Context(), context.CancelFunc(func() {})
+	if readWriteTimeout := c.GetReadWriteTimeout(); readWriteTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, readWriteTimeout)
+		r = r.WithContext(ctx)
+	}
+
+	attempts := c.retry.attempts()
+	if !canRewindBody(r) {
+		attempts = 1
 	}
 
 	var resp *http.Response
-	for i := 0; i < 3; i++ {
-		if r, err := c.protocol.Do(r); err == nil {
-			resp = r
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			if err = rewindBody(r); err == nil {
+				err = sleep(ctx, c.retry.Delay)
+			}
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+		}
+		var rr *http.Response
+		rr, err = c.protocol.Do(r)
+		if err != nil {
+			if ctx.Err() != nil {
+				cancel()
+				return nil, ctx.Err()
+			}
+			if logger != nil {
+				logger.Logf("broken persistent connection, try [%d], closing idle conns and retry...", i)
+			}
+			c.closeIdleConnections()
+			continue
+		}
+		resp = rr
+		if !c.retry.retryCode(rr.StatusCode) || i == attempts-1 {
 			break
 		}
 		if logger != nil {
-			logger.Logf("broken persistent connection, try [%d], closing idle conns and retry...", i)
+			logger.Logf("HTTP/%s, try [%d], retry...", rr.Status, i)
 		}
-		c.transport.CloseIdleConnections()
+		rr.Body.Close()
+		resp = nil
 	}
 
 	if resp == nil {
-		return nil, fmt.Errorf("broken connection")
+		cancel()
+		return nil, fmt.Errorf("broken connection: %v", err)
 	}
 
+	resp.Body = cancelBody{resp.Body, cancel}
+
 	if logger != nil {
 		logger.Logf("HTTP/%s", resp.Status)
 		for header, values := range resp.Header {
 			logger.Logf("%s: %s", header, strings.Join(values, ","))
 		}
 
-		bb, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			logger.Logf("failed to read body %s", err)
-			return nil, err
-		}
+		if isTextBody(resp.Header) {
+			bb, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				logger.Logf("failed to read body %s", err)
+				return nil, err
+			}
 
-		logger.Logf("")
-		logger.Logf("%s", string(bb))
-		logger.Logf("")
+			logger.Logf("")
+			logger.Logf("%s", string(bb))
+			logger.Logf("")
 
-		resp.Body = ioutil.NopCloser(bytes.NewReader(bb))
+			resp.Body = ioutil.NopCloser(bytes.NewReader(bb))
+		}
 	}
 
 	return &Response{resp}, nil
 }
 
+func (c *Client) closeIdleConnections() {
+	type closeIdler interface {
+		CloseIdleConnections()
+	}
+	if tr, ok := c.transport.(closeIdler); ok {
+		tr.CloseIdleConnections()
+	}
+}
+
+// isTextBody reports whether body with given headers can be written to the log
+func isTextBody(h http.Header) bool {
+	ct := h.Get("Content-Type")
+	return ct == "" || strings.HasPrefix(ct, "application/json") || strings.HasPrefix(ct, "text/")
+}
+
+// cancelBody cancels context of the request when the response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// sleep waits for given delay or the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// canRewindBody reports whether request body can be sent again
+func canRewindBody(r *http.Request) bool {
+	return r.Body == nil || r.Body == http.NoBody || r.GetBody != nil
+}
+
+// rewindBody prepares request body to be sent again
+func rewindBody(r *http.Request) error {
+	if r.GetBody == nil {
+		return nil
+	}
+	body, err := r.GetBody()
+	if err != nil {
+		return err
+	}
+	r.Body = body
+	return nil
+}
+
 func (c *Client) dialer(netw, addr string) (net.Conn, error) {
-	return net.DialTimeout(netw, addr, c.connectTimeout)
+	return net.DialTimeout(netw, addr, c.GetConnectTimeout())
 }
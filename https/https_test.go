@@ -2,3 +2,231 @@
 // Licensed under the AGPLv3, see LICENSE file for details.
 
 package https
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testLog struct{ written int32 }
+
+func (l *testLog) Logf(format string, args ...interface{}) { atomic.AddInt32(&l.written, 1) }
+
+func TestClientOptions(t *testing.T) {
+	var log testLog
+	c := NewAuthClient("user", "pass", nil,
+		WithConnectTimeout(100*time.Millisecond),
+		WithReadWriteTimeout(200*time.Millisecond),
+		WithLogger(&log))
+
+	if v := c.GetConnectTimeout(); v != 100*time.Millisecond {
+		t.Error("WithConnectTimeout check failed")
+	}
+	if v := c.GetReadWriteTimeout(); v != 200*time.Millisecond {
+		t.Error("WithReadWriteTimeout check failed")
+	}
+	if v := c.getLogger(); v != &log {
+		t.Error("WithLogger check failed")
+	}
+}
+
+func TestClientConcurrent(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != "user" || p != "pass" {
+			w.WriteHeader(401)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	var log testLog
+	c := NewAuthClient("user", "pass", nil, WithLogger(&log))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		timeout := time.Duration(i+1) * time.Second
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			r, err := c.Get(ts.URL, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer r.Body.Close()
+			if err := r.VerifyJSON(200); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			r, err := c.Post(ts.URL, nil, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer r.Body.Close()
+			if err := r.VerifyJSON(200); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			c.ReadWriteTimeout(timeout)
+			c.GetReadWriteTimeout()
+			c.ConnectTimeout(timeout)
+			c.GetConnectTimeout()
+			c.Logger(&log)
+		}()
+	}
+	wg.Wait()
+
+	if atomic.LoadInt32(&log.written) == 0 {
+		t.Error("no writes to log")
+	}
+}
+
+func TestClientRetryPolicy(t *testing.T) {
+	var requests int32
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bb, _ := ioutil.ReadAll(r.Body)
+		if string(bb) != "body" {
+			t.Errorf("invalid request body %q", string(bb))
+		}
+		if r.Header.Get("User-Agent") != "test-agent" {
+			t.Errorf("invalid User-Agent %q", r.Header.Get("User-Agent"))
+		}
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	check := func(policy RetryPolicy, code int, count int32) {
+		atomic.StoreInt32(&requests, 0)
+		c := NewClient(nil, WithRetryPolicy(policy), WithUserAgent("test-agent"))
+		r, err := c.Post(ts.URL, nil, strings.NewReader("body"))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer r.Body.Close()
+		if r.StatusCode != code {
+			t.Errorf("status code %d, wants %d", r.StatusCode, code)
+		}
+		if v := atomic.LoadInt32(&requests); v != count {
+			t.Errorf("requests %d, wants %d", v, count)
+		}
+	}
+
+	check(DefaultRetryPolicy, 503, 1)
+	check(RetryPolicy{Attempts: 2, StatusCodes: []int{503}}, 503, 2)
+	check(RetryPolicy{Attempts: 3, StatusCodes: []int{503}}, 200, 3)
+}
+
+func TestClientDo(t *testing.T) {
+	var requests int32
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		bb, _ := ioutil.ReadAll(r.Body)
+		if string(bb) != "\x00\x01" {
+			t.Errorf("invalid request body %q", string(bb))
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/octet-stream" {
+			t.Errorf("invalid Content-Type %q", ct)
+		}
+		if r.URL.Query().Get("n") != "1" {
+			t.Errorf("invalid query %q", r.URL.RawQuery)
+		}
+		w.WriteHeader(201)
+	}))
+	defer ts.Close()
+
+	var log testLog
+	c := NewClient(nil, WithLogger(&log))
+	h := http.Header{"Content-Type": {"application/octet-stream"}}
+	qq := url.Values{"n": {"1"}}
+
+	r, err := c.Do(context.Background(), "PUT", ts.URL, qq, h, bytes.NewReader([]byte{0, 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if err := r.VerifyCode(201); err != nil {
+		t.Error(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Do(ctx, "PUT", ts.URL, qq, h, bytes.NewReader([]byte{0, 1})); err == nil {
+		t.Error("request with done context must fail")
+	}
+	if v := atomic.LoadInt32(&requests); v != 1 {
+		t.Errorf("requests %d, wants 1", v)
+	}
+
+	if isTextBody(h) || !isTextBody(http.Header{}) || !isTextBody(http.Header{"Content-Type": {"application/json"}}) {
+		t.Error("isTextBody check failed")
+	}
+}
+
+func TestClientRetryInterrupted(t *testing.T) {
+	var requests int32
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(503)
+	}))
+	defer ts.Close()
+
+	policy := RetryPolicy{Attempts: 3, Delay: time.Second, StatusCodes: []int{503}}
+
+	// read-write timeout must be reported instead of broken connection
+	c := NewClient(nil, WithRetryPolicy(policy), WithReadWriteTimeout(10*time.Millisecond))
+	if _, err := c.Get(ts.URL, nil); err != context.DeadlineExceeded {
+		t.Errorf("timed out request, error %v", err)
+	}
+
+	// delay between attempts must be interrupted by the context
+	atomic.StoreInt32(&requests, 0)
+	c = NewClient(nil, WithRetryPolicy(policy))
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	if _, err := c.Do(ctx, "GET", ts.URL, nil, nil, nil); err != context.DeadlineExceeded {
+		t.Errorf("cancelled retry, error %v", err)
+	}
+	if d := time.Since(started); d > time.Second {
+		t.Errorf("cancelled retry took %v", d)
+	}
+	if v := atomic.LoadInt32(&requests); v != 1 {
+		t.Errorf("requests %d, wants 1", v)
+	}
+
+	// body, which cannot be rewound, must not be sent again
+	atomic.StoreInt32(&requests, 0)
+	policy.Delay = 0
+	c = NewClient(nil, WithRetryPolicy(policy))
+	r, err := c.Do(context.Background(), "PUT", ts.URL, nil, nil, ioutil.NopCloser(strings.NewReader("body")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if v := atomic.LoadInt32(&requests); v != 1 || r.StatusCode != 503 {
+		t.Errorf("requests %d, code %d, wants single request", v, r.StatusCode)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package https
+
+import "time"
+
+// RetryPolicy defines how Client retries failed requests
+type RetryPolicy struct {
+	// Attempts is the total number of attempts to perform request, values
+	// less than one are treated as a single attempt
+	Attempts int
+	// Delay between attempts
+	Delay time.Duration
+	// StatusCodes lists HTTP codes of responses to retry, in addition to
+	// broken connections
+	StatusCodes []int
+}
+
+// DefaultRetryPolicy retries broken connections up to three times
+var DefaultRetryPolicy = RetryPolicy{Attempts: 3}
+
+func (p RetryPolicy) attempts() int {
+	if p.Attempts < 1 {
+		return 1
+	}
+	return p.Attempts
+}
+
+func (p RetryPolicy) retryCode(code int) bool {
+	for _, c := range p.StatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"fmt"
+
+	"github.com/altoros/gosigma/data"
+)
+
+// A KeyPair interface represents SSH key pair in CloudSigma account
+type KeyPair interface {
+	// CloudSigma resource
+	Resource
+
+	// Fingerprint of public key
+	Fingerprint() string
+
+	// Get meta-information value stored in the key pair
+	Get(key string) (v string, ok bool)
+
+	// Name of key pair
+	Name() string
+
+	// PrivateKey of key pair. It is returned by the endpoint only once, in reply
+	// to CreateKeyPair, and is empty otherwise.
+	PrivateKey() string
+
+	// PublicKey of key pair in OpenSSH authorized_keys format
+	PublicKey() string
+
+	// Refresh information about key pair. Private key is not kept.
+	Refresh() error
+
+	// Remove key pair
+	Remove() error
+}
+
+// A keyPair implements SSH key pair in CloudSigma account
+type keyPair struct {
+	client *Client
+	obj    *data.KeyPair
+}
+
+var _ KeyPair = (*keyPair)(nil)
+
+// String method is used to print values passed as an operand to any format that
+// accepts a string or to an unformatted printer such as Print.
+func (k keyPair) String() string {
+	return fmt.Sprintf(`{UUID: %q, Name: %q, Fingerprint: %q}`, k.UUID(), k.Name(), k.Fingerprint())
+}
+
+// URI of key pair
+func (k keyPair) URI() string { return k.obj.URI }
+
+// UUID of key pair
+func (k keyPair) UUID() string { return k.obj.UUID }
+
+// Fingerprint of public key
+func (k keyPair) Fingerprint() string { return k.obj.Fingerprint }
+
+// Get meta-information value stored in the key pair
+func (k keyPair) Get(key string) (v string, ok bool) {
+	v, ok = k.obj.Meta[key]
+	return
+}
+
+// Name of key pair
+func (k keyPair) Name() string { return k.obj.Name }
+
+// PrivateKey of key pair. It is returned by the endpoint only once, in reply
+// to CreateKeyPair, and is empty otherwise.
+func (k keyPair) PrivateKey() string { return k.obj.PrivateKey }
+
+// PublicKey of key pair in OpenSSH authorized_keys format
+func (k keyPair) PublicKey() string { return k.obj.PublicKey }
+
+// Refresh information about key pair. Private key is not kept.
+func (k *keyPair) Refresh() error {
+	obj, err := k.client.getKeyPair(k.UUID())
+	if err != nil {
+		return err
+	}
+	k.obj = obj
+	return nil
+}
+
+// Remove key pair
+func (k *keyPair) Remove() error {
+	return k.client.RemoveKeyPair(k.UUID())
+}
@@ -0,0 +1,137 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/altoros/gosigma/mock"
+	"github.com/altoros/gosigma/sshkey"
+)
+
+func TestClientKeyPairs(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := cli.CreateKeyPair("generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(created.PrivateKey(), "PRIVATE KEY") {
+		t.Errorf("private key must be returned on create: %q", created.PrivateKey())
+	}
+	if fp, err := sshkey.Fingerprint(created.PublicKey()); err != nil || fp != created.Fingerprint() {
+		t.Errorf("invalid fingerprint %q, wants %q (%v)", created.Fingerprint(), fp, err)
+	}
+
+	key, err := sshkey.Generate(1024, "imported@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := cli.ImportKeyPair("imported", key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.PublicKey() != key.PublicKey || imported.Fingerprint() != key.Fingerprint || imported.PrivateKey() != "" {
+		t.Errorf("invalid imported key pair %v", imported)
+	}
+
+	generated, privateKey, err := cli.GenerateKeyPair("local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(privateKey) == 0 || generated.PrivateKey() != "" {
+		t.Errorf("private key must be returned to the caller only")
+	}
+
+	kk, err := cli.KeyPairs(RequestDetail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kk) != 3 {
+		t.Errorf("Client.KeyPairs: %v", kk)
+	}
+	for _, k := range kk {
+		if k.PrivateKey() != "" {
+			t.Errorf("private key must not be stored: %v", k)
+		}
+	}
+
+	if err := created.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if created.PrivateKey() != "" || created.Name() != "generated" {
+		t.Errorf("invalid refreshed key pair %v", created)
+	}
+
+	if err := imported.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.KeyPair(imported.UUID()); err == nil {
+		t.Error("removed key pair must not be found")
+	}
+}
+
+func TestClientKeyPairValidation(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cli.ImportKeyPair("test", " "); err != errEmptyPublicKey {
+		t.Errorf("invalid error %v", err)
+	}
+
+	if _, err := cli.ImportKeyPair("test", "ssh-rsa invalid"); err == nil {
+		t.Error("ImportKeyPair must fail")
+	} else if e, ok := err.(*Error); !ok || e.StatusCode != 400 {
+		t.Errorf("invalid error %v", err)
+	}
+}
+
+func TestClientServerKeyPair(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k, _, err := cli.GenerateKeyPair("deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var c Components
+	c.SetName("test")
+	c.SetCPU(2000)
+	c.SetMem(2147483648)
+	c.SetVNCPassword("test")
+	c.AttachKeyPair(k.UUID())
+
+	s, err := cli.CreateServer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr := s.PubKeys(); len(rr) != 1 || rr[0].UUID() != k.UUID() {
+		t.Errorf("Server.PubKeys: %v, wants %s", rr, k.UUID())
+	}
+
+	c.AttachKeyPair("missing")
+	if _, err := cli.CreateServer(c); err == nil {
+		t.Error("CreateServer with missing key pair must fail")
+	} else if e, ok := err.(*Error); !ok || e.StatusCode != 400 {
+		t.Errorf("invalid error %v", err)
+	}
+}
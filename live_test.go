@@ -6,19 +6,25 @@ package gosigma
 import (
 	"errors"
 	"flag"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/altoros/gosigma/https/cassette"
 )
 
 var live = flag.String("live", "", "run live tests against CloudSigma endpoint, specify credentials in form -live=user:pass")
 var suid = flag.String("suid", "", "uuid of server at CloudSigma to run server specific tests")
 var duid = flag.String("duid", "", "uuid of drive at CloudSigma to run drive specific tests")
 var vlan = flag.String("vlan", "", "uuid of vlan at CloudSigma to run server specific tests")
-var sshkey = flag.String("sshkey", "", "public ssh key to run server specific tests")
+var sshPublicKey = flag.String("sshkey", "", "public ssh key to run server specific tests")
 var force = flag.Bool("force", false, "force start/stop live tests")
 var lib = flag.Bool("lib", false, "duid is library drive")
 var size = flag.Uint64("size", 0, "size for operations: TestLiveDriveResize")
+var record = flag.String("record", "", "record live tests into cassettes at given directory, used with -live")
+var replay = flag.String("replay", "", "replay live tests from cassettes at given directory, instead of -live")
 
 func libFlag() LibrarySpec {
 	if *lib {
@@ -29,6 +35,9 @@ func libFlag() LibrarySpec {
 
 func parseCredentials() (u string, p string, e error) {
 	if *live == "" {
+		if *replay != "" {
+			u, p = "replay", "replay"
+		}
 		return
 	}
 
@@ -43,6 +52,45 @@ func parseCredentials() (u string, p string, e error) {
 	return
 }
 
+// newLiveClient creates client for live test, recording its communication into
+// cassette with -record, or replaying it from cassette with -replay. Function
+// done must be called at the end of the test to save the cassette.
+func newLiveClient(t *testing.T, u, p string) (*Client, func(), error) {
+	opts := []Option{WithRegion(DefaultRegion), WithCredentials(u, p)}
+	if *trace {
+		opts = append(opts, WithLogger(t))
+	}
+
+	done := func() {}
+	path := filepath.Join(*record+*replay, t.Name()+".json")
+
+	switch {
+	case *live != "" && *record != "":
+		rec := cassette.NewRecorder(nil)
+		opts = append(opts, WithTransport(rec))
+		done = func() {
+			if err := rec.Save(path); err != nil {
+				t.Error(err)
+			}
+		}
+	case *live == "" && *replay != "":
+		c, err := cassette.Load(path)
+		if os.IsNotExist(err) {
+			t.Skip("cassette not found:", path)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, WithTransport(cassette.NewReplayer(c)))
+	}
+
+	cli, err := New(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli, done, nil
+}
+
 func skipTest(t *testing.T, e error) {
 	if e == nil {
 		t.SkipNow()
@@ -58,15 +106,12 @@ func TestLiveServers(t *testing.T) {
 		return
 	}
 
-	cli, err := NewClient(DefaultRegion, u, p, nil)
+	cli, done, err := newLiveClient(t, u, p)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-
-	if *trace {
-		cli.Logger(t)
-	}
+	defer done()
 
 	ii, err := cli.Servers(false)
 	if err != nil {
@@ -88,15 +133,12 @@ func TestLiveServerGet(t *testing.T) {
 		return
 	}
 
-	cli, err := NewClient(DefaultRegion, u, p, nil)
+	cli, done, err := newLiveClient(t, u, p)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-
-	if *trace {
-		cli.Logger(t)
-	}
+	defer done()
 
 	s, err := cli.Server(*suid)
 	if err != nil {
@@ -118,15 +160,12 @@ func TestLiveServerStart(t *testing.T) {
 		return
 	}
 
-	cli, err := NewClient(DefaultRegion, u, p, nil)
+	cli, done, err := newLiveClient(t, u, p)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-
-	if *trace {
-		cli.Logger(t)
-	}
+	defer done()
 
 	s, err := cli.Server(*suid)
 	if err != nil {
@@ -156,15 +195,12 @@ func TestLiveServerStartWait(t *testing.T) {
 		return
 	}
 
-	cli, err := NewClient(DefaultRegion, u, p, nil)
+	cli, done, err := newLiveClient(t, u, p)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-
-	if *trace {
-		cli.Logger(t)
-	}
+	defer done()
 
 	s, err := cli.Server(*suid)
 	if err != nil {
@@ -194,15 +230,12 @@ func TestLiveServerStop(t *testing.T) {
 		return
 	}
 
-	cli, err := NewClient(DefaultRegion, u, p, nil)
+	cli, done, err := newLiveClient(t, u, p)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-
-	if *trace {
-		cli.Logger(t)
-	}
+	defer done()
 
 	s, err := cli.Server(*suid)
 	if err != nil {
@@ -232,15 +265,12 @@ func TestLiveDriveGet(t *testing.T) {
 		return
 	}
 
-	cli, err := NewClient(DefaultRegion, u, p, nil)
+	cli, done, err := newLiveClient(t, u, p)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-
-	if *trace {
-		cli.Logger(t)
-	}
+	defer done()
 
 	d, err := cli.Drive(*duid, libFlag())
 	if err != nil {
@@ -257,15 +287,12 @@ func TestLiveDriveList(t *testing.T) {
 		return
 	}
 
-	cli, err := NewClient(DefaultRegion, u, p, nil)
+	cli, done, err := newLiveClient(t, u, p)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-
-	if *trace {
-		cli.Logger(t)
-	}
+	defer done()
 
 	dd, err := cli.Drives(true, libFlag())
 	if err != nil {
@@ -290,15 +317,12 @@ func TestLiveDriveClone(t *testing.T) {
 		return
 	}
 
-	cli, err := NewClient(DefaultRegion, u, p, nil)
+	cli, done, err := newLiveClient(t, u, p)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-
-	if *trace {
-		cli.Logger(t)
-	}
+	defer done()
 
 	d, err := cli.Drive(*duid, libFlag())
 	if err != nil {
@@ -333,20 +357,17 @@ func TestLiveServerClone(t *testing.T) {
 		return
 	}
 
-	if *sshkey == "" {
+	if *sshPublicKey == "" {
 		t.Skip("-sshkey=<ssh-public-key> must be specified")
 		return
 	}
 
-	cli, err := NewClient(DefaultRegion, u, p, nil)
+	cli, done, err := newLiveClient(t, u, p)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-
-	if *trace {
-		cli.Logger(t)
-	}
+	defer done()
 
 	originalDrive, err := cli.Drive(*duid, libFlag())
 	if err != nil {
@@ -369,7 +390,7 @@ func TestLiveServerClone(t *testing.T) {
 	c.SetCPU(2000)
 	c.SetMem(2 * Gigabyte)
 	c.SetVNCPassword("test-vnc-password")
-	c.SetSSHPublicKey(*sshkey)
+	c.SetSSHPublicKey(*sshPublicKey)
 	c.SetDescription("test-description")
 	c.AttachDrive(1, "0:0", "virtio", newDrive.UUID())
 	c.NetworkDHCP4(ModelVirtio)
@@ -397,15 +418,12 @@ func TestLiveServerRemove(t *testing.T) {
 		return
 	}
 
-	cli, err := NewClient(DefaultRegion, u, p, nil)
+	cli, done, err := newLiveClient(t, u, p)
 	if err != nil {
 		t.Error("create client", err)
 		return
 	}
-
-	if *trace {
-		cli.Logger(t)
-	}
+	defer done()
 
 	s, err := cli.Server(*suid)
 	if err != nil {
@@ -446,15 +464,12 @@ func TestLiveDriveResize(t *testing.T) {
 		return
 	}
 
-	cli, err := NewClient(DefaultRegion, u, p, nil)
+	cli, done, err := newLiveClient(t, u, p)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-
-	if *trace {
-		cli.Logger(t)
-	}
+	defer done()
 
 	d, err := cli.Drive(*duid, libFlag())
 	if err != nil {
@@ -0,0 +1,357 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/altoros/gosigma/data"
+)
+
+// ACLLibrary type to store all access control lists in the mock
+type ACLLibrary struct {
+	s   sync.Mutex
+	m   map[string]*data.ACL
+	p   string
+	srv *Server
+}
+
+// ACLs defines library of all access control lists in the default mock
+var ACLs = defaultServer.ACLs
+
+// InitACL initializes the access control list
+func InitACL(a *data.ACL) (*data.ACL, error) {
+	if a.UUID == "" {
+		uuid, err := GenerateUUID()
+		if err != nil {
+			return nil, err
+		}
+		a.UUID = uuid
+	}
+	a.Resource = *data.MakeACLResource(a.UUID)
+	if a.Meta == nil {
+		a.Meta = make(map[string]string)
+	}
+	if a.Rules == nil {
+		a.Rules = []data.ACLRule{}
+	}
+	for i := range a.Rules {
+		if u := a.Rules[i].User; u != nil {
+			a.Rules[i].User = data.MakeUserResource(u.UUID)
+		}
+	}
+	a.Resources = nil
+
+	return a, nil
+}
+
+// Add access control list to the library
+func (al *ACLLibrary) Add(a *data.ACL) error {
+	a, err := InitACL(a)
+	if err != nil {
+		return err
+	}
+
+	al.s.Lock()
+	defer al.s.Unlock()
+
+	al.m[a.UUID] = a
+
+	return nil
+}
+
+// AddACLs adds access control list collection to the library
+func (al *ACLLibrary) AddACLs(aa []data.ACL) []string {
+	al.s.Lock()
+	defer al.s.Unlock()
+
+	var result []string
+	for _, a := range aa {
+		a := a
+		pa, err := InitACL(&a)
+		if err != nil {
+			continue
+		}
+		al.m[pa.UUID] = pa
+		result = append(result, pa.UUID)
+	}
+	return result
+}
+
+// Remove access control list from the library
+func (al *ACLLibrary) Remove(uuid string) bool {
+	al.s.Lock()
+	defer al.s.Unlock()
+
+	_, ok := al.m[uuid]
+	delete(al.m, uuid)
+
+	return ok
+}
+
+// Reset the library
+func (al *ACLLibrary) Reset() {
+	al.s.Lock()
+	defer al.s.Unlock()
+	al.m = make(map[string]*data.ACL)
+}
+
+// has reports whether access control list with given uuid exists in the library
+func (al *ACLLibrary) has(uuid string) bool {
+	al.s.Lock()
+	defer al.s.Unlock()
+	_, ok := al.m[uuid]
+	return ok
+}
+
+// validateACLs checks access control lists of shared resource exist
+func (srv *Server) validateACLs(rr []data.Resource) error {
+	for _, r := range rr {
+		if !srv.ACLs.has(r.UUID) {
+			return invalid("acls", "ACL %s does not exist", r.UUID)
+		}
+	}
+	return nil
+}
+
+func (al *ACLLibrary) snapshot() []data.ACL {
+	al.s.Lock()
+	defer al.s.Unlock()
+
+	var result []data.ACL
+	for _, a := range al.m {
+		result = append(result, *a)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].UUID < result[j].UUID })
+	return result
+}
+
+// makeACLResources returns resources referring access control lists, collected
+// into result by access control list uuid
+func makeACLResources(result map[string][]data.Resource, acls []data.Resource, r data.Resource) {
+	seen := make(map[string]bool)
+	for _, a := range acls {
+		if seen[a.UUID] {
+			continue
+		}
+		seen[a.UUID] = true
+		result[a.UUID] = append(result[a.UUID], r)
+	}
+}
+
+// sharedResources returns drives and servers, which refer access control lists,
+// by access control list uuid
+func (srv *Server) sharedResources() map[string][]data.Resource {
+	result := make(map[string][]data.Resource)
+
+	srv.Drives.s.Lock()
+	for _, d := range srv.Drives.m {
+		makeACLResources(result, d.ACLs, *data.MakeDriveResource(d.UUID))
+	}
+	srv.Drives.s.Unlock()
+
+	srv.syncServers.Lock()
+	for _, s := range srv.servers {
+		makeACLResources(result, s.ACLs, *data.MakeServerResource(s.UUID))
+	}
+	srv.syncServers.Unlock()
+
+	for _, rr := range result {
+		sort.Slice(rr, func(i, j int) bool { return rr[i].URI < rr[j].URI })
+	}
+	return result
+}
+
+// unshare removes access control list from all drives and servers
+func (srv *Server) unshare(uuid string) {
+	remove := func(rr []data.Resource) []data.Resource {
+		var result []data.Resource
+		for _, r := range rr {
+			if r.UUID != uuid {
+				result = append(result, r)
+			}
+		}
+		return result
+	}
+
+	srv.Drives.s.Lock()
+	for _, d := range srv.Drives.m {
+		d.ACLs = remove(d.ACLs)
+	}
+	srv.Drives.s.Unlock()
+
+	srv.syncServers.Lock()
+	for _, s := range srv.servers {
+		s.ACLs = remove(s.ACLs)
+	}
+	srv.syncServers.Unlock()
+}
+
+// URLs:
+// /api/2.0/acls/
+// /api/2.0/acls/detail/
+// /api/2.0/acls/{uuid}/
+func (al *ACLLibrary) handleRequest(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	path = strings.TrimPrefix(path, al.p)
+	path = strings.TrimPrefix(path, "/")
+
+	switch {
+	case r.Method == "GET" && path == "":
+		al.handleList(w, r, 200, false, nil)
+	case r.Method == "GET" && path == "detail":
+		al.handleList(w, r, 200, true, nil)
+	case r.Method == "GET":
+		al.handleGet(w, r, path)
+	case r.Method == "POST" && path == "":
+		al.handleCreate(w, r)
+	case r.Method == "PUT" && path != "":
+		al.handleUpdate(w, r, path)
+	case r.Method == "DELETE" && path != "":
+		al.handleDelete(w, r, path)
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+// viewACL returns copy of access control list with resources it is attached to
+func viewACL(a *data.ACL, resources map[string][]data.Resource) data.ACL {
+	v := *a
+	v.Resources = resources[a.UUID]
+	if v.Resources == nil {
+		v.Resources = []data.Resource{}
+	}
+	return v
+}
+
+func (al *ACLLibrary) handleList(w http.ResponseWriter, r *http.Request, okcode int, detail bool, filter []string) {
+	resources := al.srv.sharedResources()
+
+	al.s.Lock()
+	defer al.s.Unlock()
+
+	var aa data.ACLs
+	if len(filter) == 0 {
+		for _, a := range al.m {
+			filter = append(filter, a.UUID)
+		}
+		sort.Strings(filter)
+	}
+	aa.Objects = make([]data.ACL, 0, len(filter))
+	for _, uuid := range filter {
+		a, ok := al.m[uuid]
+		if !ok {
+			continue
+		}
+		if detail {
+			aa.Objects = append(aa.Objects, viewACL(a, resources))
+		} else {
+			aa.Objects = append(aa.Objects, data.ACL{Resource: a.Resource, Name: a.Name, Rules: a.Rules})
+		}
+	}
+	aa.Meta.TotalCount = len(aa.Objects)
+
+	writeJSON(w, okcode, &aa)
+}
+
+func (al *ACLLibrary) handleGet(w http.ResponseWriter, r *http.Request, uuid string) {
+	resources := al.srv.sharedResources()
+
+	al.s.Lock()
+	defer al.s.Unlock()
+
+	a, ok := al.m[uuid]
+	if !ok {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	v := viewACL(a, resources)
+	writeJSON(w, 200, &v)
+}
+
+func (al *ACLLibrary) handleCreate(w http.ResponseWriter, r *http.Request) {
+	bb, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(400)
+		return
+	}
+
+	aa, err := data.ReadACLs(bytes.NewReader(bb))
+	if err != nil || len(aa) == 0 {
+		a, err := data.ReadACL(bytes.NewReader(bb))
+		if err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		aa = []data.ACL{*a}
+	}
+
+	for i := range aa {
+		if err := validateACL(&aa[i]); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+		aa[i].UUID = ""
+	}
+
+	uuids := al.AddACLs(aa)
+	al.handleList(w, r, 201, true, uuids)
+}
+
+func (al *ACLLibrary) handleUpdate(w http.ResponseWriter, r *http.Request, uuid string) {
+	a, err := data.ReadACL(r.Body)
+	if err != nil {
+		w.WriteHeader(400)
+		return
+	}
+
+	if err := validateACL(a); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	al.s.Lock()
+	current, ok := al.m[uuid]
+	if ok {
+		a.UUID = uuid
+		a.Owner = current.Owner
+		if a.Meta == nil {
+			a.Meta = current.Meta
+		}
+		a, _ = InitACL(a)
+		al.m[uuid] = a
+	}
+	al.s.Unlock()
+
+	if !ok {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	al.handleGet(w, r, uuid)
+}
+
+func (al *ACLLibrary) handleDelete(w http.ResponseWriter, r *http.Request, uuid string) {
+	if !al.Remove(uuid) {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+	al.srv.unshare(uuid)
+	w.WriteHeader(204)
+}
@@ -0,0 +1,61 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/https"
+)
+
+func TestACLsKeptByUpdate(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	acl := &data.ACL{Name: "shared"}
+	if err := srv.ACLs.Add(acl); err != nil {
+		t.Fatal(err)
+	}
+	shares := []data.Resource{*data.MakeACLResource(acl.UUID)}
+
+	drv := &data.Drive{Name: "data", Size: 1024, ACLs: shares}
+	if err := srv.Drives.Add(drv); err != nil {
+		t.Fatal(err)
+	}
+	uuid := srv.AddServers([]data.Server{{Name: "server", ACLs: shares}})[0]
+
+	cli := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+	update := func(u, body string) {
+		r, err := cli.Put(u, nil, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Body.Close()
+		if r.StatusCode != 200 {
+			t.Fatalf("update %s, code %d", body, r.StatusCode)
+		}
+	}
+
+	acls := func() (drive, server []data.Resource) {
+		srv.syncServers.Lock()
+		defer srv.syncServers.Unlock()
+		srv.Drives.s.Lock()
+		defer srv.Drives.s.Unlock()
+		return srv.Drives.m[drv.UUID].ACLs, srv.servers[uuid].ACLs
+	}
+
+	update(srv.Endpoint("drives/"+drv.UUID+"/"), `{"name": "renamed"}`)
+	update(srv.Endpoint("servers/"+uuid+"/"), `{"name": "renamed"}`)
+	if d, s := acls(); len(d) != 1 || len(s) != 1 {
+		t.Errorf("update without acls must keep shares, drive %v, server %v", d, s)
+	}
+
+	update(srv.Endpoint("drives/"+drv.UUID+"/"), `{"acls": []}`)
+	update(srv.Endpoint("servers/"+uuid+"/"), `{"acls": []}`)
+	if d, s := acls(); len(d) != 0 || len(s) != 0 {
+		t.Errorf("update with empty acls must unshare, drive %v, server %v", d, s)
+	}
+}
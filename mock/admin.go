@@ -0,0 +1,163 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//
+// Administrative endpoint of the mock server, for clients running out of process.
+//
+//	GET    /admin/journal/            all journal records
+//	GET    /admin/journal/{id}/       journal records with given id
+//	DELETE /admin/journal/            remove all journal records
+//	POST   /admin/fixture/            load fixture objects, see Fixture
+//	POST   /admin/reset/              remove all objects and fault injection rules
+//	GET    /admin/snapshot/           full state of the server, see Snapshot
+//	PUT    /admin/snapshot/           replace full state of the server, see Restore
+//	POST   /admin/checkpoint/{name}/  save full state under the name, see Checkpoint
+//	POST   /admin/rollback/{name}/    restore full state saved under the name, see Rollback
+//
+
+const adminBase = "/admin/"
+
+// AdminJournalEntry represents journal record at administrative endpoint
+type AdminJournalEntry struct {
+	ID       int         `json:"id"`
+	Name     string      `json:"name"`
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header,omitempty"`
+	Response string      `json:"response,omitempty"`
+}
+
+func makeAdminJournal(id int, entries []JournalEntry) []AdminJournalEntry {
+	result := make([]AdminJournalEntry, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, AdminJournalEntry{
+			ID:       id,
+			Name:     e.Name,
+			Method:   e.Request.Method,
+			URL:      e.Request.URL.String(),
+			Status:   e.Response.Code,
+			Header:   e.Response.HeaderMap,
+			Response: e.Response.Body.String(),
+		})
+	}
+	return result
+}
+
+func (srv *Server) adminHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	if !srv.isValidAuth(r) {
+		w.WriteHeader(401)
+		w.Write([]byte("401 Unauthorized\n"))
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, adminBase)
+	path = strings.TrimSuffix(path, "/")
+
+	switch {
+	case path == "journal" && r.Method == "GET":
+		srv.handleAdminJournal(w, r)
+	case path == "journal" && r.Method == "DELETE":
+		srv.journal.reset()
+		w.WriteHeader(204)
+	case strings.HasPrefix(path, "journal/") && r.Method == "GET":
+		id, err := strconv.Atoi(strings.TrimPrefix(path, "journal/"))
+		if err != nil {
+			w.WriteHeader(404)
+			return
+		}
+		writeAdminJSON(w, makeAdminJournal(id, srv.GetJournal(id)))
+	case path == "fixture" && r.Method == "POST":
+		f, err := ReadFixture(r.Body)
+		if err != nil {
+			w.WriteHeader(400)
+			w.Write([]byte("400 " + err.Error()))
+			return
+		}
+		if err := srv.Load(f); err != nil {
+			w.WriteHeader(400)
+			w.Write([]byte("400 " + err.Error()))
+			return
+		}
+		w.WriteHeader(204)
+	case path == "reset" && r.Method == "POST":
+		srv.Reset()
+		w.WriteHeader(204)
+	case path == "snapshot" && r.Method == "GET":
+		writeAdminJSON(w, srv.Snapshot())
+	case path == "snapshot" && r.Method == "PUT":
+		f, err := ReadFixture(r.Body)
+		if err != nil {
+			w.WriteHeader(400)
+			w.Write([]byte("400 " + err.Error()))
+			return
+		}
+		if err := srv.Restore(f); err != nil {
+			w.WriteHeader(400)
+			w.Write([]byte("400 " + err.Error()))
+			return
+		}
+		w.WriteHeader(204)
+	case strings.HasPrefix(path, "checkpoint/") && r.Method == "POST":
+		srv.Checkpoint(strings.TrimPrefix(path, "checkpoint/"))
+		w.WriteHeader(204)
+	case strings.HasPrefix(path, "rollback/") && r.Method == "POST":
+		err := srv.Rollback(strings.TrimPrefix(path, "rollback/"))
+		if err == ErrNoCheckpoint {
+			w.WriteHeader(404)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(500)
+			w.Write([]byte("500 " + err.Error()))
+			return
+		}
+		w.WriteHeader(204)
+	case path == "journal", path == "fixture", path == "reset", path == "snapshot":
+		w.WriteHeader(405)
+	default:
+		w.WriteHeader(404)
+	}
+}
+
+func (srv *Server) handleAdminJournal(w http.ResponseWriter, r *http.Request) {
+	m := srv.journal.all()
+
+	ids := make([]int, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	result := make([]AdminJournalEntry, 0, len(m))
+	for _, id := range ids {
+		result = append(result, makeAdminJournal(id, m[id])...)
+	}
+
+	writeAdminJSON(w, result)
+}
+
+func writeAdminJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte("500 " + err.Error()))
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(data)
+}
@@ -0,0 +1,153 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/https"
+)
+
+func TestAdminJournal(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	resp, err := srv.Get("servers/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	id := GetIDFromResponse(resp)
+
+	client := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+
+	check := func(url string, wants int) {
+		resp, err := client.Get(url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if err := resp.VerifyJSON(200); err != nil {
+			t.Fatal(err)
+		}
+		var jj []AdminJournalEntry
+		if err := json.NewDecoder(resp.Body).Decode(&jj); err != nil {
+			t.Fatal(err)
+		}
+		if len(jj) != wants {
+			t.Fatalf("%s: %d entries, wants %d", url, len(jj), wants)
+		}
+		for _, j := range jj {
+			if j.ID != id || j.Name != "servers" || j.Method != "GET" || j.Status != 200 {
+				t.Errorf("%s: invalid entry %#v", url, j)
+			}
+		}
+	}
+
+	check(srv.URL()+"/admin/journal/", 1)
+	check(fmt.Sprintf("%s/admin/journal/%d/", srv.URL(), id), 1)
+
+	resp, err = client.Delete(srv.URL()+"/admin/journal/", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := resp.VerifyCode(204); err != nil {
+		t.Error(err)
+	}
+
+	check(srv.URL()+"/admin/journal/", 0)
+}
+
+func TestAdminFixtureAndReset(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	client := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+
+	fixture := `{"servers": [{"uuid": "server-uuid", "name": "test"}]}`
+	resp, err := client.Post(srv.URL()+"/admin/fixture/", nil, strings.NewReader(fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := resp.VerifyCode(204); err != nil {
+		t.Error(err)
+	}
+	if len(srv.servers) != 1 || srv.servers["server-uuid"] == nil {
+		t.Errorf("fixture is not loaded: %v", srv.servers)
+	}
+
+	resp, err = client.Post(srv.URL()+"/admin/reset/", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := resp.VerifyCode(204); err != nil {
+		t.Error(err)
+	}
+	if len(srv.servers) != 0 {
+		t.Errorf("servers are not reset: %v", srv.servers)
+	}
+}
+
+func TestAdminAuth(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	client := https.NewAuthClient(srv.Username(), "wrong", nil)
+	resp, err := client.Get(srv.URL()+"/admin/journal/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := resp.VerifyCode(401); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAdminCheckpoint(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	srv.AddServer(&data.Server{Resource: *data.MakeServerResource("s1")})
+
+	client := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+
+	post := func(path string, wants int) {
+		resp, err := client.Post(srv.URL()+path, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if err := resp.VerifyCode(wants); err != nil {
+			t.Errorf("%s: %s", path, err)
+		}
+	}
+
+	post("/admin/checkpoint/test/", 204)
+	post("/admin/reset/", 204)
+	post("/admin/rollback/unknown/", 404)
+	post("/admin/rollback/test/", 204)
+
+	resp, err := client.Get(srv.URL()+"/admin/snapshot/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := resp.VerifyJSON(200); err != nil {
+		t.Fatal(err)
+	}
+	f, err := ReadFixture(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Servers) != 1 || f.Servers[0].UUID != "s1" {
+		t.Errorf("invalid snapshot: %#v", f)
+	}
+}
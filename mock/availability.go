@@ -0,0 +1,198 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Servers of the mock run on numbered physical hosts and account drives are
+// stored on numbered storage systems, the failure domains. Server start and
+// drive clone place the server or drive into the lowest failure domain holding
+// none of objects from the avoid list, so without avoid list everything shares
+// failure domain 0. Servers not running and drives never placed are in domain 0.
+
+// parseAvoid returns uuids of the avoid request parameter
+func parseAvoid(r *http.Request) []string {
+	var result []string
+	for _, v := range r.URL.Query()["avoid"] {
+		for _, uuid := range strings.Split(v, ",") {
+			if uuid = strings.TrimSpace(uuid); uuid != "" {
+				result = append(result, uuid)
+			}
+		}
+	}
+	return result
+}
+
+// freeDomain returns the lowest failure domain holding none of active objects
+// from the avoid list
+func freeDomain(domains map[string]int, avoid []string, active func(uuid string) bool) int {
+	used := make(map[int]bool)
+	for _, uuid := range avoid {
+		if active(uuid) {
+			used[domains[uuid]] = true
+		}
+	}
+
+	n := 0
+	for used[n] {
+		n++
+	}
+	return n
+}
+
+// domainGroups returns sorted groups of objects sharing failure domain, groups
+// of single object are omitted
+func domainGroups(domains map[string]int, uuids []string) [][]string {
+	sort.Strings(uuids)
+
+	m := make(map[int][]string)
+	var keys []int
+	for _, uuid := range uuids {
+		n := domains[uuid]
+		if _, ok := m[n]; !ok {
+			keys = append(keys, n)
+		}
+		m[n] = append(m[n], uuid)
+	}
+	sort.Ints(keys)
+
+	result := make([][]string, 0, len(keys))
+	for _, n := range keys {
+		if len(m[n]) > 1 {
+			result = append(result, m[n])
+		}
+	}
+	return result
+}
+
+// domainGroup returns sorted objects sharing failure domain with given one
+func domainGroup(domains map[string]int, uuids []string, uuid string) []string {
+	result := make([]string, 0)
+	for _, g := range domainGroups(domains, uuids) {
+		for _, v := range g {
+			if v == uuid {
+				for _, v := range g {
+					if v != uuid {
+						result = append(result, v)
+					}
+				}
+				return result
+			}
+		}
+	}
+	return result
+}
+
+// isServerActive reports whether server runs on physical host
+func isServerActive(status string) bool {
+	return status != "" && !strings.HasPrefix(status, "stopped")
+}
+
+// activeServers returns uuids of servers running on physical hosts, must be
+// called with syncServers locked
+func (srv *Server) activeServers() []string {
+	var result []string
+	for uuid, s := range srv.servers {
+		if isServerActive(s.Status) {
+			result = append(result, uuid)
+		}
+	}
+	return result
+}
+
+// placeServer places server to physical host avoiding hosts of given servers,
+// must be called with syncServers locked
+func (srv *Server) placeServer(uuid string, avoid []string) {
+	srv.hosts[uuid] = freeDomain(srv.hosts, avoid, func(uuid string) bool {
+		s, ok := srv.servers[uuid]
+		return ok && isServerActive(s.Status)
+	})
+}
+
+// URLs:
+// /api/2.0/servers/availability_groups/
+// /api/2.0/servers/availability_groups/{uuid}/
+func (srv *Server) serverGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(405)
+		return
+	}
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	path = strings.TrimPrefix(path, serverBase+"servers/availability_groups")
+	uuid := strings.TrimPrefix(path, "/")
+
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
+
+	if uuid == "" {
+		writeJSON(w, 200, domainGroups(srv.hosts, srv.activeServers()))
+		return
+	}
+
+	if _, ok := srv.servers[uuid]; !ok {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	writeJSON(w, 200, domainGroup(srv.hosts, srv.activeServers(), uuid))
+}
+
+// placeDrive places drive to storage system avoiding storages of given drives
+func (d *DriveLibrary) placeDrive(uuid string, avoid []string) {
+	d.s.Lock()
+	defer d.s.Unlock()
+
+	if d.domains == nil {
+		d.domains = make(map[string]int)
+	}
+	d.domains[uuid] = freeDomain(d.domains, avoid, func(uuid string) bool {
+		_, ok := d.m[uuid]
+		return ok
+	})
+}
+
+// URLs:
+// /api/2.0/drives/availability_groups/
+// /api/2.0/drives/availability_groups/{uuid}/
+func (d *DriveLibrary) handleAvailabilityGroups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(405)
+		return
+	}
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	path = strings.TrimPrefix(path, d.p+"/availability_groups")
+	uuid := strings.TrimPrefix(path, "/")
+
+	d.s.Lock()
+	defer d.s.Unlock()
+
+	uuids := make([]string, 0, len(d.m))
+	for uuid := range d.m {
+		uuids = append(uuids, uuid)
+	}
+
+	if uuid == "" {
+		writeJSON(w, 200, domainGroups(d.domains, uuids))
+		return
+	}
+
+	if _, ok := d.m[uuid]; !ok {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	writeJSON(w, 200, domainGroup(d.domains, uuids, uuid))
+}
@@ -0,0 +1,156 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/https"
+)
+
+func TestDomainGroups(t *testing.T) {
+	domains := map[string]int{"a": 1, "b": 0, "c": 1, "d": 2}
+	uuids := []string{"d", "c", "b", "a", "e"}
+
+	groups := domainGroups(domains, uuids)
+	if wants := [][]string{{"b", "e"}, {"a", "c"}}; !reflect.DeepEqual(groups, wants) {
+		t.Errorf("domainGroups = %v, wants %v", groups, wants)
+	}
+
+	if g := domainGroup(domains, uuids, "c"); !reflect.DeepEqual(g, []string{"a"}) {
+		t.Errorf("domainGroup = %v", g)
+	}
+	if g := domainGroup(domains, uuids, "d"); g == nil || len(g) != 0 {
+		t.Errorf("domainGroup of single object = %v", g)
+	}
+
+	active := func(uuid string) bool { return uuid != "d" }
+	if n := freeDomain(domains, []string{"a", "b"}, active); n != 2 {
+		t.Errorf("freeDomain = %d, wants 2", n)
+	}
+	if n := freeDomain(domains, []string{"a", "d"}, active); n != 0 {
+		t.Errorf("freeDomain of inactive = %d, wants 0", n)
+	}
+}
+
+func getGroups(t *testing.T, cli *https.Client, u string, v interface{}) int {
+	r, err := cli.Get(u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	if r.StatusCode == 200 {
+		if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r.StatusCode
+}
+
+func TestServerAvailabilityGroups(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	uuids := srv.AddServers([]data.Server{{}, {}, {}, {}})
+	sort.Strings(uuids)
+
+	cli := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+
+	start := func(uuid string, avoid ...string) {
+		qq := url.Values{"do": {"start"}}
+		if len(avoid) > 0 {
+			qq.Set("avoid", strings.Join(avoid, ","))
+		}
+		r, err := cli.Post(srv.Endpoint("servers/"+uuid+"/action/"), qq, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Body.Close()
+		if r.StatusCode != 202 {
+			t.Fatalf("start server, code %d", r.StatusCode)
+		}
+	}
+
+	start(uuids[0])
+	start(uuids[1])
+	start(uuids[2], uuids[0])
+	start(uuids[3], uuids[0], uuids[2])
+
+	var groups [][]string
+	if code := getGroups(t, cli, srv.Endpoint("servers/availability_groups/"), &groups); code != 200 {
+		t.Fatalf("availability groups, code %d", code)
+	}
+	if wants := [][]string{{uuids[0], uuids[1]}}; !reflect.DeepEqual(groups, wants) {
+		t.Errorf("availability groups %v, wants %v", groups, wants)
+	}
+
+	var group []string
+	getGroups(t, cli, srv.Endpoint("servers/availability_groups/"+uuids[1]+"/"), &group)
+	if !reflect.DeepEqual(group, []string{uuids[0]}) {
+		t.Errorf("availability group %v", group)
+	}
+
+	if code := getGroups(t, cli, srv.Endpoint("servers/availability_groups/unknown/"), &group); code != 404 {
+		t.Errorf("unknown server, code %d", code)
+	}
+
+	srv.SetServerStatus(uuids[0], "stopped")
+	if getGroups(t, cli, srv.Endpoint("servers/availability_groups/"), &groups); len(groups) != 0 {
+		t.Errorf("stopped server must leave its group, %v", groups)
+	}
+}
+
+func TestDriveAvailabilityGroups(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	drv := &data.Drive{Name: "data", Size: 1024, StorageType: "dssd"}
+	if err := srv.Drives.Add(drv); err != nil {
+		t.Fatal(err)
+	}
+
+	cli := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+
+	clone := func(avoid ...string) string {
+		qq := url.Values{"do": {"clone"}}
+		if len(avoid) > 0 {
+			qq.Set("avoid", strings.Join(avoid, ","))
+		}
+		r, err := cli.Post(srv.Endpoint("drives/"+drv.UUID+"/action/"), qq, strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Body.Close()
+		dd, err := data.ReadDrives(r.Body)
+		if err != nil || len(dd) != 1 {
+			t.Fatalf("clone drive, code %d, error %v", r.StatusCode, err)
+		}
+		return dd[0].UUID
+	}
+
+	near := clone()
+	far := clone(drv.UUID)
+
+	var group []string
+	getGroups(t, cli, srv.Endpoint("drives/availability_groups/"+drv.UUID+"/"), &group)
+	if !reflect.DeepEqual(group, []string{near}) {
+		t.Errorf("availability group %v, wants %v", group, []string{near})
+	}
+
+	getGroups(t, cli, srv.Endpoint("drives/availability_groups/"+far+"/"), &group)
+	if len(group) != 0 {
+		t.Errorf("drive cloned with avoid list shares storage with %v", group)
+	}
+
+	srv.Drives.Reset()
+	if srv.Drives.domains != nil {
+		t.Error("reset must remove placement of drives")
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+)
+
+// ledgerTimeLayout defines format of time filters of ledger requests
+const ledgerTimeLayout = "2006-01-02T15:04:05"
+
+type billing struct {
+	s       sync.Mutex
+	balance data.Balance
+	ledger  []data.LedgerEntry
+	seq     int
+}
+
+// defaultBalance defines balance of new mock account
+var defaultBalance = data.Balance{Balance: "0", Currency: "USD"}
+
+// WithBalance returns Option setting balance of the account
+func WithBalance(b data.Balance) Option {
+	return func(srv *Server) { srv.SetBalance(b) }
+}
+
+// SetBalance sets balance of the account. Balance is kept by Reset.
+func (srv *Server) SetBalance(b data.Balance) {
+	srv.billing.s.Lock()
+	defer srv.billing.s.Unlock()
+	srv.billing.balance = b
+}
+
+// Balance returns balance of the account
+func (srv *Server) Balance() data.Balance {
+	srv.billing.s.Lock()
+	defer srv.billing.s.Unlock()
+	if srv.billing.balance.Currency == "" {
+		return defaultBalance
+	}
+	return srv.billing.balance
+}
+
+// AddLedgerEntries adds records to the ledger of the account, records without
+// ID are numbered sequentially. Returns IDs of added records.
+func (srv *Server) AddLedgerEntries(ee []data.LedgerEntry) []string {
+	srv.billing.s.Lock()
+	defer srv.billing.s.Unlock()
+
+	var result []string
+	for _, e := range ee {
+		srv.billing.seq++
+		if e.ID == "" {
+			e.ID = strconv.Itoa(srv.billing.seq)
+		}
+		e.Resource = *data.MakeLedgerResource(e.ID)
+		srv.billing.ledger = append(srv.billing.ledger, e)
+		result = append(result, e.ID)
+	}
+	sort.SliceStable(srv.billing.ledger, func(i, j int) bool {
+		return srv.billing.ledger[i].Time.Before(srv.billing.ledger[j].Time)
+	})
+	return result
+}
+
+// ResetLedger removes all records from the ledger of the account
+func (srv *Server) ResetLedger() {
+	srv.billing.s.Lock()
+	defer srv.billing.s.Unlock()
+	srv.billing.ledger = nil
+	srv.billing.seq = 0
+}
+
+// ledgerSnapshot returns copy of the ledger records
+func (srv *Server) ledgerSnapshot() []data.LedgerEntry {
+	srv.billing.s.Lock()
+	defer srv.billing.s.Unlock()
+	return append([]data.LedgerEntry(nil), srv.billing.ledger...)
+}
+
+// SetBalance sets balance of the account of the default mock
+func SetBalance(b data.Balance) { defaultServer.SetBalance(b) }
+
+// AddLedgerEntries adds records to the ledger of the account of the default mock
+func AddLedgerEntries(ee []data.LedgerEntry) []string { return defaultServer.AddLedgerEntries(ee) }
+
+// currentUsage returns usage of the account by billable resource. CPU and RAM
+// are used by running servers, drives use storage of their type, IP addresses
+// and VLANs are used by their subscriptions. Usage not covered by active
+// subscriptions is burst.
+func (srv *Server) currentUsage() map[string]data.Usage {
+	using := map[string]uint64{"cpu": 0, "mem": 0, defaultStorageType: 0, "ip": 0, "vlan": 0}
+	subscribed := srv.Subscriptions.subscribed()
+	using["ip"] = subscribed["ip"]
+	using["vlan"] = subscribed["vlan"]
+
+	srv.syncServers.Lock()
+	for _, s := range srv.servers {
+		if s.Status == "running" {
+			using["cpu"] += s.CPU
+			using["mem"] += s.Mem
+		}
+	}
+	srv.syncServers.Unlock()
+
+	srv.Drives.s.Lock()
+	for _, d := range srv.Drives.m {
+		st := d.StorageType
+		if st == "" {
+			st = defaultStorageType
+		}
+		using[st] += d.Size
+	}
+	srv.Drives.s.Unlock()
+
+	for k := range subscribed {
+		if _, ok := using[k]; !ok {
+			using[k] = 0
+		}
+	}
+
+	result := make(map[string]data.Usage, len(using))
+	for k, v := range using {
+		u := data.Usage{Subscribed: subscribed[k], Using: v}
+		if u.Using > u.Subscribed {
+			u.Burst = u.Using - u.Subscribed
+		}
+		result[k] = u
+	}
+	return result
+}
+
+// URLs:
+// /api/2.0/balance/
+func (srv *Server) balanceHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(405)
+		return
+	}
+	b := srv.Balance()
+	writeJSON(w, 200, &b)
+}
+
+// URLs:
+// /api/2.0/currentusage/
+func (srv *Server) currentUsageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(405)
+		return
+	}
+	u := data.CurrentUsage{Balance: srv.Balance(), Usage: srv.currentUsage()}
+	writeJSON(w, 200, &u)
+}
+
+// URLs:
+// /api/2.0/ledger/
+// /api/2.0/ledger/{id}/
+func (srv *Server) ledgerHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(405)
+		return
+	}
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	path = strings.TrimPrefix(path, "/api/2.0/ledger")
+	path = strings.TrimPrefix(path, "/")
+
+	if path != "" {
+		for _, e := range srv.ledgerSnapshot() {
+			if e.ID == path {
+				writeJSON(w, 200, &e)
+				return
+			}
+		}
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	parse := func(key string) (time.Time, error) {
+		v := r.URL.Query().Get(key)
+		if v == "" {
+			return time.Time{}, nil
+		}
+		t, err := time.Parse(ledgerTimeLayout, v)
+		if err != nil {
+			return t, invalid(key, "Enter a valid date/time.")
+		}
+		return t, nil
+	}
+	after, err := parse("time__gt")
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	before, err := parse("time__lt")
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	var ledger data.Ledger
+	ledger.Objects = []data.LedgerEntry{}
+	for _, e := range srv.ledgerSnapshot() {
+		if !after.IsZero() && !e.Time.After(after) {
+			continue
+		}
+		if !before.IsZero() && !e.Time.Before(before) {
+			continue
+		}
+		ledger.Objects = append(ledger.Objects, e)
+	}
+	ledger.Meta.TotalCount = len(ledger.Objects)
+
+	writeJSON(w, 200, &ledger)
+}
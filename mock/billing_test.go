@@ -0,0 +1,98 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/https"
+)
+
+func TestBillingState(t *testing.T) {
+	srv := New(WithBalance(data.Balance{Balance: "10", Currency: "CHF"}))
+	defer srv.Close()
+
+	ids := srv.AddLedgerEntries([]data.LedgerEntry{
+		{Amount: "-1", Time: time.Date(2014, 2, 10, 12, 0, 0, 0, time.UTC)},
+		{ID: "custom", Amount: "5", Time: time.Date(2014, 2, 10, 11, 0, 0, 0, time.UTC)},
+	})
+	if len(ids) != 2 || ids[0] != "1" || ids[1] != "custom" {
+		t.Errorf("invalid ledger IDs %v", ids)
+	}
+
+	f := srv.Snapshot()
+	if f.Balance == nil || f.Balance.Currency != "CHF" {
+		t.Errorf("invalid balance snapshot %#v", f.Balance)
+	}
+	if len(f.Ledger) != 2 || f.Ledger[0].ID != "custom" || f.Ledger[1].URI != "/api/2.0/ledger/1/" {
+		t.Errorf("invalid ledger snapshot %#v", f.Ledger)
+	}
+
+	srv.Reset()
+	if b := srv.Balance(); b.Currency != "CHF" {
+		t.Errorf("balance must be kept by Reset, got %#v", b)
+	}
+	if n := len(srv.Snapshot().Ledger); n != 0 {
+		t.Errorf("ledger must be cleared by Reset, got %d records", n)
+	}
+
+	srv.SetBalance(data.Balance{})
+	if b := srv.Balance(); b != defaultBalance {
+		t.Errorf("invalid default balance %#v", b)
+	}
+
+	if err := srv.Restore(f); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(srv.Snapshot().Ledger); n != 2 {
+		t.Errorf("ledger must be restored, got %d records", n)
+	}
+	if b := srv.Balance(); b.Currency != "CHF" {
+		t.Errorf("balance must be restored, got %#v", b)
+	}
+}
+
+func TestBillingLedgerRequests(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	srv.AddLedgerEntries([]data.LedgerEntry{{Amount: "-1", Reason: "test"}})
+
+	client := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+
+	resp, err := client.Get(srv.Endpoint("ledger/1/"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e data.LedgerEntry
+	err = data.ReadJSON(resp.Body, &e)
+	resp.Body.Close()
+	if err != nil {
+		t.Error(err)
+	} else if err := resp.VerifyJSON(200); err != nil {
+		t.Error(err)
+	} else if e.ID != "1" || e.Reason != "test" {
+		t.Errorf("invalid ledger entry %#v", e)
+	}
+
+	resp, err = client.Get(srv.Endpoint("ledger/2/"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := resp.VerifyJSON(404); err != nil {
+		t.Error(err)
+	}
+
+	resp, err = client.Get(srv.Endpoint("ledger/"), map[string][]string{"time__gt": {"yesterday"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := resp.VerifyJSON(400); err != nil {
+		t.Error(err)
+	}
+}
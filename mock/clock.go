@@ -0,0 +1,138 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// A Clock provides time to the mock state machine
+type Clock interface {
+	// Now returns current time
+	Now() time.Time
+	// AfterFunc calls f in its own goroutine after duration d elapsed
+	AfterFunc(d time.Duration, f func())
+}
+
+// RealClock implements Clock with wall time
+type RealClock struct{}
+
+var _ Clock = RealClock{}
+
+// Now returns current wall time
+func (RealClock) Now() time.Time { return time.Now() }
+
+// AfterFunc calls f in its own goroutine after duration d elapsed
+func (RealClock) AfterFunc(d time.Duration, f func()) { time.AfterFunc(d, f) }
+
+// A ManualClock implements Clock, which time moves only with Advance calls.
+// It makes transitions of the mock state machine deterministic.
+type ManualClock struct {
+	s      sync.Mutex
+	now    time.Time
+	seq    int
+	timers []manualTimer
+}
+
+type manualTimer struct {
+	at  time.Time
+	seq int
+	f   func()
+}
+
+var _ Clock = (*ManualClock)(nil)
+
+// NewManualClock returns new ManualClock starting at given time
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now returns current time of the clock
+func (c *ManualClock) Now() time.Time {
+	c.s.Lock()
+	defer c.s.Unlock()
+	return c.now
+}
+
+// AfterFunc schedules f to be called by Advance after duration d
+func (c *ManualClock) AfterFunc(d time.Duration, f func()) {
+	c.s.Lock()
+	defer c.s.Unlock()
+	c.seq++
+	c.timers = append(c.timers, manualTimer{c.now.Add(d), c.seq, f})
+}
+
+// Advance moves the clock forward by duration d, calling scheduled functions
+// which became due, in order of their time. Functions are called synchronously.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.s.Lock()
+	until := c.now.Add(d)
+	c.s.Unlock()
+
+	for {
+		c.s.Lock()
+		sort.Slice(c.timers, func(i, j int) bool {
+			if c.timers[i].at.Equal(c.timers[j].at) {
+				return c.timers[i].seq < c.timers[j].seq
+			}
+			return c.timers[i].at.Before(c.timers[j].at)
+		})
+		if len(c.timers) == 0 || c.timers[0].at.After(until) {
+			c.now = until
+			c.s.Unlock()
+			return
+		}
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		if t.at.After(c.now) {
+			c.now = t.at
+		}
+		c.s.Unlock()
+
+		t.f()
+	}
+}
+
+// Pending returns number of scheduled functions not called yet
+func (c *ManualClock) Pending() int {
+	c.s.Lock()
+	defer c.s.Unlock()
+	return len(c.timers)
+}
+
+// Transitions defines durations of the mock state machine transitions
+type Transitions struct {
+	// Start is duration of server transition from "starting" to "running"
+	Start time.Duration
+	// Stop is duration of server transition from "stopping" to "stopped"
+	Stop time.Duration
+	// Clone is duration of drive cloning job, its progress is 50 at the half of duration
+	Clone time.Duration
+	// Snapshot is duration of remote snapshot jobs: creation of the snapshot,
+	// cloning and restoring drive from it
+	Snapshot time.Duration
+}
+
+// DefaultTransitions defines transition durations of the mock state machine
+var DefaultTransitions = Transitions{
+	Start:    300 * time.Millisecond,
+	Stop:     300 * time.Millisecond,
+	Clone:    10 * time.Millisecond,
+	Snapshot: 10 * time.Millisecond,
+}
+
+// WithClock returns Option setting clock for the mock state machine
+func WithClock(clock Clock) Option {
+	return func(srv *Server) { srv.clock = clock }
+}
+
+// WithTransitions returns Option setting durations of the mock state machine transitions
+func WithTransitions(transitions Transitions) Option {
+	return func(srv *Server) { srv.transitions = transitions }
+}
+
+// Clock returns clock of the server state machine
+func (srv *Server) Clock() Clock { return srv.clock }
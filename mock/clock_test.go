@@ -0,0 +1,76 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+)
+
+func TestManualClockAdvance(t *testing.T) {
+	start := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewManualClock(start)
+
+	var calls []int
+	c.AfterFunc(20*time.Millisecond, func() { calls = append(calls, 2) })
+	c.AfterFunc(10*time.Millisecond, func() { calls = append(calls, 1) })
+	c.AfterFunc(20*time.Millisecond, func() { calls = append(calls, 3) })
+
+	c.Advance(5 * time.Millisecond)
+	if len(calls) != 0 || c.Pending() != 3 {
+		t.Errorf("calls = %v, pending = %d", calls, c.Pending())
+	}
+
+	c.Advance(15 * time.Millisecond)
+	if len(calls) != 3 || calls[0] != 1 || calls[1] != 2 || calls[2] != 3 {
+		t.Errorf("calls = %v", calls)
+	}
+	if c.Pending() != 0 {
+		t.Errorf("pending = %d, wants 0", c.Pending())
+	}
+
+	if now := c.Now(); !now.Equal(start.Add(20 * time.Millisecond)) {
+		t.Errorf("Now() = %v", now)
+	}
+}
+
+func TestManualClockDriveClone(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	srv := newServer(WithClock(clock))
+
+	drv := &data.Drive{}
+	if err := srv.Drives.Add(drv); err != nil {
+		t.Fatal(err)
+	}
+
+	newUUID, err := srv.Drives.Clone(drv.UUID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(progress int, state, status string) {
+		newDrive := srv.Drives.m[newUUID]
+		if len(newDrive.Jobs) != 1 {
+			t.Fatalf("drive jobs = %v", newDrive.Jobs)
+		}
+		job := srv.Jobs.m[newDrive.Jobs[0].UUID]
+		if job.Data.Progress != progress {
+			t.Errorf("job progress = %d, wants %d", job.Data.Progress, progress)
+		}
+		if job.State != state {
+			t.Errorf("job state = %q, wants %q", job.State, state)
+		}
+		if newDrive.Status != status {
+			t.Errorf("drive status = %q, wants %q", newDrive.Status, status)
+		}
+	}
+
+	check(0, "started", "cloning_dst")
+	clock.Advance(DefaultTransitions.Clone / 2)
+	check(50, "started", "cloning_dst")
+	clock.Advance(DefaultTransitions.Clone / 2)
+	check(100, "success", "unmounted")
+}
@@ -0,0 +1,109 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"bytes"
+	"errors"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"time"
+)
+
+// syntheticImage generates contents of drive without uploaded image, every
+// byte is derived from uuid of the drive and its offset
+type syntheticImage struct {
+	seed uint32
+	size int64
+	off  int64
+}
+
+func newSyntheticImage(uuid string, size int64) *syntheticImage {
+	h := fnv.New32a()
+	h.Write([]byte(uuid))
+	return &syntheticImage{seed: h.Sum32(), size: size}
+}
+
+func (s *syntheticImage) at(off int64) byte {
+	x := s.seed ^ uint32(off>>2)*2654435761
+	x ^= x >> 15
+	return byte(x >> (8 * uint(off&3)))
+}
+
+func (s *syntheticImage) Read(p []byte) (int, error) {
+	if s.off >= s.size {
+		return 0, io.EOF
+	}
+	n := 0
+	for ; n < len(p) && s.off < s.size; n++ {
+		p[n] = s.at(s.off)
+		s.off++
+	}
+	return n, nil
+}
+
+func (s *syntheticImage) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += s.off
+	case io.SeekEnd:
+		offset += s.size
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	s.off = offset
+	return offset, nil
+}
+
+// SetDriveImage sets contents of drive served by download requests. Drives
+// without uploaded or set image of their size serve synthetic contents.
+func (srv *Server) SetDriveImage(uuid string, image []byte) {
+	srv.uploads.s.Lock()
+	defer srv.uploads.s.Unlock()
+	if srv.uploads.images == nil {
+		srv.uploads.images = make(map[string][]byte)
+	}
+	srv.uploads.images[uuid] = append([]byte(nil), image...)
+}
+
+// removeDriveImage makes drive serve synthetic contents
+func (srv *Server) removeDriveImage(uuid string) {
+	srv.uploads.s.Lock()
+	defer srv.uploads.s.Unlock()
+	delete(srv.uploads.images, uuid)
+}
+
+// handleDownload serves contents of the drive, ranged requests are supported
+func (d *DriveLibrary) handleDownload(w http.ResponseWriter, r *http.Request, uuid string) {
+	if d != d.srv.Drives {
+		w.WriteHeader(405)
+		return
+	}
+
+	d.s.Lock()
+	var size int64
+	drv, ok := d.m[uuid]
+	if ok {
+		size = int64(drv.Size)
+	}
+	d.s.Unlock()
+
+	if !ok {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	var content io.ReadSeeker = newSyntheticImage(uuid, size)
+	if image, ok := d.srv.DriveImage(uuid); ok && int64(len(image)) == size {
+		content = bytes.NewReader(image)
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, "", time.Time{}, content)
+}
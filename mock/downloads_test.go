@@ -0,0 +1,80 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/https"
+)
+
+func TestSyntheticImage(t *testing.T) {
+	full, err := ioutil.ReadAll(newSyntheticImage("uuid", 1000))
+	if err != nil || len(full) != 1000 {
+		t.Fatalf("invalid synthetic image of %d bytes, %v", len(full), err)
+	}
+
+	s := newSyntheticImage("uuid", 1000)
+	if _, err := s.Seek(-10, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	tail, err := ioutil.ReadAll(s)
+	if err != nil || !bytes.Equal(tail, full[990:]) {
+		t.Errorf("invalid tail of synthetic image %v %v", tail, err)
+	}
+
+	other, _ := ioutil.ReadAll(newSyntheticImage("other", 1000))
+	if bytes.Equal(full, other) {
+		t.Error("synthetic images of different drives must differ")
+	}
+}
+
+func TestDownloadHandler(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("uuid"), Size: 1000})
+	srv.LibDrives.Add(&data.Drive{Resource: *data.MakeLibDriveResource("libuuid"), Size: 1000})
+
+	cli := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+	ctx := context.Background()
+
+	get := func(section string, h http.Header) (int, []byte) {
+		r, err := cli.Do(ctx, "GET", srv.Endpoint(section), nil, h, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Body.Close()
+		bb, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r.StatusCode, bb
+	}
+
+	synthetic, _ := ioutil.ReadAll(newSyntheticImage("uuid", 1000))
+	if code, bb := get("drives/uuid/download/", nil); code != 200 || !bytes.Equal(bb, synthetic) {
+		t.Errorf("invalid synthetic download, code %d", code)
+	}
+
+	image := bytes.Repeat([]byte("0123456789"), 100)
+	srv.SetDriveImage("uuid", image)
+	code, bb := get("drives/uuid/download/", http.Header{"Range": {"bytes=100-109"}})
+	if code != 206 || string(bb) != "0123456789" {
+		t.Errorf("invalid ranged download, code %d, body %q", code, bb)
+	}
+
+	if code, _ := get("drives/missing/download/", nil); code != 404 {
+		t.Errorf("download of missing drive, code %d", code)
+	}
+	if code, _ := get("libdrives/libuuid/download/", nil); code != 405 {
+		t.Errorf("download of library drive, code %d", code)
+	}
+}
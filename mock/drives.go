@@ -11,29 +11,24 @@ import (
 	"net/http"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/altoros/gosigma/data"
 )
 
 // DriveLibrary defines type for mock drive library
 type DriveLibrary struct {
-	s sync.Mutex
-	m map[string]*data.Drive
-	p string
+	s       sync.Mutex
+	m       map[string]*data.Drive
+	p       string
+	srv     *Server
+	domains map[string]int
 }
 
-// Drives defines user account drives
-var Drives = &DriveLibrary{
-	m: make(map[string]*data.Drive),
-	p: "/api/2.0/drives",
-}
+// Drives defines user account drives of the default mock
+var Drives = defaultServer.Drives
 
-// LibDrives defines public drives
-var LibDrives = &DriveLibrary{
-	m: make(map[string]*data.Drive),
-	p: "/api/2.0/libdrives",
-}
+// LibDrives defines public drives of the default mock
+var LibDrives = defaultServer.LibDrives
 
 // ResetDrives clean-up all drive libraries
 func ResetDrives() {
@@ -79,11 +74,13 @@ func (d *DriveLibrary) AddDrives(dd []data.Drive) []string {
 
 	var result []string
 	for _, drv := range dd {
-		drv, err := InitDrive(&drv)
+		drv := drv
+		pd, err := InitDrive(&drv)
 		if err != nil {
-			d.m[drv.UUID] = drv
-			result = append(result, drv.UUID)
+			continue
 		}
+		d.m[pd.UUID] = pd
+		result = append(result, pd.UUID)
 	}
 	return result
 }
@@ -95,6 +92,7 @@ func (d *DriveLibrary) Remove(uuid string) bool {
 
 	_, ok := d.m[uuid]
 	delete(d.m, uuid)
+	delete(d.domains, uuid)
 
 	return ok
 }
@@ -104,6 +102,7 @@ func (d *DriveLibrary) Reset() {
 	d.s.Lock()
 	defer d.s.Unlock()
 	d.m = make(map[string]*data.Drive)
+	d.domains = nil
 }
 
 // SetStatus sets drive status in the library
@@ -139,21 +138,19 @@ func (d *DriveLibrary) Clone(uuid string, params map[string]interface{}) (string
 	newDrive.Resource = *data.MakeDriveResource(newUUID)
 	newDrive.Status = "cloning_dst"
 	newDrive.Jobs = nil
+	newDrive.MountedOn = nil
 
-	job := &data.Job{}
-	Jobs.Add(job)
+	job := &data.Job{
+		Operation: "drive_clone",
+		Resources: []string{drv.URI, newDrive.URI},
+	}
+	d.srv.Jobs.Add(job)
 
 	newDrive.Jobs = append(newDrive.Jobs, *data.MakeJobResource(job.UUID))
 
-	cloning := func() {
-		<-time.After(10 * time.Millisecond)
-		Jobs.s.Lock()
-		defer Jobs.s.Unlock()
-		job.Data.Progress = 100
-		job.State = "success"
-		d.SetStatus(newDrive.UUID, "unmounted")
-	}
-	go cloning()
+	d.srv.Jobs.run(job.UUID, d.srv.transitions.Clone, func() {
+		d.srv.Drives.SetStatus(newDrive.UUID, "unmounted")
+	})
 
 	if s, ok := params["name"].(string); ok {
 		newDrive.Name = s
@@ -162,8 +159,8 @@ func (d *DriveLibrary) Clone(uuid string, params map[string]interface{}) (string
 		newDrive.Media = s
 	}
 
-	if d == LibDrives {
-		Drives.Add(&newDrive)
+	if d == d.srv.LibDrives {
+		d.srv.Drives.Add(&newDrive)
 	} else {
 		d.m[newUUID] = &newDrive
 	}
@@ -196,12 +193,19 @@ func (d *DriveLibrary) handleRequest(w http.ResponseWriter, r *http.Request) {
 		d.handleGet(w, r, path)
 	case "POST":
 		d.handlePost(w, r, path)
+	case "PUT":
+		d.handlePut(w, r, path)
 	case "DELETE":
 		d.handleDelete(w, r, path)
 	}
 }
 
 func (d *DriveLibrary) handleGet(w http.ResponseWriter, r *http.Request, path string) {
+	if uuid := strings.TrimSuffix(path, "/download"); uuid != path {
+		d.handleDownload(w, r, uuid)
+		return
+	}
+
 	switch path {
 	case "":
 		d.handleDrives(w, r)
@@ -217,6 +221,61 @@ func (d *DriveLibrary) handlePost(w http.ResponseWriter, r *http.Request, path s
 	d.handleAction(w, r, uuid)
 }
 
+// handlePut updates name, media, meta-information and access control lists of
+// the drive, other properties of the drive are changed with actions
+func (d *DriveLibrary) handlePut(w http.ResponseWriter, r *http.Request, uuid string) {
+	if d != d.srv.Drives || uuid == "" {
+		w.WriteHeader(405)
+		return
+	}
+
+	drv, err := data.ReadDrive(r.Body)
+	if err != nil {
+		w.WriteHeader(400)
+		return
+	}
+
+	if drv.Media != "" {
+		if err := checkEnum("media", drv.Media, "disk", "cdrom"); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+	}
+
+	if err := d.srv.validateACLs(drv.ACLs); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	d.s.Lock()
+	current, ok := d.m[uuid]
+	if ok {
+		if drv.Name != "" {
+			current.Name = drv.Name
+		}
+		if drv.Media != "" {
+			current.Media = drv.Media
+		}
+		if drv.Meta != nil {
+			current.Meta = drv.Meta
+		}
+		if drv.ACLs != nil {
+			current.ACLs = drv.ACLs
+		}
+	}
+	d.s.Unlock()
+
+	if !ok {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	d.handleDrive(w, r, 200, uuid)
+}
+
 func (d *DriveLibrary) handleDelete(w http.ResponseWriter, r *http.Request, uuid string) {
 	if ok := d.Remove(uuid); !ok {
 		h := w.Header()
@@ -350,6 +409,27 @@ func (d *DriveLibrary) handleClone(w http.ResponseWriter, r *http.Request, uuid
 		return
 	}
 
+	if err := validateCloneParams(params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	d.s.Lock()
+	source, ok := d.m[uuid]
+	var storageType string
+	var size uint64
+	if ok {
+		storageType, size = source.StorageType, source.Size
+	}
+	d.s.Unlock()
+
+	if ok {
+		if err := d.srv.checkDriveQuota(storageType, size); err != nil {
+			writeQuotaError(w, err)
+			return
+		}
+	}
+
 	newUUID, err := d.Clone(uuid, params)
 	if err == ErrNotFound {
 		h := w.Header()
@@ -362,7 +442,8 @@ func (d *DriveLibrary) handleClone(w http.ResponseWriter, r *http.Request, uuid
 		w.Write([]byte("500 " + err.Error()))
 		return
 	}
-	Drives.handleDrivesDetail(w, r, 202, []string{newUUID})
+	d.srv.Drives.placeDrive(newUUID, parseAvoid(r))
+	d.srv.Drives.handleDrivesDetail(w, r, 202, []string{newUUID})
 }
 
 func (d *DriveLibrary) handleResize(w http.ResponseWriter, r *http.Request, uuid string) {
@@ -380,6 +461,28 @@ func (d *DriveLibrary) handleResize(w http.ResponseWriter, r *http.Request, uuid
 		return
 	}
 
+	d.s.Lock()
+	current, ok := d.m[uuid]
+	var storageType string
+	var size uint64
+	if ok {
+		storageType, size = current.StorageType, current.Size
+	}
+	d.s.Unlock()
+
+	if ok {
+		if err := validateDriveSize(drv.Size, storageType); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+		if d == d.srv.Drives && drv.Size > size {
+			if err := d.srv.checkDriveQuota(storageType, drv.Size-size); err != nil {
+				writeQuotaError(w, err)
+				return
+			}
+		}
+	}
+
 	err = d.Resize(uuid, drv.Size)
 	if err == ErrNotFound {
 		h := w.Header()
@@ -0,0 +1,234 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Fault defines rule of fault injection into responses of the mock server.
+// Rule matches request by method and path, matching request is answered with
+// fault response instead of regular one.
+type Fault struct {
+	// Method of request to match, empty value matches any method
+	Method string
+	// Path prefix of request to match, relative to '/api/2.0/', for example
+	// "servers/" or "drives/{uuid}/action/". Empty value matches any path.
+	Path string
+
+	// EveryNth applies the fault to every Nth matching request only,
+	// values less than two apply the fault to every matching request
+	EveryNth int
+	// Count limits number of times the fault is applied, zero means no limit
+	Count int
+
+	// Code of HTTP response. If not zero, request is not passed to regular
+	// handler and response carries CloudSigma error object, or Body if set.
+	Code int
+	// Body of fault response, used with non-zero Code only
+	Body string
+	// ContentType overrides Content-Type header of the response
+	ContentType string
+	// Latency delays the response, as measured by the server clock
+	Latency time.Duration
+	// Drop closes connection after sending headers and half of response body
+	Drop bool
+}
+
+type faultRule struct {
+	Fault
+	id      int
+	matched int
+	applied int
+}
+
+type faults struct {
+	s     sync.Mutex
+	seq   int
+	rules []*faultRule
+}
+
+func (ff *faults) add(f Fault) int {
+	ff.s.Lock()
+	defer ff.s.Unlock()
+	ff.seq++
+	ff.rules = append(ff.rules, &faultRule{Fault: f, id: ff.seq})
+	return ff.seq
+}
+
+func (ff *faults) remove(id int) bool {
+	ff.s.Lock()
+	defer ff.s.Unlock()
+	for i, rule := range ff.rules {
+		if rule.id == id {
+			ff.rules = append(ff.rules[:i], ff.rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (ff *faults) reset() {
+	ff.s.Lock()
+	defer ff.s.Unlock()
+	ff.rules = nil
+}
+
+// match finds first fault to apply to the request
+func (ff *faults) match(r *http.Request) *Fault {
+	path := strings.TrimPrefix(r.URL.Path, serverBase)
+
+	ff.s.Lock()
+	defer ff.s.Unlock()
+
+	for _, rule := range ff.rules {
+		if rule.Method != "" && rule.Method != r.Method {
+			continue
+		}
+		if !strings.HasPrefix(path, rule.Path) {
+			continue
+		}
+		if rule.Count > 0 && rule.applied >= rule.Count {
+			continue
+		}
+		rule.matched++
+		if rule.EveryNth > 1 && rule.matched%rule.EveryNth != 0 {
+			continue
+		}
+		rule.applied++
+		f := rule.Fault
+		return &f
+	}
+
+	return nil
+}
+
+const jsonFault = `[{
+		"error_point": null,
+		"error_type": "%s",
+		"error_message": "%s"
+}]`
+
+func faultErrorType(code int) string {
+	switch {
+	case code == 402:
+		return "billing"
+	case code == 403:
+		return "permission"
+	case code == 404:
+		return "notexist"
+	case code == 409, code == 429:
+		return "concurrency"
+	case code >= 500:
+		return "backend"
+	}
+	return "validation"
+}
+
+// writeResponse writes fault response to the recorder
+func (f Fault) writeResponse(rec *httptest.ResponseRecorder) {
+	body := f.Body
+	if body == "" {
+		body = fmt.Sprintf(jsonFault, faultErrorType(f.Code), http.StatusText(f.Code))
+	}
+	rec.Header().Set("Content-Type", "application/json; charset=utf-8")
+	rec.WriteHeader(f.Code)
+	rec.Write([]byte(body))
+}
+
+// delay waits for duration d of the server clock or until the client gives up
+// the request, so ManualClock releases delayed responses with Advance
+func (srv *Server) delay(r *http.Request, d time.Duration) {
+	done := make(chan struct{})
+	srv.clock.AfterFunc(d, func() { close(done) })
+	select {
+	case <-done:
+	case <-r.Context().Done():
+	}
+}
+
+// hijacker returns connection hijacker of the response writer, possibly
+// wrapped by other writers
+func hijacker(w http.ResponseWriter) (http.Hijacker, bool) {
+	for {
+		if hj, ok := w.(http.Hijacker); ok {
+			return hj, true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil, false
+		}
+		w = u.Unwrap()
+	}
+}
+
+// drop sends headers and half of recorded response, then closes connection.
+// If the connection cannot be hijacked, half of response is written with
+// Content-Length of the full one, so the client receives truncated response.
+func (f Fault) drop(w http.ResponseWriter, rec *httptest.ResponseRecorder) {
+	body := rec.Body.Bytes()
+
+	hj, ok := hijacker(w)
+	if !ok {
+		h := w.Header()
+		for k, v := range rec.Header() {
+			h[k] = v
+		}
+		h.Set("Content-Length", fmt.Sprint(len(body)))
+		w.WriteHeader(rec.Code)
+		w.Write(body[:len(body)/2])
+		return
+	}
+
+	conn, buf, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	bw := buf.Writer
+	fmt.Fprintf(bw, "HTTP/1.1 %d %s\r\n", rec.Code, http.StatusText(rec.Code))
+	hdr := rec.Header()
+	hdr.Set("Content-Length", fmt.Sprint(len(body)))
+	hdr.Write(bw)
+	fmt.Fprint(bw, "\r\n")
+	bw.Write(body[:len(body)/2])
+	bw.Flush()
+}
+
+// AddFault installs fault injection rule into the server and returns its id.
+// Rules are checked in order of installation, first matching rule is applied.
+func (srv *Server) AddFault(f Fault) int {
+	return srv.faults.add(f)
+}
+
+// RemoveFault removes fault injection rule with given id from the server
+func (srv *Server) RemoveFault(id int) bool {
+	return srv.faults.remove(id)
+}
+
+// ResetFaults removes all fault injection rules from the server
+func (srv *Server) ResetFaults() {
+	srv.faults.reset()
+}
+
+// AddFault installs fault injection rule into the default mock
+func AddFault(f Fault) int {
+	return defaultServer.AddFault(f)
+}
+
+// RemoveFault removes fault injection rule from the default mock
+func RemoveFault(id int) bool {
+	return defaultServer.RemoveFault(id)
+}
+
+// ResetFaults removes all fault injection rules from the default mock
+func ResetFaults() {
+	defaultServer.ResetFaults()
+}
@@ -0,0 +1,218 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+)
+
+func TestFaultCode(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	for _, code := range []int{403, 404, 429, 500, 503} {
+		srv.ResetFaults()
+		srv.AddFault(Fault{Method: "GET", Path: "servers/", Code: code})
+
+		resp, err := srv.Get("servers/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := resp.VerifyCode(code); err != nil {
+			t.Error(err)
+		}
+
+		ee, err := data.ReadError(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(ee) != 1 || ee[0].Type != faultErrorType(code) {
+			t.Errorf("code %d: invalid error %#v", code, ee)
+		}
+
+		jj := srv.GetJournal(GetIDFromResponse(resp))
+		if len(jj) != 1 || jj[0].Response.Code != code {
+			t.Errorf("code %d: invalid journal %v", code, jj)
+		}
+	}
+
+	resp, err := srv.Get("drives/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := resp.VerifyJSON(200); err != nil {
+		t.Error("fault must not match other path:", err)
+	}
+}
+
+func TestFaultEveryNthAndCount(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	srv.AddFault(Fault{Path: "drives/", Code: 500, EveryNth: 2, Count: 2})
+
+	codes := []int{200, 500, 200, 500, 200, 200}
+	for i, code := range codes {
+		resp, err := srv.Get("drives/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != code {
+			t.Errorf("request %d: code %d, wants %d", i, resp.StatusCode, code)
+		}
+	}
+}
+
+func TestFaultRemove(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	id := srv.AddFault(Fault{Code: 503})
+	if !srv.RemoveFault(id) {
+		t.Error("RemoveFault must succeed")
+	}
+	if srv.RemoveFault(id) {
+		t.Error("RemoveFault must fail for removed rule")
+	}
+
+	resp, err := srv.Get("servers/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := resp.VerifyJSON(200); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFaultContentType(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	srv.AddFault(Fault{Path: "servers/", ContentType: "text/html"})
+
+	resp, err := srv.Get("servers/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := resp.VerifyCode(200); err != nil {
+		t.Error(err)
+	}
+	if err := resp.VerifyJSON(200); err == nil {
+		t.Error("VerifyJSON must fail")
+	}
+}
+
+func TestFaultLatency(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	const latency = 50 * time.Millisecond
+	srv.AddFault(Fault{Latency: latency})
+
+	start := time.Now()
+	resp, err := srv.Get("servers/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if d := time.Since(start); d < latency {
+		t.Errorf("response in %v, wants at least %v", d, latency)
+	}
+}
+
+func TestFaultLatencyManualClock(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	srv := New(WithClock(clock))
+	defer srv.Close()
+
+	const latency = time.Hour
+	srv.AddFault(Fault{Latency: latency})
+
+	done := make(chan error, 1)
+	go func() {
+		resp, err := srv.Get("servers/")
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+
+	for clock.Pending() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("response must be delayed until clock advances, error %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clock.Advance(latency)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFaultDrop(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	srv.AddFault(Fault{Path: "servers/", Drop: true})
+
+	resp, err := srv.Get("servers/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if _, err := ioutil.ReadAll(resp.Body); err == nil {
+		t.Error("reading body of dropped connection must fail")
+	}
+}
+
+// wrappedWriter hides connection hijacker of the response writer
+type wrappedWriter struct{ http.ResponseWriter }
+
+func (w wrappedWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func TestFaultDropNoHijack(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "application/json")
+	rec.WriteHeader(200)
+	rec.Write([]byte("[1, 2, 3, 4]"))
+
+	w := httptest.NewRecorder()
+	Fault{Drop: true}.drop(wrappedWriter{w}, rec)
+
+	if w.Code != 200 || w.Body.String() != "[1, 2," {
+		t.Errorf("dropped response %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Length") != "12" || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("dropped response headers %v", w.Header())
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := hijacker(wrappedWriter{w}); !ok {
+			t.Error("wrapped hijacker must be found")
+		}
+	}))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
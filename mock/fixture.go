@@ -0,0 +1,125 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/altoros/gosigma/data"
+)
+
+// A Fixture contains seed objects of the mock server. IP addresses of servers
+// are kept in runtime of their NICs.
+type Fixture struct {
+	Servers   []data.Server `json:"servers,omitempty"`
+	Drives    []data.Drive  `json:"drives,omitempty"`
+	LibDrives []data.Drive  `json:"libdrives,omitempty"`
+	Jobs      []data.Job    `json:"jobs,omitempty"`
+
+	FirewallPolicies []data.FirewallPolicy `json:"fwpolicies,omitempty"`
+	KeyPairs         []data.KeyPair        `json:"keypairs,omitempty"`
+	ACLs             []data.ACL            `json:"acls,omitempty"`
+	Subscriptions    []data.Subscription   `json:"subscriptions,omitempty"`
+	RemoteSnapshots  []data.RemoteSnapshot `json:"remotesnapshots,omitempty"`
+
+	// Balance of the account, nil keeps current balance
+	Balance *data.Balance      `json:"balance,omitempty"`
+	Ledger  []data.LedgerEntry `json:"ledger,omitempty"`
+
+	// IgnoreShutdown lists UUIDs of servers, which guests ignore ACPI shutdown
+	IgnoreShutdown []string `json:"ignore_shutdown,omitempty"`
+}
+
+// ReadFixture reads and unmarshalls fixture from JSON stream
+func ReadFixture(r io.Reader) (*Fixture, error) {
+	var f Fixture
+	if err := data.ReadJSON(r, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// fixtureErrors lists errors of fixture objects failed to load
+type fixtureErrors []error
+
+func (ee fixtureErrors) Error() string {
+	ss := make([]string, 0, len(ee))
+	for _, err := range ee {
+		ss = append(ss, err.Error())
+	}
+	return "fixture: " + strings.Join(ss, "; ")
+}
+
+// Load adds objects of the fixture to the server. Objects failed to add are
+// skipped, the error lists all of them.
+func (srv *Server) Load(f *Fixture) error {
+	var ee fixtureErrors
+	check := func(section string, i int, err error) {
+		if err != nil {
+			ee = append(ee, fmt.Errorf("%s[%d]: %v", section, i, err))
+		}
+	}
+
+	for i, v := range f.Drives {
+		v := v
+		check("drives", i, srv.Drives.Add(&v))
+	}
+	for i, v := range f.LibDrives {
+		v := v
+		check("libdrives", i, srv.LibDrives.Add(&v))
+	}
+	for i, v := range f.Jobs {
+		v := v
+		check("jobs", i, srv.Jobs.Add(&v))
+	}
+	for i, v := range f.FirewallPolicies {
+		v := v
+		check("fwpolicies", i, srv.FirewallPolicies.Add(&v))
+	}
+	for i, v := range f.KeyPairs {
+		v := v
+		check("keypairs", i, srv.KeyPairs.Add(&v))
+	}
+	for i, v := range f.ACLs {
+		v := v
+		check("acls", i, srv.ACLs.Add(&v))
+	}
+	for i, v := range f.Subscriptions {
+		v := v
+		check("subscriptions", i, srv.Subscriptions.Add(&v))
+	}
+	for i, v := range f.RemoteSnapshots {
+		v := v
+		check("remotesnapshots", i, srv.RemoteSnapshots.Add(&v))
+	}
+	if f.Balance != nil {
+		srv.SetBalance(*f.Balance)
+	}
+	srv.AddLedgerEntries(f.Ledger)
+	for i, v := range f.Servers {
+		v := v
+		check("servers", i, srv.AddServer(&v))
+	}
+	for _, uuid := range f.IgnoreShutdown {
+		srv.IgnoreShutdown(uuid, true)
+	}
+	srv.resumeTransitions()
+
+	if len(ee) > 0 {
+		return ee
+	}
+	return nil
+}
+
+// WithFixture returns Option loading objects of the fixture to the server. It
+// panics if the fixture fails to load, use Load to handle the error.
+func WithFixture(f *Fixture) Option {
+	return func(srv *Server) {
+		if err := srv.Load(f); err != nil {
+			panic(err)
+		}
+	}
+}
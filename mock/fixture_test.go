@@ -0,0 +1,74 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"strings"
+	"testing"
+)
+
+const jsonFixture = `{
+	"servers": [{"uuid": "s1", "name": "server 1"}, {"name": "server 2", "status": "running"}],
+	"drives": [{"uuid": "d1", "name": "drive 1"}],
+	"libdrives": [{"uuid": "l1", "name": "libdrive 1"}, {"uuid": "l2"}],
+	"jobs": [{"uuid": "j1", "state": "success"}]
+}`
+
+func TestFixture(t *testing.T) {
+	f, err := ReadFixture(strings.NewReader(jsonFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := newServer(WithFixture(f))
+
+	if len(srv.servers) != 2 {
+		t.Errorf("servers: %d, wants 2", len(srv.servers))
+	}
+	if s := srv.servers["s1"]; s == nil || s.Name != "server 1" || s.Status != "stopped" {
+		t.Errorf("invalid server s1: %#v", s)
+	}
+	if len(srv.Drives.m) != 1 || srv.Drives.m["d1"] == nil {
+		t.Errorf("invalid drives: %v", srv.Drives.m)
+	}
+	if len(srv.LibDrives.m) != 2 {
+		t.Errorf("invalid libdrives: %v", srv.LibDrives.m)
+	}
+	if j := srv.Jobs.m["j1"]; j == nil || j.State != "success" {
+		t.Errorf("invalid job j1: %#v", j)
+	}
+}
+
+func TestFixtureInvalid(t *testing.T) {
+	if _, err := ReadFixture(strings.NewReader(`{"servers": {}}`)); err == nil {
+		t.Error("ReadFixture must fail")
+	}
+}
+
+func TestFixtureLoadErrors(t *testing.T) {
+	f, err := ReadFixture(strings.NewReader(`{
+		"drives": [{"uuid": "d1"}],
+		"subscriptions": [{"id": "1", "period": "1 century"}, {"id": "2"}, {"id": "3", "period": "2 eons"}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := newServer()
+	err = srv.Load(f)
+	ee, ok := err.(fixtureErrors)
+	if !ok || len(ee) != 2 {
+		t.Fatalf("Load must fail for two subscriptions, got %v", err)
+	}
+	if !strings.HasPrefix(ee[0].Error(), "subscriptions[0]: ") || !strings.HasPrefix(ee[1].Error(), "subscriptions[2]: ") {
+		t.Errorf("invalid errors %v", err)
+	}
+
+	if srv.Drives.m["d1"] == nil || len(srv.Subscriptions.m) != 1 {
+		t.Errorf("valid objects must be loaded: %v, %v", srv.Drives.m, srv.Subscriptions.m)
+	}
+	if f.Drives[0].Resource.URI != "" {
+		t.Error("Load must not modify the fixture")
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/altoros/gosigma/data"
+)
+
+// FirewallPolicyLibrary type to store all firewall policies in the mock
+type FirewallPolicyLibrary struct {
+	s   sync.Mutex
+	m   map[string]*data.FirewallPolicy
+	p   string
+	srv *Server
+}
+
+// FirewallPolicies defines library of all firewall policies in the default mock
+var FirewallPolicies = defaultServer.FirewallPolicies
+
+// InitFirewallPolicy initializes the firewall policy
+func InitFirewallPolicy(p *data.FirewallPolicy) (*data.FirewallPolicy, error) {
+	if p.UUID == "" {
+		uuid, err := GenerateUUID()
+		if err != nil {
+			return nil, err
+		}
+		p.UUID = uuid
+	}
+	p.Resource = *data.MakeFirewallPolicyResource(p.UUID)
+	if p.Meta == nil {
+		p.Meta = make(map[string]string)
+	}
+	if p.Rules == nil {
+		p.Rules = []data.FirewallRule{}
+	}
+
+	return p, nil
+}
+
+// Add firewall policy to the library
+func (fw *FirewallPolicyLibrary) Add(p *data.FirewallPolicy) error {
+	p, err := InitFirewallPolicy(p)
+	if err != nil {
+		return err
+	}
+
+	fw.s.Lock()
+	defer fw.s.Unlock()
+
+	fw.m[p.UUID] = p
+
+	return nil
+}
+
+// AddFirewallPolicies adds firewall policy collection to the library
+func (fw *FirewallPolicyLibrary) AddFirewallPolicies(pp []data.FirewallPolicy) []string {
+	fw.s.Lock()
+	defer fw.s.Unlock()
+
+	var result []string
+	for _, p := range pp {
+		p := p
+		pfw, err := InitFirewallPolicy(&p)
+		if err != nil {
+			continue
+		}
+		fw.m[pfw.UUID] = pfw
+		result = append(result, pfw.UUID)
+	}
+	return result
+}
+
+// Remove firewall policy from the library
+func (fw *FirewallPolicyLibrary) Remove(uuid string) bool {
+	fw.s.Lock()
+	defer fw.s.Unlock()
+
+	_, ok := fw.m[uuid]
+	delete(fw.m, uuid)
+
+	return ok
+}
+
+// Reset the library
+func (fw *FirewallPolicyLibrary) Reset() {
+	fw.s.Lock()
+	defer fw.s.Unlock()
+	fw.m = make(map[string]*data.FirewallPolicy)
+}
+
+// has reports whether firewall policy with given uuid exists in the library
+func (fw *FirewallPolicyLibrary) has(uuid string) bool {
+	fw.s.Lock()
+	defer fw.s.Unlock()
+	_, ok := fw.m[uuid]
+	return ok
+}
+
+// validateFirewallPolicies checks firewall policies attached to NICs of the
+// server exist, must be called under syncServers lock
+func (srv *Server) validateFirewallPolicies(s *data.Server) error {
+	for _, n := range s.NICs {
+		if n.FirewallPolicy != nil && !srv.FirewallPolicies.has(n.FirewallPolicy.UUID) {
+			return invalid("firewall_policy", "Firewall policy %s does not exist", n.FirewallPolicy.UUID)
+		}
+	}
+	return nil
+}
+
+func (fw *FirewallPolicyLibrary) snapshot() []data.FirewallPolicy {
+	fw.s.Lock()
+	defer fw.s.Unlock()
+
+	var result []data.FirewallPolicy
+	for _, p := range fw.m {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].UUID < result[j].UUID })
+	return result
+}
+
+// URLs:
+// /api/2.0/fwpolicies/
+// /api/2.0/fwpolicies/detail/
+// /api/2.0/fwpolicies/{uuid}/
+func (fw *FirewallPolicyLibrary) handleRequest(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	path = strings.TrimPrefix(path, fw.p)
+	path = strings.TrimPrefix(path, "/")
+
+	switch {
+	case r.Method == "GET" && path == "":
+		fw.handleList(w, r, 200, false, nil)
+	case r.Method == "GET" && path == "detail":
+		fw.handleList(w, r, 200, true, nil)
+	case r.Method == "GET":
+		fw.handleGet(w, r, path)
+	case r.Method == "POST" && path == "":
+		fw.handleCreate(w, r)
+	case r.Method == "PUT" && path != "":
+		fw.handleUpdate(w, r, path)
+	case r.Method == "DELETE" && path != "":
+		fw.handleDelete(w, r, path)
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+// attachedServers returns servers, which NICs refer firewall policies, by
+// firewall policy uuid
+func (srv *Server) attachedServers() map[string][]data.Resource {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
+
+	result := make(map[string][]data.Resource)
+	for _, s := range srv.servers {
+		seen := make(map[string]bool)
+		for _, n := range s.NICs {
+			if n.FirewallPolicy == nil || seen[n.FirewallPolicy.UUID] {
+				continue
+			}
+			seen[n.FirewallPolicy.UUID] = true
+			result[n.FirewallPolicy.UUID] = append(result[n.FirewallPolicy.UUID], s.Resource)
+		}
+	}
+	for _, rr := range result {
+		sort.Slice(rr, func(i, j int) bool { return rr[i].UUID < rr[j].UUID })
+	}
+	return result
+}
+
+// viewFirewallPolicy returns copy of firewall policy with servers it is attached to
+func viewFirewallPolicy(p *data.FirewallPolicy, servers map[string][]data.Resource) data.FirewallPolicy {
+	v := *p
+	v.Servers = servers[p.UUID]
+	if v.Servers == nil {
+		v.Servers = []data.Resource{}
+	}
+	return v
+}
+
+func (fw *FirewallPolicyLibrary) handleList(w http.ResponseWriter, r *http.Request, okcode int, detail bool, filter []string) {
+	servers := fw.srv.attachedServers()
+
+	fw.s.Lock()
+	defer fw.s.Unlock()
+
+	var pp data.FirewallPolicies
+	if len(filter) == 0 {
+		for _, p := range fw.m {
+			filter = append(filter, p.UUID)
+		}
+		sort.Strings(filter)
+	}
+	pp.Objects = make([]data.FirewallPolicy, 0, len(filter))
+	for _, uuid := range filter {
+		p, ok := fw.m[uuid]
+		if !ok {
+			continue
+		}
+		if detail {
+			pp.Objects = append(pp.Objects, viewFirewallPolicy(p, servers))
+		} else {
+			pp.Objects = append(pp.Objects, data.FirewallPolicy{Resource: p.Resource, Name: p.Name, Rules: p.Rules})
+		}
+	}
+	pp.Meta.TotalCount = len(pp.Objects)
+
+	data, err := json.Marshal(&pp)
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte("500 " + err.Error()))
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(okcode)
+	w.Write(data)
+}
+
+func (fw *FirewallPolicyLibrary) handleGet(w http.ResponseWriter, r *http.Request, uuid string) {
+	servers := fw.srv.attachedServers()
+
+	fw.s.Lock()
+	defer fw.s.Unlock()
+
+	p, ok := fw.m[uuid]
+	if !ok {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	v := viewFirewallPolicy(p, servers)
+	writeJSON(w, 200, &v)
+}
+
+func (fw *FirewallPolicyLibrary) handleCreate(w http.ResponseWriter, r *http.Request) {
+	bb, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(400)
+		return
+	}
+
+	pp, err := data.ReadFirewallPolicies(bytes.NewReader(bb))
+	if err != nil || len(pp) == 0 {
+		p, err := data.ReadFirewallPolicy(bytes.NewReader(bb))
+		if err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		pp = []data.FirewallPolicy{*p}
+	}
+
+	for i := range pp {
+		if err := validateFirewallPolicy(&pp[i]); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+		pp[i].UUID = ""
+		pp[i].Servers = nil
+	}
+
+	uuids := fw.AddFirewallPolicies(pp)
+	fw.handleList(w, r, 201, true, uuids)
+}
+
+func (fw *FirewallPolicyLibrary) handleUpdate(w http.ResponseWriter, r *http.Request, uuid string) {
+	p, err := data.ReadFirewallPolicy(r.Body)
+	if err != nil {
+		w.WriteHeader(400)
+		return
+	}
+
+	if err := validateFirewallPolicy(p); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	fw.s.Lock()
+	current, ok := fw.m[uuid]
+	if ok {
+		current.Name = p.Name
+		current.Rules = p.Rules
+		if current.Rules == nil {
+			current.Rules = []data.FirewallRule{}
+		}
+		if p.Meta != nil {
+			current.Meta = p.Meta
+		}
+	}
+	fw.s.Unlock()
+
+	if !ok {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	fw.handleGet(w, r, uuid)
+}
+
+func (fw *FirewallPolicyLibrary) handleDelete(w http.ResponseWriter, r *http.Request, uuid string) {
+	if !fw.Remove(uuid) {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func writeJSON(w http.ResponseWriter, okcode int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte("500 " + err.Error()))
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(okcode)
+	w.Write(data)
+}
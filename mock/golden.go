@@ -0,0 +1,90 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// EnvUpdateGolden names environment variable, which makes CheckGolden to
+// rewrite golden files instead of comparing them
+const EnvUpdateGolden = "GOSIGMA_UPDATE_GOLDEN"
+
+var (
+	reUUID      = regexp.MustCompile(`\b[0-9a-fA-F]{1,8}-[0-9a-fA-F]{1,4}-[0-9a-fA-F]{1,4}-[0-9a-fA-F]{1,4}-[0-9a-fA-F]{1,12}\b`)
+	reTimestamp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+)
+
+// normaliser replaces UUIDs with numbered placeholders in order of their
+// appearance, so relations between requests are kept, and timestamps with
+// constant placeholder
+type normaliser struct {
+	uuids map[string]string
+}
+
+func (n *normaliser) normalise(s string) string {
+	s = reTimestamp.ReplaceAllString(s, "{timestamp}")
+	return reUUID.ReplaceAllStringFunc(s, func(uuid string) string {
+		uuid = strings.ToLower(uuid)
+		v, ok := n.uuids[uuid]
+		if !ok {
+			v = fmt.Sprintf("{uuid-%d}", len(n.uuids)+1)
+			n.uuids[uuid] = v
+		}
+		return v
+	})
+}
+
+// FormatRequests formats request sequence of journal entries as text, with
+// UUIDs and timestamps normalised. Every request is written as request line,
+// compacted JSON body if any, and status code of response.
+func FormatRequests(jj []JournalEntry) string {
+	n := normaliser{uuids: make(map[string]string)}
+
+	var buf bytes.Buffer
+	for _, j := range jj {
+		fmt.Fprintf(&buf, "%s %s\n", j.Request.Method, n.normalise(j.Request.URL.RequestURI()))
+		if len(j.RequestBody) > 0 {
+			body := j.RequestBody
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, body); err == nil {
+				body = compact.Bytes()
+			}
+			fmt.Fprintf(&buf, "%s\n", n.normalise(string(body)))
+		}
+		fmt.Fprintf(&buf, "-> %d\n\n", j.Response.Code)
+	}
+	return buf.String()
+}
+
+// CheckGolden compares request sequence of journal entries, formatted with
+// FormatRequests, to the content of golden file. If environment variable
+// GOSIGMA_UPDATE_GOLDEN is not empty, golden file is rewritten instead.
+func CheckGolden(t *testing.T, path string, jj []JournalEntry) {
+	actual := FormatRequests(jj)
+
+	if os.Getenv(EnvUpdateGolden) != "" {
+		if err := ioutil.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Error(err)
+		}
+		return
+	}
+
+	bb, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Errorf("%s, set %s=1 to create golden file", err, EnvUpdateGolden)
+		return
+	}
+
+	if expected := string(bb); actual != expected {
+		t.Errorf("request sequence differs from golden file %s\ngot:\n%s\nwants:\n%s", path, actual, expected)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/https"
+)
+
+func TestFormatRequests(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	s := &data.Server{Name: "test", Status: "stopped"}
+	srv.AddServer(s)
+
+	client := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+
+	body := `{"name": "test", "cpu": 2000, "mem": 536870912, "vnc_password": "test", "meta": {"created": "2014-05-06T07:08:09.123Z", "other": "` + s.UUID + `"}}`
+	resp, err := client.Post(srv.Endpoint("servers/"), nil, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	resp, err = client.Post(srv.Endpoint("servers/"+s.UUID+"/action/?do=start"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	resp, err = client.Get(srv.Endpoint("servers/missing/"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	CheckGolden(t, "testdata/requests.golden", srv.GetSession())
+}
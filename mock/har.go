@@ -0,0 +1,204 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// HAR 1.2 format, see http://www.softwareishard.com/blog/har-12-spec/
+
+type harLog struct {
+	Log harLogBody `json:"log"`
+}
+
+type harLogBody struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harScrubHeaders lists headers left out of HAR entries, as they carry
+// credentials, see cassette.ScrubHeaders
+var harScrubHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+func harHeaders(h http.Header) []harNameValue {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		if !isScrubbedHeader(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	result := make([]harNameValue, 0, len(h))
+	for _, k := range keys {
+		for _, v := range h[k] {
+			result = append(result, harNameValue{k, v})
+		}
+	}
+	return result
+}
+
+func isScrubbedHeader(k string) bool {
+	for _, v := range harScrubHeaders {
+		if http.CanonicalHeaderKey(k) == v {
+			return true
+		}
+	}
+	return false
+}
+
+func harMilliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func makeHAREntry(j JournalEntry) harEntry {
+	r := j.Request
+
+	u := *r.URL
+	u.Scheme = "https"
+	u.Host = r.Host
+
+	req := harRequest{
+		Method:      r.Method,
+		URL:         u.String(),
+		HTTPVersion: r.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(r.Header),
+		QueryString: harHeaders(http.Header(r.URL.Query())),
+		HeadersSize: -1,
+		BodySize:    len(j.RequestBody),
+	}
+	if len(j.RequestBody) > 0 {
+		req.PostData = &harPostData{
+			MimeType: r.Header.Get("Content-Type"),
+			Text:     string(j.RequestBody),
+		}
+	}
+
+	rr := j.Response
+	body := rr.Body.String()
+	resp := harResponse{
+		Status:      rr.Code,
+		StatusText:  http.StatusText(rr.Code),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(rr.HeaderMap),
+		Content: harContent{
+			Size:     len(body),
+			MimeType: rr.HeaderMap.Get("Content-Type"),
+			Text:     body,
+		},
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+
+	return harEntry{
+		StartedDateTime: j.Started.Format(time.RFC3339Nano),
+		Time:            harMilliseconds(j.Duration),
+		Request:         req,
+		Response:        resp,
+		Timings:         harTimings{Wait: harMilliseconds(j.Duration)},
+		Comment:         j.Name,
+	}
+}
+
+// WriteHAR writes journal entries to the stream in HAR 1.2 JSON format
+func WriteHAR(w io.Writer, jj []JournalEntry) error {
+	var har harLog
+	har.Log.Version = "1.2"
+	har.Log.Creator = harCreator{Name: "gosigma mock", Version: "1.0"}
+	har.Log.Entries = make([]harEntry, 0, len(jj))
+	for _, j := range jj {
+		har.Log.Entries = append(har.Log.Entries, makeHAREntry(j))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&har)
+}
+
+// WriteHAR writes journal records of the server with given ids to the stream
+// in HAR 1.2 JSON format. If no ids are specified, whole session is written.
+func (srv *Server) WriteHAR(w io.Writer, ids ...int) error {
+	if len(ids) == 0 {
+		return WriteHAR(w, srv.GetSession())
+	}
+	var jj []JournalEntry
+	for _, id := range ids {
+		jj = append(jj, srv.GetJournal(id)...)
+	}
+	return WriteHAR(w, jj)
+}
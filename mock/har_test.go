@@ -0,0 +1,83 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/altoros/gosigma/https"
+)
+
+func TestWriteHAR(t *testing.T) {
+	clock := NewManualClock(time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC))
+	srv := New(WithClock(clock))
+	defer srv.Close()
+
+	resp, err := srv.Get("servers/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	id := GetIDFromResponse(resp)
+
+	client := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+	resp, err = client.Post(srv.Endpoint("servers/"), nil, strings.NewReader(`{"name": "test", "cpu": 2000, "mem": 536870912, "vnc_password": "test"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	check := func(wants int, ids ...int) map[string]interface{} {
+		var buf bytes.Buffer
+		if err := srv.WriteHAR(&buf, ids...); err != nil {
+			t.Fatal(err)
+		}
+
+		var har struct {
+			Log struct {
+				Version string
+				Entries []map[string]interface{}
+			}
+		}
+		if err := json.Unmarshal(buf.Bytes(), &har); err != nil {
+			t.Fatal(err)
+		}
+		if har.Log.Version != "1.2" {
+			t.Errorf("HAR version %q", har.Log.Version)
+		}
+		if len(har.Log.Entries) != wants {
+			t.Fatalf("HAR entries %d, wants %d", len(har.Log.Entries), wants)
+		}
+		return har.Log.Entries[len(har.Log.Entries)-1]
+	}
+
+	e := check(1, id)
+	if v := e["startedDateTime"]; v != "2014-01-01T00:00:00Z" {
+		t.Errorf("startedDateTime %v", v)
+	}
+	if v := e["request"].(map[string]interface{})["method"]; v != "GET" {
+		t.Errorf("request method %v", v)
+	}
+
+	e = check(2)
+	req := e["request"].(map[string]interface{})
+	if v := req["postData"].(map[string]interface{})["text"]; v != `{"name": "test", "cpu": 2000, "mem": 536870912, "vnc_password": "test"}` {
+		t.Errorf("request postData %v", v)
+	}
+	if v := e["response"].(map[string]interface{})["status"]; v != 201.0 {
+		t.Errorf("response status %v", v)
+	}
+	for _, h := range req["headers"].([]interface{}) {
+		if name := h.(map[string]interface{})["name"]; name == "Authorization" {
+			t.Errorf("credentials must be scrubbed: %v", h)
+		}
+	}
+	if len(req["headers"].([]interface{})) == 0 {
+		t.Error("request headers must be kept")
+	}
+}
@@ -6,8 +6,9 @@ package mock
 import "testing"
 
 func TestMockGenerateID(t *testing.T) {
-	for i := 0; i < 10; i++ {
-		if v := genID(); v != i {
+	first := genID()
+	for i := 1; i < 10; i++ {
+		if v := genID(); v != first+i {
 			t.Errorf("ID at %d should be equal to %d", i, v)
 		}
 	}
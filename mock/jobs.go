@@ -8,22 +8,21 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/altoros/gosigma/data"
 )
 
 // JobLibrary type to store all jobs in the mock
 type JobLibrary struct {
-	s sync.Mutex
-	m map[string]*data.Job
-	p string
+	s   sync.Mutex
+	m   map[string]*data.Job
+	p   string
+	srv *Server
 }
 
-// Jobs defines library of all jobs in the mock
-var Jobs = &JobLibrary{
-	m: make(map[string]*data.Job),
-	p: "/api/2.0/jobs",
-}
+// Jobs defines library of all jobs in the default mock
+var Jobs = defaultServer.Jobs
 
 // InitJob initializes the job
 func InitJob(j *data.Job) (*data.Job, error) {
@@ -48,6 +47,11 @@ func (j *JobLibrary) Add(job *data.Job) error {
 		return err
 	}
 
+	if job.Created.IsZero() {
+		job.Created = j.srv.clock.Now()
+		job.LastModified = job.Created
+	}
+
 	j.s.Lock()
 	defer j.s.Unlock()
 
@@ -56,18 +60,20 @@ func (j *JobLibrary) Add(job *data.Job) error {
 	return nil
 }
 
-// AddJobs adds job collection to the libraryh
+// AddJobs adds job collection to the library
 func (j *JobLibrary) AddJobs(jj []data.Job) []string {
 	j.s.Lock()
 	defer j.s.Unlock()
 
 	var result []string
 	for _, job := range jj {
-		job, err := InitJob(&job)
+		job := job
+		pj, err := InitJob(&job)
 		if err != nil {
-			j.m[job.UUID] = job
-			result = append(result, job.UUID)
+			continue
 		}
+		j.m[pj.UUID] = pj
+		result = append(result, pj.UUID)
 	}
 	return result
 }
@@ -98,6 +104,7 @@ func (j *JobLibrary) SetState(uuid, state string) {
 	job, ok := j.m[uuid]
 	if ok {
 		job.State = state
+		job.LastModified = j.srv.clock.Now()
 	}
 }
 
@@ -109,9 +116,29 @@ func (j *JobLibrary) SetProgress(uuid string, progress int) {
 	job, ok := j.m[uuid]
 	if ok {
 		job.Data.Progress = progress
+		job.LastModified = j.srv.clock.Now()
 	}
 }
 
+// run advances progress of the job to completion in given duration, progress
+// is 50 at the half of duration. Function done is called when the job succeeds.
+func (j *JobLibrary) run(uuid string, duration time.Duration, done func()) {
+	// second half is scheduled from the first one, so steps of real clock
+	// cannot run out of order
+	clock := j.srv.clock
+	clock.AfterFunc(duration/2, func() {
+		j.SetProgress(uuid, 50)
+		clock.AfterFunc(duration-duration/2, func() {
+			// results of the job are visible once it succeeds
+			if done != nil {
+				done()
+			}
+			j.SetProgress(uuid, 100)
+			j.SetState(uuid, "success")
+		})
+	})
+}
+
 func (j *JobLibrary) handleRequest(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimSuffix(r.URL.Path, "/")
 	path = strings.TrimPrefix(path, j.p)
@@ -6,6 +6,9 @@ package mock
 import (
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"sync"
+	"time"
 )
 
 // JournalEntry contains single journal record
@@ -13,49 +16,105 @@ type JournalEntry struct {
 	Name     string
 	Request  *http.Request
 	Response *httptest.ResponseRecorder
+
+	// RequestBody contains body of the request as received by the server
+	RequestBody []byte
+	// Started is the time the server received the request
+	Started time.Time
+	// Duration of the request processing by the server
+	Duration time.Duration
+
+	seq int
+}
+
+type journal struct {
+	s   sync.Mutex
+	m   map[int][]JournalEntry
+	seq int
+}
+
+func (j *journal) put(id int, entry JournalEntry) {
+	j.s.Lock()
+	defer j.s.Unlock()
+	j.seq++
+	entry.seq = j.seq
+	j.m[id] = append(j.m[id], entry)
+}
+
+func (j *journal) get(id int) []JournalEntry {
+	j.s.Lock()
+	defer j.s.Unlock()
+	return j.m[id]
+}
+
+func (j *journal) all() map[int][]JournalEntry {
+	j.s.Lock()
+	defer j.s.Unlock()
+	m := make(map[int][]JournalEntry, len(j.m))
+	for id, entries := range j.m {
+		m[id] = entries
+	}
+	return m
 }
 
-var journal = make(map[int][]JournalEntry)
+// session returns all entries in order of recording
+func (j *journal) session() []JournalEntry {
+	j.s.Lock()
+	defer j.s.Unlock()
+	var result []JournalEntry
+	for _, entries := range j.m {
+		result = append(result, entries...)
+	}
+	sort.Slice(result, func(a, b int) bool { return result[a].seq < result[b].seq })
+	return result
+}
+
+func (j *journal) reset() {
+	j.s.Lock()
+	defer j.s.Unlock()
+	j.m = make(map[int][]JournalEntry)
+}
+
+func (srv *Server) recordJournal(name string, r *http.Request, rr *httptest.ResponseRecorder) {
+	srv.recordJournalEntry(JournalEntry{Name: name, Request: r, Response: rr})
+}
 
-type journalRequest struct {
-	id    int
-	entry JournalEntry
-	reply chan []JournalEntry
+func (srv *Server) recordJournalEntry(entry JournalEntry) {
+	id := GetIDFromRequest(entry.Request)
+	SetID(entry.Response.HeaderMap, id)
+	srv.journal.put(id, entry)
 }
 
-var chPut = make(chan journalRequest)
-var chGet = make(chan journalRequest)
+// PutJournal adds record to specified journal of the server
+func (srv *Server) PutJournal(id int, name string, r *http.Request, rr *httptest.ResponseRecorder) {
+	srv.journal.put(id, JournalEntry{Name: name, Request: r, Response: rr})
+}
+
+// GetJournal retrivies record from specified journal of the server
+func (srv *Server) GetJournal(id int) []JournalEntry {
+	return srv.journal.get(id)
+}
 
-func init() {
-	go func() {
-		for {
-			select {
-			case req := <-chPut:
-				journal[req.id] = append(journal[req.id], req.entry)
-			case req := <-chGet:
-				req.reply <- journal[req.id]
-			}
-		}
-	}()
+// GetSession retrivies all journal records of the server in order of recording
+func (srv *Server) GetSession() []JournalEntry {
+	return srv.journal.session()
 }
 
 func recordJournal(name string, r *http.Request, rr *httptest.ResponseRecorder) {
-	id := GetIDFromRequest(r)
-	SetID(rr.HeaderMap, id)
-	PutJournal(id, name, r, rr)
+	defaultServer.recordJournal(name, r, rr)
 }
 
 // PutJournal adds record to specified journal
 func PutJournal(id int, name string, r *http.Request, rr *httptest.ResponseRecorder) {
-	entry := JournalEntry{name, r, rr}
-	jr := journalRequest{id, entry, nil}
-	chPut <- jr
+	defaultServer.PutJournal(id, name, r, rr)
 }
 
 // GetJournal retrivies record from specified journal
 func GetJournal(id int) []JournalEntry {
-	ch := make(chan []JournalEntry)
-	jr := journalRequest{id, JournalEntry{}, ch}
-	chGet <- jr
-	return <-ch
+	return defaultServer.GetJournal(id)
+}
+
+// GetSession retrivies all journal records in order of recording
+func GetSession() []JournalEntry {
+	return defaultServer.GetSession()
 }
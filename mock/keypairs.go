@@ -0,0 +1,250 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/sshkey"
+)
+
+// KeyPairLibrary type to store all SSH key pairs in the mock
+type KeyPairLibrary struct {
+	s   sync.Mutex
+	m   map[string]*data.KeyPair
+	p   string
+	srv *Server
+}
+
+// KeyPairs defines library of all SSH key pairs in the default mock
+var KeyPairs = defaultServer.KeyPairs
+
+// InitKeyPair initializes the SSH key pair. If public key is not set, new key
+// pair is generated and its private key is left in the object.
+func InitKeyPair(k *data.KeyPair) (*data.KeyPair, error) {
+	if k.UUID == "" {
+		uuid, err := GenerateUUID()
+		if err != nil {
+			return nil, err
+		}
+		k.UUID = uuid
+	}
+	k.Resource = *data.MakeKeyPairResource(k.UUID)
+	if k.Meta == nil {
+		k.Meta = make(map[string]string)
+	}
+	if k.PublicKey == "" {
+		key, err := sshkey.Generate(0, k.Name)
+		if err != nil {
+			return nil, err
+		}
+		k.PublicKey = key.PublicKey
+		k.PrivateKey = string(key.PrivateKey)
+	}
+	if fp, err := sshkey.Fingerprint(k.PublicKey); err == nil {
+		k.Fingerprint = fp
+	}
+
+	return k, nil
+}
+
+// Add SSH key pair to the library. Private key of generated key pair is left in
+// the object, but it is not stored in the library.
+func (kl *KeyPairLibrary) Add(k *data.KeyPair) error {
+	k, err := InitKeyPair(k)
+	if err != nil {
+		return err
+	}
+
+	kl.s.Lock()
+	defer kl.s.Unlock()
+
+	stored := *k
+	stored.PrivateKey = ""
+	kl.m[k.UUID] = &stored
+
+	return nil
+}
+
+// AddKeyPairs adds SSH key pair collection to the library
+func (kl *KeyPairLibrary) AddKeyPairs(kk []data.KeyPair) []string {
+	var result []string
+	for _, k := range kk {
+		k := k
+		if err := kl.Add(&k); err != nil {
+			continue
+		}
+		result = append(result, k.UUID)
+	}
+	return result
+}
+
+// Remove SSH key pair from the library
+func (kl *KeyPairLibrary) Remove(uuid string) bool {
+	kl.s.Lock()
+	defer kl.s.Unlock()
+
+	_, ok := kl.m[uuid]
+	delete(kl.m, uuid)
+
+	return ok
+}
+
+// Reset the library
+func (kl *KeyPairLibrary) Reset() {
+	kl.s.Lock()
+	defer kl.s.Unlock()
+	kl.m = make(map[string]*data.KeyPair)
+}
+
+// has reports whether SSH key pair with given uuid exists in the library
+func (kl *KeyPairLibrary) has(uuid string) bool {
+	kl.s.Lock()
+	defer kl.s.Unlock()
+	_, ok := kl.m[uuid]
+	return ok
+}
+
+// validateKeyPairs checks SSH key pairs attached to the server exist, must be
+// called under syncServers lock
+func (srv *Server) validateKeyPairs(s *data.Server) error {
+	for _, r := range s.PubKeys {
+		if !srv.KeyPairs.has(r.UUID) {
+			return invalid("pubkeys", "Key pair %s does not exist", r.UUID)
+		}
+	}
+	return nil
+}
+
+func (kl *KeyPairLibrary) snapshot() []data.KeyPair {
+	kl.s.Lock()
+	defer kl.s.Unlock()
+
+	var result []data.KeyPair
+	for _, k := range kl.m {
+		result = append(result, *k)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].UUID < result[j].UUID })
+	return result
+}
+
+// URLs:
+// /api/2.0/keypairs/
+// /api/2.0/keypairs/detail/
+// /api/2.0/keypairs/{uuid}/
+func (kl *KeyPairLibrary) handleRequest(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	path = strings.TrimPrefix(path, kl.p)
+	path = strings.TrimPrefix(path, "/")
+
+	switch {
+	case r.Method == "GET" && (path == "" || path == "detail"):
+		kl.handleList(w, r)
+	case r.Method == "GET":
+		kl.handleGet(w, r, path)
+	case r.Method == "POST" && path == "":
+		kl.handleCreate(w, r)
+	case r.Method == "DELETE" && path != "":
+		kl.handleDelete(w, r, path)
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+func (kl *KeyPairLibrary) handleList(w http.ResponseWriter, r *http.Request) {
+	var kk data.KeyPairs
+	kk.Objects = kl.snapshot()
+	if kk.Objects == nil {
+		kk.Objects = []data.KeyPair{}
+	}
+	kk.Meta.TotalCount = len(kk.Objects)
+
+	writeJSON(w, 200, &kk)
+}
+
+func (kl *KeyPairLibrary) handleGet(w http.ResponseWriter, r *http.Request, uuid string) {
+	kl.s.Lock()
+	defer kl.s.Unlock()
+
+	k, ok := kl.m[uuid]
+	if !ok {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	writeJSON(w, 200, k)
+}
+
+func (kl *KeyPairLibrary) handleCreate(w http.ResponseWriter, r *http.Request) {
+	bb, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(400)
+		return
+	}
+
+	kk, err := data.ReadKeyPairs(bytes.NewReader(bb))
+	if err != nil || len(kk) == 0 {
+		k, err := data.ReadKeyPair(bytes.NewReader(bb))
+		if err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		kk = []data.KeyPair{*k}
+	}
+
+	for i := range kk {
+		if err := validateKeyPair(&kk[i]); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+		kk[i].UUID = ""
+		kk[i].PrivateKey = ""
+	}
+
+	// private key of generated pair is kept in the request objects only, and
+	// returned in reply to the request
+	for i := range kk {
+		if err := kl.Add(&kk[i]); err != nil {
+			w.WriteHeader(500)
+			w.Write([]byte("500 " + err.Error()))
+			return
+		}
+	}
+
+	result := data.KeyPairs{Objects: kk}
+	result.Meta.TotalCount = len(kk)
+
+	bb, err = json.Marshal(&result)
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte("500 " + err.Error()))
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(201)
+	w.Write(bb)
+}
+
+func (kl *KeyPairLibrary) handleDelete(w http.ResponseWriter, r *http.Request, uuid string) {
+	if !kl.Remove(uuid) {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+	w.WriteHeader(204)
+}
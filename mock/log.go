@@ -43,8 +43,13 @@ func log() severity {
 
 // LogResponse log journal entries associated with response to testing log
 func LogResponse(t *testing.T, r *https.Response) {
+	defaultServer.LogResponse(t, r)
+}
+
+// LogResponse log journal entries of the server associated with response to testing log
+func (srv *Server) LogResponse(t *testing.T, r *https.Response) {
 	id := GetIDFromResponse(r)
-	jj := GetJournal(id)
+	jj := srv.GetJournal(id)
 	Log(t, jj)
 }
 
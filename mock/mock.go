@@ -4,11 +4,17 @@
 package mock
 
 import (
+	"bytes"
+	"crypto/tls"
 	"encoding/base64"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 
+	"github.com/altoros/gosigma/data"
 	"github.com/altoros/gosigma/https"
 )
 
@@ -28,44 +34,283 @@ const (
 	TestPassword = "test"
 )
 
-var pServer *httptest.Server
+// A Server implements CloudSigma endpoint stand-in with its own state, URL,
+// credentials and journal. Every test can run isolated instance of Server.
+type Server struct {
+	// Drives defines user account drives
+	Drives *DriveLibrary
+	// LibDrives defines public drives
+	LibDrives *DriveLibrary
+	// Jobs defines library of all jobs
+	Jobs *JobLibrary
+	// FirewallPolicies defines library of all firewall policies
+	FirewallPolicies *FirewallPolicyLibrary
+	// KeyPairs defines library of all SSH key pairs
+	KeyPairs *KeyPairLibrary
+	// ACLs defines library of all access control lists
+	ACLs *ACLLibrary
+	// Subscriptions defines library of all subscriptions
+	Subscriptions *SubscriptionLibrary
+	// RemoteSnapshots defines library of all remote snapshots
+	RemoteSnapshots *RemoteSnapshotLibrary
 
-// Start mock server for testing CloudSigma endpoint communication.
-// If server is already started, this function does nothing.
-func Start() {
-	if IsStarted() {
-		return
+	username string
+	password string
+
+	syncServers    sync.Mutex
+	servers        map[string]*data.Server
+	ignoreShutdown map[string]bool
+	hosts          map[string]int
+
+	journal     journal
+	faults      faults
+	checkpoints checkpoints
+	limits      limits
+	billing     billing
+	pricing     pricing
+	uploads     uploads
+
+	clock       Clock
+	transitions Transitions
+
+	listener    net.Listener
+	certificate *tls.Certificate
+
+	pServer *httptest.Server
+}
+
+// An Option configures Server at construction time
+type Option func(*Server)
+
+// WithCredentials returns Option setting account name and password for log into Server
+func WithCredentials(username, password string) Option {
+	return func(s *Server) {
+		s.username = username
+		s.password = password
 	}
+}
+
+// WithListener returns Option setting listener of the server, instead of one on
+// random port of the loopback interface
+func WithListener(l net.Listener) Option {
+	return func(s *Server) { s.listener = l }
+}
+
+// WithCertificate returns Option setting TLS certificate of the server, instead
+// of built-in test certificate
+func WithCertificate(cert tls.Certificate) Option {
+	return func(s *Server) { s.certificate = &cert }
+}
+
+// New creates and starts new mock server instance. Server must be closed with Close.
+func New(opts ...Option) *Server {
+	s := newServer(opts...)
+	s.start()
+	return s
+}
 
+func newServer(opts ...Option) *Server {
+	s := &Server{
+		username:       TestUser,
+		password:       TestPassword,
+		servers:        make(map[string]*data.Server),
+		ignoreShutdown: make(map[string]bool),
+		hosts:          make(map[string]int),
+		journal:        journal{m: make(map[int][]JournalEntry)},
+		clock:          RealClock{},
+		transitions:    DefaultTransitions,
+	}
+
+	s.Drives = &DriveLibrary{
+		m:   make(map[string]*data.Drive),
+		p:   "/api/2.0/drives",
+		srv: s,
+	}
+	s.LibDrives = &DriveLibrary{
+		m:   make(map[string]*data.Drive),
+		p:   "/api/2.0/libdrives",
+		srv: s,
+	}
+	s.Jobs = &JobLibrary{
+		m:   make(map[string]*data.Job),
+		p:   "/api/2.0/jobs",
+		srv: s,
+	}
+	s.FirewallPolicies = &FirewallPolicyLibrary{
+		m:   make(map[string]*data.FirewallPolicy),
+		p:   "/api/2.0/fwpolicies",
+		srv: s,
+	}
+	s.KeyPairs = &KeyPairLibrary{
+		m:   make(map[string]*data.KeyPair),
+		p:   "/api/2.0/keypairs",
+		srv: s,
+	}
+	s.ACLs = &ACLLibrary{
+		m:   make(map[string]*data.ACL),
+		p:   "/api/2.0/acls",
+		srv: s,
+	}
+	s.Subscriptions = &SubscriptionLibrary{
+		m:   make(map[string]*data.Subscription),
+		p:   "/api/2.0/subscriptions",
+		srv: s,
+	}
+	s.RemoteSnapshots = &RemoteSnapshotLibrary{
+		m:   make(map[string]*data.RemoteSnapshot),
+		p:   "/api/2.0/remotesnapshots",
+		srv: s,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (srv *Server) start() {
 	mux := http.NewServeMux()
 
-	mux.HandleFunc(makeHandler("capabilities", capsHandler))
-	mux.HandleFunc(makeHandler("drives", Drives.handleRequest))
-	mux.HandleFunc(makeHandler("libdrives", LibDrives.handleRequest))
-	mux.HandleFunc(makeHandler("servers", serversHandler))
-	mux.HandleFunc(makeHandler("jobs", Jobs.handleRequest))
+	mux.HandleFunc(srv.makeHandler("capabilities", capsHandler))
+	mux.HandleFunc(srv.makeHandler("drives", srv.Drives.handleRequest))
+	mux.HandleFunc(srv.makeHandler("drives/upload", srv.uploadHandler))
+	mux.HandleFunc(srv.makeHandler("drives/availability_groups", srv.Drives.handleAvailabilityGroups))
+	mux.HandleFunc(srv.makeHandler("libdrives", srv.LibDrives.handleRequest))
+	mux.HandleFunc(srv.makeHandler("servers", srv.serversHandler))
+	mux.HandleFunc(srv.makeHandler("servers/availability_groups", srv.serverGroupsHandler))
+	mux.HandleFunc(srv.makeHandler("jobs", srv.Jobs.handleRequest))
+	mux.HandleFunc(srv.makeHandler("fwpolicies", srv.FirewallPolicies.handleRequest))
+	mux.HandleFunc(srv.makeHandler("keypairs", srv.KeyPairs.handleRequest))
+	mux.HandleFunc(srv.makeHandler("acls", srv.ACLs.handleRequest))
+	mux.HandleFunc(srv.makeHandler("balance", srv.balanceHandler))
+	mux.HandleFunc(srv.makeHandler("currentusage", srv.currentUsageHandler))
+	mux.HandleFunc(srv.makeHandler("ledger", srv.ledgerHandler))
+	mux.HandleFunc(srv.makeHandler("pricing", srv.pricingHandler))
+	mux.HandleFunc(srv.makeHandler("subscriptions", srv.Subscriptions.handleRequest))
+	mux.HandleFunc(srv.makeHandler("remotesnapshots", srv.RemoteSnapshots.handleRequest))
+	mux.HandleFunc(adminBase, srv.adminHandler)
+
+	srv.pServer = httptest.NewUnstartedServer(mux)
+	if srv.listener != nil {
+		srv.pServer.Listener.Close()
+		srv.pServer.Listener = srv.listener
+	}
+	if srv.certificate != nil {
+		srv.pServer.TLS = &tls.Config{Certificates: []tls.Certificate{*srv.certificate}}
+	}
+	srv.pServer.StartTLS()
+}
+
+// Close shuts down the server
+func (srv *Server) Close() {
+	if srv.pServer == nil {
+		return
+	}
+	srv.pServer.CloseClientConnections()
+	srv.pServer.Close()
+	srv.pServer = nil
+}
+
+// Reset removes all servers, drives, drive uploads, jobs, firewall policies, key
+// pairs, access control lists, subscriptions, remote snapshots, ledger records
+// and fault injection rules from the server
+func (srv *Server) Reset() {
+	srv.Jobs.Reset()
+	srv.Drives.Reset()
+	srv.LibDrives.Reset()
+	srv.uploads.reset()
+	srv.FirewallPolicies.Reset()
+	srv.KeyPairs.Reset()
+	srv.ACLs.Reset()
+	srv.Subscriptions.Reset()
+	srv.RemoteSnapshots.Reset()
+	srv.ResetLedger()
+	srv.ResetServers()
+	srv.ResetFaults()
+}
+
+// URL of the server, represented as string in form 'https://host:port'
+func (srv *Server) URL() string {
+	return srv.pServer.URL
+}
+
+// Endpoint of the server, represented as string in form
+// 'https://host:port/api/{version}/{section}'
+func (srv *Server) Endpoint(section string) string {
+	return srv.pServer.URL + serverBase + section
+}
+
+// Username returns account name for log into the server
+func (srv *Server) Username() string { return srv.username }
+
+// Password returns password for log into the server
+func (srv *Server) Password() string { return srv.password }
+
+// GetAuth performs Get request to the given section of the server with authentication
+func (srv *Server) GetAuth(section, username, password string) (*https.Response, error) {
+	client := https.NewAuthClient(username, password, nil)
+	url := srv.Endpoint(section)
+	return client.Get(url, nil)
+}
 
-	pServer = httptest.NewUnstartedServer(mux)
-	pServer.StartTLS()
+// Get performs Get request to the given section of the server with its credentials
+func (srv *Server) Get(section string) (*https.Response, error) {
+	return srv.GetAuth(section, srv.username, srv.password)
 }
 
 type handlerType func(http.ResponseWriter, *http.Request)
 
-func makeHandler(name string, f handlerType) (string, handlerType) {
+func (srv *Server) makeHandler(name string, f handlerType) (string, handlerType) {
 	url := serverBase + name + "/"
 	handler := func(w http.ResponseWriter, r *http.Request) {
 		defer r.Body.Close()
 
+		started := srv.clock.Now()
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
 		rec := httptest.NewRecorder()
 
-		if isValidAuth(r) {
-			f(rec, r)
+		var fault *Fault
+		if srv.isValidAuth(r) {
+			fault = srv.faults.match(r)
+			if fault != nil && fault.Code != 0 {
+				fault.writeResponse(rec)
+			} else {
+				f(rec, r)
+			}
 		} else {
 			rec.WriteHeader(401)
 			rec.Write([]byte("401 Unauthorized\n"))
 		}
 
-		recordJournal(name, r, rec)
+		if fault != nil && fault.ContentType != "" {
+			rec.HeaderMap.Set("Content-Type", fault.ContentType)
+		}
+
+		srv.recordJournalEntry(JournalEntry{
+			Name:        name,
+			Request:     r,
+			Response:    rec,
+			RequestBody: body,
+			Started:     started,
+			Duration:    srv.clock.Now().Sub(started),
+		})
+
+		if fault != nil && fault.Latency > 0 {
+			srv.delay(r, fault.Latency)
+		}
+
+		if fault != nil && fault.Drop {
+			fault.drop(w, rec)
+			return
+		}
 
 		hdr := w.Header()
 		for k, v := range rec.HeaderMap {
@@ -78,22 +323,22 @@ func makeHandler(name string, f handlerType) (string, handlerType) {
 	return url, handler
 }
 
-func isValidAuth(r *http.Request) bool {
-	s := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
-	if len(s) < 2 {
+func (srv *Server) isValidAuth(r *http.Request) bool {
+	a := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(a) < 2 {
 		return false
 	}
-	switch s[0] {
+	switch a[0] {
 	case "Basic":
-		return isValidBasicAuth(s[1])
+		return srv.isValidBasicAuth(a[1])
 	case "Digest":
-		return isValidDigestAuth(s[1])
+		return isValidDigestAuth(a[1])
 	}
 
 	return false
 }
 
-func isValidBasicAuth(auth string) bool {
+func (srv *Server) isValidBasicAuth(auth string) bool {
 	b, err := base64.StdEncoding.DecodeString(auth)
 	if err != nil {
 		return false
@@ -102,10 +347,10 @@ func isValidBasicAuth(auth string) bool {
 	if len(pair) != 2 {
 		return false
 	}
-	if pair[0] != TestUser {
+	if pair[0] != srv.username {
 		return false
 	}
-	if pair[1] != TestPassword {
+	if pair[1] != srv.password {
 		return false
 	}
 	return true
@@ -115,44 +360,45 @@ func isValidDigestAuth(auth string) bool {
 	return false
 }
 
+// defaultServer is the instance behind package level functions
+var defaultServer = newServer()
+
+// Start mock server for testing CloudSigma endpoint communication.
+// If server is already started, this function does nothing.
+func Start() {
+	if IsStarted() {
+		return
+	}
+	defaultServer.start()
+}
+
 // IsStarted checks the mock server is running
 func IsStarted() bool {
-	if pServer == nil {
-		return false
-	}
-	return true
+	return defaultServer.pServer != nil
 }
 
 // Stop mock server.
-// Panic if server is not started.
 func Stop() {
-	pServer.CloseClientConnections()
-	pServer.Close()
-	pServer = nil
+	defaultServer.Close()
 }
 
 // Reset mock server
 func Reset() {
-	Jobs.Reset()
-	Drives.Reset()
-	LibDrives.Reset()
-	ResetServers()
+	defaultServer.Reset()
 }
 
 // Endpoint of mock server, represented as string in form
 // 'https://host:port/api/{version}/{section}'. Panic if server is not started.
 func Endpoint(section string) string {
-	return pServer.URL + serverBase + section
+	return defaultServer.Endpoint(section)
 }
 
 // GetAuth performs Get request to the given section of mock server with authentication
 func GetAuth(section, username, password string) (*https.Response, error) {
-	client := https.NewAuthClient(username, password, nil)
-	url := Endpoint(section)
-	return client.Get(url, nil)
+	return defaultServer.GetAuth(section, username, password)
 }
 
 // Get performs Get request to the given section of mock server with default authentication
 func Get(section string) (*https.Response, error) {
-	return GetAuth(section, TestUser, TestPassword)
+	return defaultServer.Get(section)
 }
@@ -5,7 +5,10 @@ package mock
 
 import (
 	"bytes"
+	"sync"
 	"testing"
+
+	"github.com/altoros/gosigma/data"
 )
 
 func init() {
@@ -85,6 +88,68 @@ func TestMockSections(t *testing.T) {
 	}
 }
 
+func TestMockServerIsolated(t *testing.T) {
+
+	t.Parallel()
+
+	check := func(name string) {
+		srv := New(WithCredentials(name+"@example.com", name))
+		defer srv.Close()
+
+		if srv.Username() != name+"@example.com" || srv.Password() != name {
+			t.Errorf("%s: invalid credentials", name)
+		}
+
+		if err := srv.AddServer(&data.Server{Name: name}); err != nil {
+			t.Error(err)
+			return
+		}
+		srv.Drives.Add(&data.Drive{Name: name})
+
+		resp, err := srv.GetAuth("servers/detail/", TestUser, TestPassword)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode != 401 {
+			t.Errorf("%s: status %d with default credentials, wants 401", name, resp.StatusCode)
+		}
+
+		resp, err = srv.Get("servers/detail/")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+
+		srv.LogResponse(t, resp)
+
+		ss, err := data.ReadServers(resp.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(ss) != 1 || ss[0].Name != name {
+			t.Errorf("%s: invalid servers %v", name, ss)
+		}
+
+		if jj := srv.GetJournal(GetIDFromResponse(resp)); len(jj) != 1 {
+			t.Errorf("%s: journal length %d, wants 1", name, len(jj))
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"one", "two", "three"} {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			check(name)
+		}(name)
+	}
+	wg.Wait()
+}
+
 /*
 func TestHeaders(t *testing.T) {
 	tr := &http.Transport{
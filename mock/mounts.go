@@ -0,0 +1,153 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"fmt"
+
+	"github.com/altoros/gosigma/data"
+)
+
+const jsonStartDriveFailed = `[{
+		"error_point": null,
+		"error_type": "permission",
+		"error_message": %q
+}]`
+
+// validateDrives checks drives attached to new server instance exist in the
+// account, are attached once, are not mounted elsewhere unless multimount is
+// allowed, have media matching the device and use distinct channels of their
+// device. Claimed
+// holds drives attached to servers validated earlier in the same request, the
+// drives of the server are added to it. Must be called under syncServers lock.
+func (srv *Server) validateDrives(s *data.Server, claimed map[string]bool) error {
+	srv.Drives.s.Lock()
+	defer srv.Drives.s.Unlock()
+
+	attached := make(map[string]bool)
+	channels := make(map[string]bool)
+	for _, sd := range s.Drives {
+		drv, ok := srv.Drives.m[sd.Drive.UUID]
+		if !ok {
+			return invalid("drives", "Drive %s does not exist", sd.Drive.UUID)
+		}
+		if attached[drv.UUID] {
+			return invalid("drives", "Drive %s is attached to the server several times", drv.UUID)
+		}
+		attached[drv.UUID] = true
+		if len(drv.MountedOn) > 0 && !drv.AllowMultimount {
+			return invalid("drives", "Drive %s is already mounted on server %s", drv.UUID, drv.MountedOn[0].UUID)
+		}
+		if claimed[drv.UUID] && !drv.AllowMultimount {
+			return invalid("drives", "Drive %s is attached to several new servers", drv.UUID)
+		}
+		if drv.Media == "cdrom" && sd.Device != "ide" {
+			return invalid("device", "Drive %s with media cdrom must be attached to ide device", drv.UUID)
+		}
+		// channels are numbered per device, virtio and ide may share them
+		channel := sd.Device + " " + sd.Channel
+		if channels[channel] {
+			return invalid("dev_channel", "Channel %s of %s is used by several drives", sd.Channel, sd.Device)
+		}
+		channels[channel] = true
+	}
+
+	for uuid := range attached {
+		claimed[uuid] = true
+	}
+
+	return nil
+}
+
+// checkDrivesReady checks drives attached to server instance can be used for
+// start. Must be called under syncServers lock.
+func (srv *Server) checkDrivesReady(s *data.Server) error {
+	srv.Drives.s.Lock()
+	defer srv.Drives.s.Unlock()
+
+	for _, sd := range s.Drives {
+		drv, ok := srv.Drives.m[sd.Drive.UUID]
+		if !ok {
+			continue
+		}
+		switch drv.Status {
+		case "cloning_dst", "creating":
+			return fmt.Errorf("Cannot start guest with drive %s in state %q", drv.UUID, drv.Status)
+		}
+	}
+
+	return nil
+}
+
+// mountDrives reflects server instance in mounted_on of its drives. Must be
+// called under syncServers lock.
+func (srv *Server) mountDrives(s *data.Server) {
+	srv.Drives.s.Lock()
+	defer srv.Drives.s.Unlock()
+
+	for _, sd := range s.Drives {
+		drv, ok := srv.Drives.m[sd.Drive.UUID]
+		if !ok || isMountedOn(drv, s.UUID) {
+			continue
+		}
+		drv.MountedOn = append(drv.MountedOn, *data.MakeServerResource(s.UUID))
+	}
+}
+
+// unmountDrives removes server instance from mounted_on of its drives. Must be
+// called under syncServers lock.
+func (srv *Server) unmountDrives(s *data.Server) {
+	srv.Drives.s.Lock()
+	defer srv.Drives.s.Unlock()
+
+	for _, sd := range s.Drives {
+		if drv, ok := srv.Drives.m[sd.Drive.UUID]; ok {
+			drv.MountedOn = unmount(drv.MountedOn, s.UUID)
+		}
+	}
+}
+
+func isMountedOn(drv *data.Drive, uuid string) bool {
+	for _, r := range drv.MountedOn {
+		if r.UUID == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+func unmount(rr []data.Resource, uuid string) []data.Resource {
+	var result []data.Resource
+	for _, r := range rr {
+		if r.UUID != uuid {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// removeDrives removes drives of server instance according to recurse
+// specification: "all_drives", "disks" or "cdroms". Drives, still mounted on
+// other servers, are not removed. Must be called under syncServers lock.
+func (srv *Server) removeDrives(s *data.Server, recurse string) {
+	srv.Drives.s.Lock()
+	defer srv.Drives.s.Unlock()
+
+	for _, sd := range s.Drives {
+		drv, ok := srv.Drives.m[sd.Drive.UUID]
+		if !ok || len(drv.MountedOn) > 0 {
+			continue
+		}
+		media := drv.Media
+		if media == "" {
+			media = "disk"
+		}
+		switch {
+		case recurse == "all_drives",
+			recurse == "disks" && media == "disk",
+			recurse == "cdroms" && media == "cdrom":
+			delete(srv.Drives.m, drv.UUID)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/https"
+)
+
+func mountServerBody(channels ...string) string {
+	var dd []string
+	for _, ch := range channels {
+		uuid := "single"
+		if strings.HasPrefix(ch, "m") {
+			uuid, ch = "multi", ch[1:]
+		}
+		dd = append(dd, `{"boot_order": 1, "dev_channel": "`+ch+`", "device": "virtio", "drive": {"uuid": "`+uuid+`"}}`)
+	}
+	return `{"name": "test", "cpu": 2000, "mem": 1073741824, "vnc_password": "test", "drives": [` + strings.Join(dd, ", ") + `]}`
+}
+
+func TestMountDrivesBatch(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	client := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+	post := func(bodies ...string) int {
+		body := `{"objects": [` + strings.Join(bodies, ", ") + `]}`
+		resp, err := client.Post(srv.Endpoint("servers/"), nil, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("single"), Media: "disk"})
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("multi"), Media: "disk", AllowMultimount: true})
+
+	if code := post(mountServerBody("0:0"), mountServerBody("0:0")); code != 400 {
+		t.Errorf("drive attached to two new servers, code %d", code)
+	}
+	if code := post(mountServerBody("0:0", "0:1")); code != 400 {
+		t.Errorf("drive attached twice to the server, code %d", code)
+	}
+	if code := post(mountServerBody("m0:0", "m0:1")); code != 400 {
+		t.Errorf("multimount drive attached twice to the server, code %d", code)
+	}
+
+	srv.syncServers.Lock()
+	n := len(srv.servers)
+	srv.syncServers.Unlock()
+	if n != 0 {
+		t.Errorf("rejected request created %d servers", n)
+	}
+
+	if code := post(mountServerBody("m0:0", "0:1"), mountServerBody("m0:0")); code != 201 {
+		t.Errorf("multimount drive attached to two new servers, code %d", code)
+	}
+	if drv := srv.Drives.m["multi"]; len(drv.MountedOn) != 2 {
+		t.Errorf("multimount drive mounted on %v", drv.MountedOn)
+	}
+}
+
+func TestMountDrivesChannels(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("disk"), Media: "disk"})
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("data"), Media: "disk"})
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("cdrom"), Media: "cdrom"})
+
+	client := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+	post := func(drives ...string) int {
+		body := `{"name": "test", "cpu": 2000, "mem": 1073741824, "vnc_password": "test", "drives": [` + strings.Join(drives, ", ") + `]}`
+		resp, err := client.Post(srv.Endpoint("servers/"), nil, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	drive := func(uuid, device string) string {
+		return `{"dev_channel": "0:0", "device": "` + device + `", "drive": {"uuid": "` + uuid + `"}}`
+	}
+
+	if code := post(drive("disk", "virtio"), drive("data", "virtio")); code != 400 {
+		t.Errorf("drives sharing virtio channel, code %d", code)
+	}
+	if code := post(drive("disk", "virtio"), drive("cdrom", "ide")); code != 201 {
+		t.Errorf("virtio and ide drives on the same channel number, code %d", code)
+	}
+}
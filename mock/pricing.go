@@ -0,0 +1,108 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/altoros/gosigma/data"
+)
+
+const (
+	secondsPerHour  = 60 * 60
+	secondsPerMonth = 30 * 24 * secondsPerHour
+	gigabyte        = 1024 * 1024 * 1024
+)
+
+// DefaultPricing defines pricing catalogue of new mock account. Prices of
+// level 0 are subscription prices, level 1 prices are burst ones.
+var DefaultPricing = data.Pricing{
+	Current: map[string]int{"cpu": 1, "mem": 1, "dssd": 1},
+	Next:    map[string]int{"cpu": 1, "mem": 1, "dssd": 1},
+	Objects: []data.Price{
+		{Currency: "USD", ID: "1", Level: 0, Multiplier: 1000 * secondsPerMonth, Price: "6.00", Resource: "cpu", Unit: "GHz/month"},
+		{Currency: "USD", ID: "2", Level: 1, Multiplier: 1000 * secondsPerHour, Price: "0.0125", Resource: "cpu", Unit: "GHz/hour"},
+		{Currency: "USD", ID: "3", Level: 0, Multiplier: gigabyte * secondsPerMonth, Price: "8.00", Resource: "mem", Unit: "GB/month"},
+		{Currency: "USD", ID: "4", Level: 1, Multiplier: gigabyte * secondsPerHour, Price: "0.0160", Resource: "mem", Unit: "GB/hour"},
+		{Currency: "USD", ID: "5", Level: 0, Multiplier: gigabyte * secondsPerMonth, Price: "0.14", Resource: "dssd", Unit: "GB/month"},
+		{Currency: "USD", ID: "6", Level: 1, Multiplier: gigabyte * secondsPerMonth, Price: "0.21", Resource: "dssd", Unit: "GB/month"},
+		{Currency: "USD", ID: "7", Level: 0, Multiplier: secondsPerMonth, Price: "5.00", Resource: "ip", Unit: "IP/month"},
+		{Currency: "USD", ID: "8", Level: 0, Multiplier: secondsPerMonth, Price: "10.00", Resource: "vlan", Unit: "VLAN/month"},
+		{Currency: "USD", ID: "9", Level: 0, Multiplier: gigabyte, Price: "0.05", Resource: "tx", Unit: "GB"},
+	},
+}
+
+type pricing struct {
+	s sync.Mutex
+	p *data.Pricing
+}
+
+// WithPricing returns Option setting pricing catalogue of the account
+func WithPricing(p data.Pricing) Option {
+	return func(srv *Server) { srv.SetPricing(p) }
+}
+
+// SetPricing sets pricing catalogue of the account. Pricing is kept by Reset.
+func (srv *Server) SetPricing(p data.Pricing) {
+	p = copyPricing(p)
+
+	srv.pricing.s.Lock()
+	defer srv.pricing.s.Unlock()
+	srv.pricing.p = &p
+}
+
+// Pricing returns pricing catalogue of the account
+func (srv *Server) Pricing() data.Pricing {
+	srv.pricing.s.Lock()
+	defer srv.pricing.s.Unlock()
+
+	if srv.pricing.p == nil {
+		return copyPricing(DefaultPricing)
+	}
+	return copyPricing(*srv.pricing.p)
+}
+
+// SetPricing sets pricing catalogue of the account of the default mock
+func SetPricing(p data.Pricing) { defaultServer.SetPricing(p) }
+
+func copyPricing(p data.Pricing) data.Pricing {
+	copyLevels := func(m map[string]int) map[string]int {
+		result := make(map[string]int, len(m))
+		for k, v := range m {
+			result[k] = v
+		}
+		return result
+	}
+	p.Current = copyLevels(p.Current)
+	p.Next = copyLevels(p.Next)
+	p.Objects = append([]data.Price(nil), p.Objects...)
+	return p
+}
+
+// URLs:
+// /api/2.0/pricing/
+func (srv *Server) pricingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(405)
+		return
+	}
+
+	p := srv.Pricing()
+	if currency := r.URL.Query().Get("currency"); currency != "" {
+		var objs []data.Price
+		for _, price := range p.Objects {
+			if price.Currency == currency {
+				objs = append(objs, price)
+			}
+		}
+		p.Objects = objs
+	}
+	if p.Objects == nil {
+		p.Objects = []data.Price{}
+	}
+	p.Meta.TotalCount = len(p.Objects)
+
+	writeJSON(w, 200, &p)
+}
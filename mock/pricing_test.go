@@ -0,0 +1,54 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"testing"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/https"
+)
+
+func TestPricing(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	if p := srv.Pricing(); len(p.Objects) != len(DefaultPricing.Objects) {
+		t.Errorf("invalid default pricing %v", p)
+	}
+
+	p := data.Pricing{
+		Current: map[string]int{"cpu": 2},
+		Objects: []data.Price{
+			{Currency: "EUR", Resource: "cpu", Price: "1", Multiplier: 1},
+			{Currency: "CHF", Resource: "cpu", Price: "2", Multiplier: 1},
+		},
+	}
+	srv.SetPricing(p)
+	p.Current["cpu"] = 3
+	p.Objects[0].Price = "3"
+
+	srv.Reset()
+	if v := srv.Pricing(); v.Current["cpu"] != 2 || v.Objects[0].Price != "1" {
+		t.Errorf("pricing must be copied and kept by Reset, got %v", v)
+	}
+
+	client := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+	resp, err := client.Get(srv.Endpoint("pricing/"), map[string][]string{"currency": {"CHF"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if err := resp.VerifyJSON(200); err != nil {
+		t.Fatal(err)
+	}
+	v, err := data.ReadPricing(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Objects) != 1 || v.Objects[0].Currency != "CHF" || v.Meta.TotalCount != 1 {
+		t.Errorf("invalid pricing response %v", v)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Limits defines resource limits of the mock account. Zero value of a limit
+// means no limit, except Snapshots, which defaults to the value reported by
+// capabilities section.
+type Limits struct {
+	// Servers limits number of servers in the account
+	Servers int
+	// Mem limits total RAM of servers in the account, in bytes
+	Mem uint64
+	// DriveSize limits total size of account drives per storage type, in bytes.
+	// Drives without storage type are accounted as "dssd".
+	DriveSize map[string]uint64
+	// Snapshots limits number of drive snapshots in the account
+	Snapshots int
+}
+
+type limits struct {
+	s sync.Mutex
+	l Limits
+}
+
+// A quotaError describes creation of object exceeding limits of the account
+type quotaError struct {
+	point   string
+	message string
+}
+
+func (e quotaError) Error() string { return e.message }
+
+func exceeded(point, format string, args ...interface{}) error {
+	return quotaError{point, fmt.Sprintf(format, args...)}
+}
+
+// writeQuotaError writes CloudSigma billing error object to the response
+func writeQuotaError(w http.ResponseWriter, err error) {
+	point := ""
+	if qe, ok := err.(quotaError); ok {
+		point = qe.point
+	}
+	writeError(w, 402, "billing", point, err.Error())
+}
+
+// WithLimits returns Option setting resource limits of the account
+func WithLimits(l Limits) Option {
+	return func(srv *Server) { srv.SetLimits(l) }
+}
+
+// SetLimits sets resource limits of the account. Limits are kept by Reset.
+func (srv *Server) SetLimits(l Limits) {
+	dd := make(map[string]uint64, len(l.DriveSize))
+	for k, v := range l.DriveSize {
+		dd[k] = v
+	}
+	l.DriveSize = dd
+
+	srv.limits.s.Lock()
+	defer srv.limits.s.Unlock()
+	srv.limits.l = l
+}
+
+// Limits returns resource limits of the account
+func (srv *Server) Limits() Limits {
+	srv.limits.s.Lock()
+	defer srv.limits.s.Unlock()
+
+	l := srv.limits.l
+	l.DriveSize = make(map[string]uint64, len(srv.limits.l.DriveSize))
+	for k, v := range srv.limits.l.DriveSize {
+		l.DriveSize[k] = v
+	}
+	if l.Snapshots == 0 {
+		l.Snapshots = caps.Snapshots.Max
+	}
+	return l
+}
+
+// SetLimits sets resource limits of the account of the default mock
+func SetLimits(l Limits) { defaultServer.SetLimits(l) }
+
+// checkServerQuota checks whether servers with given total RAM can be added
+// to the account, must be called under syncServers lock
+func (srv *Server) checkServerQuota(count int, mem uint64) error {
+	l := srv.Limits()
+
+	if l.Servers > 0 && len(srv.servers)+count > l.Servers {
+		return exceeded("servers", "Insufficient resources: limit of %d servers exceeded.", l.Servers)
+	}
+
+	if l.Mem > 0 {
+		for _, s := range srv.servers {
+			mem += s.Mem
+		}
+		if mem > l.Mem {
+			return exceeded("mem", "Insufficient resources: limit of %d bytes of RAM exceeded.", l.Mem)
+		}
+	}
+
+	return nil
+}
+
+// checkDriveQuota checks whether account drives of given storage type can
+// grow by size bytes
+func (srv *Server) checkDriveQuota(storageType string, size uint64) error {
+	if storageType == "" {
+		storageType = defaultStorageType
+	}
+
+	l := srv.Limits()
+	max, ok := l.DriveSize[storageType]
+	if !ok || max == 0 {
+		return nil
+	}
+
+	if used := srv.Drives.usedSize(storageType); used+size > max {
+		return exceeded("size", "Insufficient resources: limit of %d bytes of %s storage exceeded.", max, storageType)
+	}
+
+	return nil
+}
+
+// checkSnapshotQuota checks whether given number of snapshots can be added to
+// the account, already having used ones
+func (srv *Server) checkSnapshotQuota(used, count int) error {
+	if max := srv.Limits().Snapshots; used+count > max {
+		return exceeded("snapshots", "Insufficient resources: limit of %d snapshots exceeded.", max)
+	}
+	return nil
+}
+
+// usedSize returns total size of drives of given storage type in the library
+func (d *DriveLibrary) usedSize(storageType string) uint64 {
+	d.s.Lock()
+	defer d.s.Unlock()
+
+	var size uint64
+	for _, drv := range d.m {
+		st := drv.StorageType
+		if st == "" {
+			st = defaultStorageType
+		}
+		if st == storageType {
+			size += drv.Size
+		}
+	}
+	return size
+}
@@ -0,0 +1,143 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/https"
+)
+
+const quotaServerBody = `{"name": "test", "cpu": 2000, "mem": 1073741824, "vnc_password": "test"}`
+
+func checkQuotaResponse(t *testing.T, resp *https.Response, point string) {
+	defer resp.Body.Close()
+
+	if err := resp.VerifyJSON(402); err != nil {
+		t.Error(err)
+		return
+	}
+
+	ee, err := data.ReadError(resp.Body)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(ee) != 1 || ee[0].Type != "billing" || ee[0].Point != point {
+		t.Errorf("invalid error %#v", ee)
+	}
+}
+
+func TestQuotaServers(t *testing.T) {
+	srv := New(WithLimits(Limits{Servers: 2, Mem: 2 * 1073741824}))
+	defer srv.Close()
+
+	client := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+	post := func(body string) *https.Response {
+		resp, err := client.Post(srv.Endpoint("servers/"), nil, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := post(quotaServerBody)
+	resp.Body.Close()
+	if err := resp.VerifyCode(201); err != nil {
+		t.Fatal(err)
+	}
+
+	checkQuotaResponse(t, post(strings.Replace(quotaServerBody, "1073741824", "2147483648", 1)), "mem")
+	checkQuotaResponse(t, post(`{"objects": [`+quotaServerBody+`, `+quotaServerBody+`]}`), "servers")
+
+	resp = post(quotaServerBody)
+	resp.Body.Close()
+	if err := resp.VerifyCode(201); err != nil {
+		t.Fatal(err)
+	}
+
+	checkQuotaResponse(t, post(strings.Replace(quotaServerBody, "1073741824", "268435456", 1)), "servers")
+
+	if n := len(srv.Snapshot().Servers); n != 2 {
+		t.Errorf("servers count %d, wants 2", n)
+	}
+}
+
+func TestQuotaDriveSize(t *testing.T) {
+	const gb = 1073741824
+
+	srv := New(WithLimits(Limits{DriveSize: map[string]uint64{"dssd": 3 * gb}}))
+	defer srv.Close()
+
+	srv.LibDrives.Add(&data.Drive{Resource: *data.MakeLibDriveResource("lib"), Size: 2 * gb})
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("zadara"), Size: 10 * gb, StorageType: "zadara"})
+
+	client := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+	post := func(section, body string) *https.Response {
+		resp, err := client.Post(srv.Endpoint(section), nil, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := post("libdrives/lib/action/?do=clone", `{}`)
+	resp.Body.Close()
+	if err := resp.VerifyCode(202); err != nil {
+		t.Fatal(err)
+	}
+
+	checkQuotaResponse(t, post("libdrives/lib/action/?do=clone", `{}`), "size")
+
+	var uuid string
+	for _, d := range srv.Snapshot().Drives {
+		if d.StorageType == "" {
+			uuid = d.UUID
+		}
+	}
+
+	checkQuotaResponse(t, post("drives/"+uuid+"/action/?do=resize", `{"size": 4294967296}`), "size")
+
+	resp = post("drives/"+uuid+"/action/?do=resize", `{"size": 3221225472}`)
+	resp.Body.Close()
+	if err := resp.VerifyCode(202); err != nil {
+		t.Error(err)
+	}
+
+	resp = post("drives/zadara/action/?do=resize", `{"size": 21474836480}`)
+	resp.Body.Close()
+	if err := resp.VerifyCode(202); err != nil {
+		t.Error("other storage type must not be limited:", err)
+	}
+}
+
+func TestQuotaLimits(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	if l := srv.Limits(); l.Servers != 0 || l.Mem != 0 || len(l.DriveSize) != 0 || l.Snapshots != 600 {
+		t.Errorf("invalid default limits %#v", l)
+	}
+
+	l := Limits{Snapshots: 2, DriveSize: map[string]uint64{"dssd": 1}}
+	srv.SetLimits(l)
+	l.DriveSize["dssd"] = 2
+	if v := srv.Limits().DriveSize["dssd"]; v != 1 {
+		t.Errorf("limits must be copied, got %d", v)
+	}
+
+	if err := srv.checkSnapshotQuota(1, 1); err != nil {
+		t.Error(err)
+	}
+	if err := srv.checkSnapshotQuota(1, 2); err == nil {
+		t.Error("snapshot quota must be exceeded")
+	}
+
+	srv.Reset()
+	if v := srv.Limits().Snapshots; v != 2 {
+		t.Errorf("limits must be kept by Reset, got %d", v)
+	}
+}
@@ -0,0 +1,485 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/altoros/gosigma/data"
+)
+
+// RemoteSnapshotLibrary type to store all remote snapshots in the mock
+type RemoteSnapshotLibrary struct {
+	s   sync.Mutex
+	m   map[string]*data.RemoteSnapshot
+	p   string
+	srv *Server
+
+	// images of drives taken with snapshots
+	images map[string][]byte
+}
+
+// RemoteSnapshots defines library of all remote snapshots in the default mock
+var RemoteSnapshots = defaultServer.RemoteSnapshots
+
+// InitRemoteSnapshot initializes the remote snapshot
+func InitRemoteSnapshot(s *data.RemoteSnapshot) (*data.RemoteSnapshot, error) {
+	if s.UUID == "" {
+		uuid, err := GenerateUUID()
+		if err != nil {
+			return nil, err
+		}
+		s.UUID = uuid
+	}
+	s.Resource = *data.MakeRemoteSnapshotResource(s.UUID)
+	if s.Drive != nil {
+		s.Drive = data.MakeDriveResource(s.Drive.UUID)
+	}
+	if s.Meta == nil {
+		s.Meta = make(map[string]string)
+	}
+	if s.Status == "" {
+		s.Status = "available"
+	}
+
+	return s, nil
+}
+
+// Add remote snapshot to the library
+func (rs *RemoteSnapshotLibrary) Add(s *data.RemoteSnapshot) error {
+	s, err := InitRemoteSnapshot(s)
+	if err != nil {
+		return err
+	}
+
+	rs.s.Lock()
+	defer rs.s.Unlock()
+
+	rs.m[s.UUID] = s
+
+	return nil
+}
+
+// AddRemoteSnapshots adds remote snapshot collection to the library
+func (rs *RemoteSnapshotLibrary) AddRemoteSnapshots(ss []data.RemoteSnapshot) []string {
+	rs.s.Lock()
+	defer rs.s.Unlock()
+
+	var result []string
+	for _, s := range ss {
+		s := s
+		ps, err := InitRemoteSnapshot(&s)
+		if err != nil {
+			continue
+		}
+		rs.m[ps.UUID] = ps
+		result = append(result, ps.UUID)
+	}
+	return result
+}
+
+// Remove remote snapshot from the library
+func (rs *RemoteSnapshotLibrary) Remove(uuid string) bool {
+	rs.s.Lock()
+	defer rs.s.Unlock()
+
+	_, ok := rs.m[uuid]
+	delete(rs.m, uuid)
+	delete(rs.images, uuid)
+
+	return ok
+}
+
+// Reset the library
+func (rs *RemoteSnapshotLibrary) Reset() {
+	rs.s.Lock()
+	defer rs.s.Unlock()
+	rs.m = make(map[string]*data.RemoteSnapshot)
+	rs.images = nil
+}
+
+// SetStatus sets remote snapshot status in the library
+func (rs *RemoteSnapshotLibrary) SetStatus(uuid, status string) {
+	rs.s.Lock()
+	defer rs.s.Unlock()
+
+	if s, ok := rs.m[uuid]; ok {
+		s.Status = status
+	}
+}
+
+// get returns copy of remote snapshot with given uuid
+func (rs *RemoteSnapshotLibrary) get(uuid string) (data.RemoteSnapshot, bool) {
+	rs.s.Lock()
+	defer rs.s.Unlock()
+
+	s, ok := rs.m[uuid]
+	if !ok {
+		return data.RemoteSnapshot{}, false
+	}
+	return *s, true
+}
+
+// setImage stores drive image taken with remote snapshot
+func (rs *RemoteSnapshotLibrary) setImage(uuid string, image []byte) {
+	rs.s.Lock()
+	defer rs.s.Unlock()
+
+	if _, ok := rs.m[uuid]; !ok {
+		return
+	}
+	if rs.images == nil {
+		rs.images = make(map[string][]byte)
+	}
+	rs.images[uuid] = append([]byte(nil), image...)
+}
+
+// image returns drive image taken with remote snapshot
+func (rs *RemoteSnapshotLibrary) image(uuid string) ([]byte, bool) {
+	rs.s.Lock()
+	defer rs.s.Unlock()
+
+	image, ok := rs.images[uuid]
+	return image, ok
+}
+
+// addJob attaches job to remote snapshot with given uuid
+func (rs *RemoteSnapshotLibrary) addJob(uuid string, job data.Resource) {
+	rs.s.Lock()
+	defer rs.s.Unlock()
+
+	if s, ok := rs.m[uuid]; ok {
+		s.Jobs = append(s.Jobs, job)
+	}
+}
+
+func (rs *RemoteSnapshotLibrary) snapshot() []data.RemoteSnapshot {
+	rs.s.Lock()
+	defer rs.s.Unlock()
+
+	var result []data.RemoteSnapshot
+	for _, s := range rs.m {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].UUID < result[j].UUID })
+	return result
+}
+
+// URLs:
+// /api/2.0/remotesnapshots/
+// /api/2.0/remotesnapshots/detail/
+// /api/2.0/remotesnapshots/{uuid}/
+// /api/2.0/remotesnapshots/{uuid}/action/?do={clone,restore}
+func (rs *RemoteSnapshotLibrary) handleRequest(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	path = strings.TrimPrefix(path, rs.p)
+	path = strings.TrimPrefix(path, "/")
+
+	switch {
+	case r.Method == "GET" && path == "":
+		rs.handleList(w, r, 200, false, nil)
+	case r.Method == "GET" && path == "detail":
+		rs.handleList(w, r, 200, true, nil)
+	case r.Method == "GET":
+		rs.handleGet(w, r, path)
+	case r.Method == "POST" && path == "":
+		rs.handleCreate(w, r)
+	case r.Method == "POST" && strings.HasSuffix(path, "/action"):
+		rs.handleAction(w, r, strings.TrimSuffix(path, "/action"))
+	case r.Method == "DELETE" && path != "":
+		rs.handleDelete(w, r, path)
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+func (rs *RemoteSnapshotLibrary) handleList(w http.ResponseWriter, r *http.Request, okcode int, detail bool, filter []string) {
+	rs.s.Lock()
+	defer rs.s.Unlock()
+
+	var ss data.RemoteSnapshots
+	if len(filter) == 0 {
+		for _, s := range rs.m {
+			filter = append(filter, s.UUID)
+		}
+		sort.Strings(filter)
+	}
+	ss.Objects = make([]data.RemoteSnapshot, 0, len(filter))
+	for _, uuid := range filter {
+		s, ok := rs.m[uuid]
+		if !ok {
+			continue
+		}
+		if detail {
+			ss.Objects = append(ss.Objects, *s)
+		} else {
+			ss.Objects = append(ss.Objects, data.RemoteSnapshot{
+				Resource: s.Resource,
+				Drive:    s.Drive,
+				Location: s.Location,
+				Name:     s.Name,
+				Status:   s.Status,
+			})
+		}
+	}
+	ss.Meta.TotalCount = len(ss.Objects)
+
+	writeJSON(w, okcode, &ss)
+}
+
+func (rs *RemoteSnapshotLibrary) handleGet(w http.ResponseWriter, r *http.Request, uuid string) {
+	s, ok := rs.get(uuid)
+	if !ok {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	writeJSON(w, 200, &s)
+}
+
+func (rs *RemoteSnapshotLibrary) handleCreate(w http.ResponseWriter, r *http.Request) {
+	bb, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(400)
+		return
+	}
+
+	ss, err := data.ReadRemoteSnapshots(bytes.NewReader(bb))
+	if err != nil || len(ss) == 0 {
+		s, err := data.ReadRemoteSnapshot(bytes.NewReader(bb))
+		if err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		ss = []data.RemoteSnapshot{*s}
+	}
+
+	for i := range ss {
+		if err := validateRemoteSnapshot(&ss[i]); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+	}
+
+	// snapshot takes size and default name of the drive
+	var missing string
+	rs.srv.Drives.s.Lock()
+	for i := range ss {
+		drv, ok := rs.srv.Drives.m[ss[i].Drive.UUID]
+		if !ok {
+			missing = ss[i].Drive.UUID
+			break
+		}
+		if ss[i].Name == "" {
+			ss[i].Name = drv.Name
+		}
+		ss[i].AllocatedSize = drv.Size
+	}
+	rs.srv.Drives.s.Unlock()
+
+	if missing != "" {
+		writeValidationError(w, invalid("drive", "Drive %s does not exist", missing))
+		return
+	}
+
+	rs.s.Lock()
+	used := len(rs.m)
+	rs.s.Unlock()
+
+	if err := rs.srv.checkSnapshotQuota(used, len(ss)); err != nil {
+		writeQuotaError(w, err)
+		return
+	}
+
+	now := rs.srv.clock.Now()
+	for i := range ss {
+		ss[i].UUID = ""
+		ss[i].Jobs = nil
+		ss[i].Status = "creating"
+		ss[i].Timestamp = &now
+	}
+
+	uuids := rs.AddRemoteSnapshots(ss)
+	for _, uuid := range uuids {
+		uuid := uuid
+		s, _ := rs.get(uuid)
+		if image, ok := rs.srv.DriveImage(s.Drive.UUID); ok {
+			rs.setImage(uuid, image)
+		}
+		rs.startJob("remote_snapshot_create", []string{s.Drive.URI, s.URI}, uuid, func() {
+			rs.SetStatus(uuid, "available")
+		})
+	}
+
+	rs.handleList(w, r, 201, true, uuids)
+}
+
+// startJob adds job of remote snapshot operation and runs it, returns the job
+func (rs *RemoteSnapshotLibrary) startJob(operation string, resources []string, uuid string, done func()) data.Job {
+	job := &data.Job{
+		Operation: operation,
+		Resources: resources,
+	}
+	rs.srv.Jobs.Add(job)
+
+	// job must be copied before it is run
+	result := *job
+
+	rs.addJob(uuid, *data.MakeJobResource(job.UUID))
+	rs.srv.Jobs.run(job.UUID, rs.srv.transitions.Snapshot, done)
+
+	return result
+}
+
+func (rs *RemoteSnapshotLibrary) handleAction(w http.ResponseWriter, r *http.Request, uuid string) {
+	s, ok := rs.get(uuid)
+	if !ok {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	if s.Status != "available" {
+		writeError(w, 409, "concurrency", "", "Remote snapshot is not available.")
+		return
+	}
+
+	switch r.URL.Query().Get("do") {
+	case "clone":
+		rs.handleClone(w, r, s)
+	case "restore":
+		rs.handleRestore(w, r, s)
+	default:
+		w.WriteHeader(400)
+	}
+}
+
+// handleClone creates new drive from the remote snapshot
+func (rs *RemoteSnapshotLibrary) handleClone(w http.ResponseWriter, r *http.Request, s data.RemoteSnapshot) {
+	var params map[string]interface{}
+
+	bb, err := ioutil.ReadAll(r.Body)
+	if err == nil && len(bb) > 0 {
+		err = json.Unmarshal(bb, &params)
+	}
+
+	if err != nil {
+		w.WriteHeader(400)
+		return
+	}
+
+	if err := validateCloneParams(params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	if err := rs.srv.checkDriveQuota(defaultStorageType, s.AllocatedSize); err != nil {
+		writeQuotaError(w, err)
+		return
+	}
+
+	drv, err := InitDrive(&data.Drive{
+		Name:        s.Name,
+		Media:       "disk",
+		Size:        s.AllocatedSize,
+		Status:      "cloning_dst",
+		StorageType: defaultStorageType,
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte("500 " + err.Error()))
+		return
+	}
+	drv.Resource = *data.MakeDriveResource(drv.UUID)
+
+	if v, ok := params["name"].(string); ok {
+		drv.Name = v
+	}
+	if v, ok := params["media"].(string); ok {
+		drv.Media = v
+	}
+
+	drives := rs.srv.Drives
+	drives.Add(drv)
+
+	job := rs.startJob("remote_snapshot_clone", []string{s.URI, drv.URI}, s.UUID, func() {
+		drives.SetStatus(drv.UUID, "unmounted")
+	})
+
+	drives.s.Lock()
+	drv.Jobs = append(drv.Jobs, job.Resource)
+	drives.s.Unlock()
+
+	drives.handleDrivesDetail(w, r, 202, []string{drv.UUID})
+}
+
+// handleRestore reverts the drive the remote snapshot was taken from to the
+// snapshot contents, the drive can not be used until the restore job is done
+func (rs *RemoteSnapshotLibrary) handleRestore(w http.ResponseWriter, r *http.Request, s data.RemoteSnapshot) {
+	drives := rs.srv.Drives
+
+	drives.s.Lock()
+	var status string
+	drv, ok := drives.m[s.Drive.UUID]
+	if ok {
+		status = drv.Status
+	}
+	drives.s.Unlock()
+
+	if !ok {
+		writeValidationError(w, invalid("drive", "Drive %s does not exist", s.Drive.UUID))
+		return
+	}
+	if status != "unmounted" {
+		writeError(w, 409, "concurrency", "", "Drive must be unmounted to be restored.")
+		return
+	}
+
+	drives.s.Lock()
+	if drv, ok := drives.m[s.Drive.UUID]; ok {
+		drv.Size = s.AllocatedSize
+		drv.Status = "cloning_dst"
+	}
+	drives.s.Unlock()
+
+	image, ok := rs.image(s.UUID)
+	job := rs.startJob("remote_snapshot_restore", []string{s.URI, s.Drive.URI}, s.UUID, func() {
+		if ok {
+			rs.srv.SetDriveImage(s.Drive.UUID, image)
+		} else {
+			rs.srv.removeDriveImage(s.Drive.UUID)
+		}
+		drives.SetStatus(s.Drive.UUID, "unmounted")
+	})
+
+	drives.s.Lock()
+	if drv, ok := drives.m[s.Drive.UUID]; ok {
+		drv.Jobs = append(drv.Jobs, job.Resource)
+	}
+	drives.s.Unlock()
+
+	writeJSON(w, 202, &job)
+}
+
+func (rs *RemoteSnapshotLibrary) handleDelete(w http.ResponseWriter, r *http.Request, uuid string) {
+	if !rs.Remove(uuid) {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+	w.WriteHeader(204)
+}
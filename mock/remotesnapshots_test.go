@@ -0,0 +1,246 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/https"
+)
+
+func createRemoteSnapshot(t *testing.T, cli *https.Client, u, body string) (*https.Response, []data.RemoteSnapshot) {
+	r, err := cli.Post(u, nil, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	if r.StatusCode != 201 {
+		return r, nil
+	}
+	ss, err := data.ReadRemoteSnapshots(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, ss
+}
+
+func TestRemoteSnapshotCreate(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	srv := New(WithClock(clock))
+	defer srv.Close()
+
+	drv := &data.Drive{Name: "data", Size: 1024, Status: "unmounted"}
+	if err := srv.Drives.Add(drv); err != nil {
+		t.Fatal(err)
+	}
+
+	cli := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+	u := srv.Endpoint("remotesnapshots/")
+
+	body := `{"objects":[{"drive":{"uuid":"` + drv.UUID + `"},"location":"wdc"}]}`
+	r, ss := createRemoteSnapshot(t, cli, u, body)
+	if r.StatusCode != 201 || len(ss) != 1 {
+		t.Fatalf("create remote snapshot, code %d, %v", r.StatusCode, ss)
+	}
+
+	s := ss[0]
+	if s.Name != "data" || s.AllocatedSize != 1024 || s.Status != "creating" || len(s.Jobs) != 1 {
+		t.Errorf("invalid remote snapshot %#v", s)
+	}
+
+	clock.Advance(srv.transitions.Snapshot)
+	if s, _ := srv.RemoteSnapshots.get(s.UUID); s.Status != "available" {
+		t.Errorf("remote snapshot status %q, wants available", s.Status)
+	}
+	if job := srv.Jobs.m[s.Jobs[0].UUID]; job.State != "success" || job.Operation != "remote_snapshot_create" {
+		t.Errorf("invalid job %#v", job)
+	}
+
+	for body, code := range map[string]int{
+		`{"objects":[{"location":"wdc"}]}`:                                       400,
+		`{"objects":[{"drive":{"uuid":"` + drv.UUID + `"}}]}`:                    400,
+		`{"objects":[{"drive":{"uuid":"` + drv.UUID + `"},"location":"WDC1"}]}`:  400,
+		`{"objects":[{"drive":{"uuid":"missing-drive-uuid"},"location":"wdc"}]}`: 400,
+	} {
+		if r, _ := createRemoteSnapshot(t, cli, u, body); r.StatusCode != code {
+			t.Errorf("%s: code %d, wants %d", body, r.StatusCode, code)
+		}
+	}
+
+	srv.SetLimits(Limits{Snapshots: 1})
+	if r, _ := createRemoteSnapshot(t, cli, u, body); r.StatusCode != 402 {
+		t.Errorf("snapshot quota must be checked, code %d", r.StatusCode)
+	}
+}
+
+func TestRemoteSnapshotActions(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	srv := New(WithClock(clock))
+	defer srv.Close()
+
+	drv := &data.Drive{Name: "data", Size: 1024, Status: "mounted"}
+	if err := srv.Drives.Add(drv); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &data.RemoteSnapshot{
+		Drive:         data.MakeDriveResource(drv.UUID),
+		Location:      "wdc",
+		Name:          "backup",
+		AllocatedSize: 2048,
+	}
+	if err := srv.RemoteSnapshots.Add(s); err != nil {
+		t.Fatal(err)
+	}
+
+	cli := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+	u := srv.Endpoint("remotesnapshots/" + s.UUID + "/action/")
+
+	r, err := cli.Post(u, map[string][]string{"do": {"restore"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != 409 {
+		t.Errorf("restore of mounted drive, code %d", r.StatusCode)
+	}
+
+	srv.Drives.SetStatus(drv.UUID, "unmounted")
+	r, err = cli.Post(u, map[string][]string{"do": {"restore"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := data.ReadJob(r.Body)
+	r.Body.Close()
+	if err != nil || r.StatusCode != 202 {
+		t.Fatalf("restore, code %d, error %v", r.StatusCode, err)
+	}
+	if job.Operation != "remote_snapshot_restore" || srv.Drives.m[drv.UUID].Size != 2048 {
+		t.Errorf("invalid restore job %#v", job)
+	}
+	if status := srv.Drives.m[drv.UUID].Status; status != "cloning_dst" {
+		t.Errorf("drive status %q during restore, wants cloning_dst", status)
+	}
+	r, err = cli.Post(u, map[string][]string{"do": {"restore"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != 409 {
+		t.Errorf("restore of drive being restored, code %d", r.StatusCode)
+	}
+
+	r, err = cli.Post(u, map[string][]string{"do": {"clone"}}, strings.NewReader(`{"name":"restored"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dd, err := data.ReadDrives(r.Body)
+	r.Body.Close()
+	if err != nil || r.StatusCode != 202 || len(dd) != 1 {
+		t.Fatalf("clone, code %d, error %v", r.StatusCode, err)
+	}
+	if d := dd[0]; d.Name != "restored" || d.Size != 2048 || d.Status != "cloning_dst" || len(d.Jobs) != 1 {
+		t.Errorf("invalid clone %#v", d)
+	}
+
+	clock.Advance(srv.transitions.Snapshot)
+	if status := srv.Drives.m[dd[0].UUID].Status; status != "unmounted" {
+		t.Errorf("clone status %q, wants unmounted", status)
+	}
+	if status := srv.Drives.m[drv.UUID].Status; status != "unmounted" {
+		t.Errorf("restored drive status %q, wants unmounted", status)
+	}
+	if s, _ := srv.RemoteSnapshots.get(s.UUID); len(s.Jobs) != 2 {
+		t.Errorf("remote snapshot jobs %v", s.Jobs)
+	}
+
+	srv.RemoteSnapshots.SetStatus(s.UUID, "creating")
+	r, err = cli.Post(u, map[string][]string{"do": {"clone"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != 409 {
+		t.Errorf("clone of unavailable snapshot, code %d", r.StatusCode)
+	}
+
+	r, err = cli.Delete(srv.Endpoint("remotesnapshots/"+s.UUID+"/"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != 204 {
+		t.Errorf("delete, code %d", r.StatusCode)
+	}
+	if _, ok := srv.RemoteSnapshots.get(s.UUID); ok {
+		t.Error("remote snapshot must be removed")
+	}
+}
+
+func TestRemoteSnapshotRestoreImage(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	srv := New(WithClock(clock))
+	defer srv.Close()
+
+	drv := &data.Drive{Name: "data", Size: 4, Status: "unmounted"}
+	if err := srv.Drives.Add(drv); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetDriveImage(drv.UUID, []byte("base"))
+
+	cli := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+
+	body := `{"objects":[{"drive":{"uuid":"` + drv.UUID + `"},"location":"wdc"}]}`
+	r, ss := createRemoteSnapshot(t, cli, srv.Endpoint("remotesnapshots/"), body)
+	if r.StatusCode != 201 || len(ss) != 1 {
+		t.Fatalf("create remote snapshot, code %d, %v", r.StatusCode, ss)
+	}
+	clock.Advance(srv.transitions.Snapshot)
+
+	srv.SetDriveImage(drv.UUID, []byte("next"))
+
+	u := srv.Endpoint("remotesnapshots/" + ss[0].UUID + "/action/")
+	r, err := cli.Post(u, map[string][]string{"do": {"restore"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != 202 {
+		t.Fatalf("restore, code %d", r.StatusCode)
+	}
+
+	if image, _ := srv.DriveImage(drv.UUID); string(image) != "next" {
+		t.Errorf("drive image %q before restore is done", image)
+	}
+	clock.Advance(srv.transitions.Snapshot)
+	if image, _ := srv.DriveImage(drv.UUID); string(image) != "base" {
+		t.Errorf("restored drive image %q, wants %q", image, "base")
+	}
+}
+
+func TestRemoteSnapshotState(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	s := &data.RemoteSnapshot{Location: "wdc", Name: "backup"}
+	if err := srv.RemoteSnapshots.Add(s); err != nil {
+		t.Fatal(err)
+	}
+
+	snap := srv.Snapshot()
+	srv.Reset()
+	if _, ok := srv.RemoteSnapshots.get(s.UUID); ok {
+		t.Error("reset must remove remote snapshots")
+	}
+
+	if err := srv.Restore(snap); err != nil {
+		t.Fatal(err)
+	}
+	if rs, ok := srv.RemoteSnapshots.get(s.UUID); !ok || rs.Name != "backup" || rs.Status != "available" {
+		t.Errorf("restored remote snapshot %#v", rs)
+	}
+}
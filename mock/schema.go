@@ -0,0 +1,309 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/sshkey"
+)
+
+// A validationError describes request body violating the API schema
+type validationError struct {
+	point   string
+	message string
+}
+
+func (e validationError) Error() string { return e.message }
+
+func invalid(point, format string, args ...interface{}) error {
+	return validationError{point, fmt.Sprintf(format, args...)}
+}
+
+// writeValidationError writes CloudSigma validation error object to the response
+func writeValidationError(w http.ResponseWriter, err error) {
+	point := ""
+	if ve, ok := err.(validationError); ok {
+		point = ve.point
+	}
+	writeError(w, 400, "validation", point, err.Error())
+}
+
+// writeError writes CloudSigma error object to the response
+func writeError(w http.ResponseWriter, code int, errorType, point, message string) {
+	bb, _ := json.Marshal([]data.Error{{Type: errorType, Point: point, Message: message}})
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write(bb)
+}
+
+type capsRange struct {
+	Min uint64 `json:"min"`
+	Max uint64 `json:"max"`
+}
+
+type capsSize struct {
+	Min uint64 `json:"min_size"`
+	Max uint64 `json:"max_size"`
+}
+
+// limits of object properties, as reported by capabilities section
+var caps = func() (c struct {
+	Drives  map[string]capsSize `json:"drives"`
+	Servers struct {
+		CPU capsRange `json:"cpu"`
+		Mem capsRange `json:"mem"`
+		SMP capsRange `json:"smp"`
+	} `json:"servers"`
+	Snapshots struct {
+		Max int `json:"max"`
+	} `json:"snapshots"`
+}) {
+	if err := json.Unmarshal([]byte(response), &c); err != nil {
+		panic(err)
+	}
+	return
+}()
+
+// default storage type for size limits of drives
+const defaultStorageType = "dssd"
+
+var reChannel = regexp.MustCompile(`^\d+:\d+$`)
+
+func checkRange(point string, v uint64, r capsRange) error {
+	if v < r.Min || v > r.Max {
+		return invalid(point, "Value of %s must be in range [%d, %d], got %d", point, r.Min, r.Max, v)
+	}
+	return nil
+}
+
+func checkEnum(point, v string, values ...string) error {
+	for _, value := range values {
+		if v == value {
+			return nil
+		}
+	}
+	return invalid(point, "Value of %s must be one of %q, got %q", point, values, v)
+}
+
+// validateServer checks server object of create request against the API schema
+func validateServer(s *data.Server) error {
+	if s.Name == "" {
+		return invalid("name", "This field is required.")
+	}
+	if err := validateServerUpdate(s); err != nil {
+		return err
+	}
+	if s.CPU == 0 {
+		return invalid("cpu", "This field is required.")
+	}
+	if err := checkRange("cpu", s.CPU, caps.Servers.CPU); err != nil {
+		return err
+	}
+	if s.Mem == 0 {
+		return invalid("mem", "This field is required.")
+	}
+	if err := checkRange("mem", s.Mem, caps.Servers.Mem); err != nil {
+		return err
+	}
+	if s.SMP != 0 {
+		if err := checkRange("smp", s.SMP, caps.Servers.SMP); err != nil {
+			return err
+		}
+	}
+	if s.VNCPassword == "" {
+		return invalid("vnc_password", "This field is required.")
+	}
+
+	for _, sd := range s.Drives {
+		if sd.Drive.UUID == "" {
+			return invalid("drives", "Drive of attached drive is required.")
+		}
+		if !reChannel.MatchString(sd.Channel) {
+			return invalid("dev_channel", "Value of dev_channel must be in form 'N:M', got %q", sd.Channel)
+		}
+		if err := checkEnum("device", sd.Device, "virtio", "ide"); err != nil {
+			return err
+		}
+	}
+
+	for _, n := range s.NICs {
+		if n.VLAN == nil && n.IPv4 == nil {
+			return invalid("nics", "NIC must have either ip_v4_conf or vlan.")
+		}
+		if err := checkEnum("model", n.Model, "virtio", "e1000"); err != nil {
+			return err
+		}
+		if n.IPv4 == nil {
+			continue
+		}
+		if err := checkEnum("conf", n.IPv4.Conf, "dhcp", "static", "manual"); err != nil {
+			return err
+		}
+		if n.IPv4.Conf == "static" && (n.IPv4.IP == nil || n.IPv4.IP.UUID == "") {
+			return invalid("ip", "IP address is required for static configuration.")
+		}
+	}
+
+	return nil
+}
+
+// validateServerUpdate checks fields of server object changed by update request
+// against the API schema, the fields omitted from request are left intact
+func validateServerUpdate(s *data.Server) error {
+	if s.Name != "" && strings.TrimSpace(s.Name) == "" {
+		return invalid("name", "This field may not be blank.")
+	}
+	for k := range s.Meta {
+		if strings.TrimSpace(k) == "" {
+			return invalid("meta", "Meta keys may not be blank.")
+		}
+	}
+	return nil
+}
+
+// validateCloneParams checks parameters of drive clone request against the API schema
+func validateCloneParams(params map[string]interface{}) error {
+	for k, v := range params {
+		switch k {
+		case "name":
+			if _, ok := v.(string); !ok {
+				return invalid(k, "Value of %s must be string.", k)
+			}
+		case "media":
+			s, ok := v.(string)
+			if !ok {
+				return invalid(k, "Value of %s must be string.", k)
+			}
+			if err := checkEnum(k, s, "disk", "cdrom"); err != nil {
+				return err
+			}
+		case "affinities":
+			vv, ok := v.([]interface{})
+			if !ok {
+				return invalid(k, "Value of %s must be list of strings.", k)
+			}
+			for _, v := range vv {
+				if _, ok := v.(string); !ok {
+					return invalid(k, "Value of %s must be list of strings.", k)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateDriveSize checks size of drive with given storage type against the API schema
+func validateDriveSize(size uint64, storageType string) error {
+	if size == 0 {
+		return invalid("size", "This field is required.")
+	}
+	limits, ok := caps.Drives[storageType]
+	if !ok {
+		limits = caps.Drives[defaultStorageType]
+	}
+	return checkRange("size", size, capsRange{limits.Min, limits.Max})
+}
+
+// validateFirewallPolicy checks firewall policy object of create or update
+// request against the API schema
+func validateFirewallPolicy(p *data.FirewallPolicy) error {
+	if p.Name == "" {
+		return invalid("name", "This field is required.")
+	}
+	for _, r := range p.Rules {
+		if err := checkEnum("direction", r.Direction, "in", "out", "both"); err != nil {
+			return err
+		}
+		if err := checkEnum("action", r.Action, "accept", "drop"); err != nil {
+			return err
+		}
+		if err := checkEnum("ip_proto", r.IPProto, "", "tcp", "udp"); err != nil {
+			return err
+		}
+		if (r.SrcPort != "" || r.DstPort != "") && r.IPProto == "" {
+			return invalid("ip_proto", "Protocol is required for rules with ports.")
+		}
+	}
+	return nil
+}
+
+// validateKeyPair checks SSH key pair object of create request against the API
+// schema, empty public key requests generation of new key pair
+func validateKeyPair(k *data.KeyPair) error {
+	if k.Name == "" {
+		return invalid("name", "This field is required.")
+	}
+	if k.PublicKey == "" {
+		return nil
+	}
+	if _, err := sshkey.Fingerprint(k.PublicKey); err != nil {
+		return invalid("public_key", "Invalid public key.")
+	}
+	return nil
+}
+
+// validateACL checks access control list object of create or update request
+// against the API schema
+func validateACL(a *data.ACL) error {
+	if a.Name == "" {
+		return invalid("name", "This field is required.")
+	}
+	for _, r := range a.Rules {
+		if err := checkEnum("permission", r.Permission,
+			"LIST", "ATTACH", "EDIT", "OPEN_VNC", "START", "STOP", "CLONE"); err != nil {
+			return err
+		}
+		if r.User == nil || r.User.UUID == "" {
+			return invalid("user", "This field is required.")
+		}
+	}
+	return nil
+}
+
+// validateSubscription checks subscription object of create request against
+// the API schema. IP addresses and VLANs are subscribed one by one.
+func validateSubscription(s *data.Subscription) error {
+	if s.Resource == "" {
+		return invalid("resource", "This field is required.")
+	}
+	amount, err := strconv.ParseUint(string(s.Amount), 10, 64)
+	if err != nil || amount == 0 {
+		return invalid("amount", "Enter a positive whole number.")
+	}
+	if (s.Resource == "ip" || s.Resource == "vlan") && amount != 1 {
+		return invalid("amount", "Only one %s can be subscribed at once.", s.Resource)
+	}
+	if s.Period != "" {
+		if _, err := addPeriod(time.Time{}, s.Period); err != nil {
+			return invalid("period", "Invalid period %q.", s.Period)
+		}
+	}
+	return nil
+}
+
+var reLocation = regexp.MustCompile(`^[a-z]{3}$`)
+
+// validateRemoteSnapshot checks remote snapshot object of create request against the API schema
+func validateRemoteSnapshot(s *data.RemoteSnapshot) error {
+	if s.Drive == nil || s.Drive.UUID == "" {
+		return invalid("drive", "This field is required.")
+	}
+	if s.Location == "" {
+		return invalid("location", "This field is required.")
+	}
+	if !reLocation.MatchString(s.Location) {
+		return invalid("location", "Value of location must be location code, got %q", s.Location)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/https"
+)
+
+func TestSchemaValidateServer(t *testing.T) {
+	valid := func() *data.Server {
+		return &data.Server{
+			Name:        "test",
+			CPU:         2000,
+			Mem:         536870912,
+			VNCPassword: "test",
+		}
+	}
+
+	if err := validateServer(valid()); err != nil {
+		t.Errorf("valid server: %v", err)
+	}
+
+	check := func(point string, modify func(s *data.Server)) {
+		s := valid()
+		modify(s)
+		err := validateServer(s)
+		if ve, ok := err.(validationError); !ok || ve.point != point {
+			t.Errorf("%s: invalid error %#v", point, err)
+		}
+	}
+
+	check("name", func(s *data.Server) { s.Name = "" })
+	check("name", func(s *data.Server) { s.Name = "  " })
+	check("meta", func(s *data.Server) { s.Meta = map[string]string{"": "value"} })
+	check("cpu", func(s *data.Server) { s.CPU = 0 })
+	check("cpu", func(s *data.Server) { s.CPU = 1 })
+	check("mem", func(s *data.Server) { s.Mem = 1 << 50 })
+	check("smp", func(s *data.Server) { s.SMP = 1000 })
+	check("vnc_password", func(s *data.Server) { s.VNCPassword = "" })
+	check("dev_channel", func(s *data.Server) {
+		s.Drives = []data.ServerDrive{{Channel: "0", Device: "virtio", Drive: *data.MakeDriveResource("uuid")}}
+	})
+	check("device", func(s *data.Server) {
+		s.Drives = []data.ServerDrive{{Channel: "0:0", Device: "scsi", Drive: *data.MakeDriveResource("uuid")}}
+	})
+	check("nics", func(s *data.Server) { s.NICs = []data.NIC{{Model: "virtio"}} })
+	check("model", func(s *data.Server) {
+		s.NICs = []data.NIC{{Model: "rtl8139", IPv4: &data.IPv4{Conf: "dhcp"}}}
+	})
+	check("conf", func(s *data.Server) {
+		s.NICs = []data.NIC{{Model: "virtio", IPv4: &data.IPv4{Conf: "auto"}}}
+	})
+	check("ip", func(s *data.Server) {
+		s.NICs = []data.NIC{{Model: "virtio", IPv4: &data.IPv4{Conf: "static"}}}
+	})
+}
+
+func TestSchemaValidateServerUpdate(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	uuid := srv.AddServers([]data.Server{{Name: "test"}})[0]
+
+	client := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+	update := func(body string) *https.Response {
+		resp, err := client.Put(srv.Endpoint("servers/"+uuid+"/"), nil, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	for _, tc := range []struct{ body, point string }{
+		{`{"name": " "}`, "name"},
+		{`{"meta": {"": "value"}}`, "meta"},
+	} {
+		resp := update(tc.body)
+		ee, err := data.ReadError(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != 400 || err != nil || len(ee) != 1 || ee[0].Point != tc.point {
+			t.Errorf("%s: code %d, error %v, %#v", tc.body, resp.StatusCode, err, ee)
+		}
+	}
+
+	srv.syncServers.Lock()
+	if s := srv.servers[uuid]; s.Name != "test" || len(s.Meta) != 0 {
+		t.Errorf("invalid update must not change server: %#v", s)
+	}
+	srv.syncServers.Unlock()
+
+	resp := update(`{"name": "renamed", "meta": {"key": "value"}}`)
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Errorf("valid update, code %d", resp.StatusCode)
+	}
+}
+
+func TestSchemaValidateClone(t *testing.T) {
+	if err := validateCloneParams(map[string]interface{}{
+		"name": "test", "media": "cdrom", "affinities": []interface{}{"ssd"},
+	}); err != nil {
+		t.Errorf("valid params: %v", err)
+	}
+
+	for k, v := range map[string]interface{}{
+		"name":       1,
+		"media":      "ssd",
+		"affinities": "ssd",
+	} {
+		err := validateCloneParams(map[string]interface{}{k: v})
+		if ve, ok := err.(validationError); !ok || ve.point != k {
+			t.Errorf("%s: invalid error %#v", k, err)
+		}
+	}
+}
+
+func TestSchemaValidateDriveSize(t *testing.T) {
+	if err := validateDriveSize(1073741824, ""); err != nil {
+		t.Errorf("valid size: %v", err)
+	}
+	for _, size := range []uint64{0, 1, 1 << 60} {
+		if err := validateDriveSize(size, "dssd"); err == nil {
+			t.Errorf("size %d must be invalid", size)
+		}
+	}
+}
+
+func TestSchemaErrorResponse(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	client := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+	resp, err := client.Post(srv.Endpoint("servers/"), nil, strings.NewReader(`{"name": "test"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if err := resp.VerifyJSON(400); err != nil {
+		t.Fatal(err)
+	}
+
+	ee, err := data.ReadError(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ee) != 1 || ee[0].Type != "validation" || ee[0].Point != "cpu" {
+		t.Errorf("invalid error %#v", ee)
+	}
+
+	if ss := srv.Snapshot().Servers; len(ss) != 0 {
+		t.Errorf("invalid server must not be created: %v", ss)
+	}
+}
@@ -11,15 +11,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
-	"sync"
-	"time"
 
 	"github.com/altoros/gosigma/data"
 )
 
-var syncServers sync.Mutex
-var servers = make(map[string]*data.Server)
-
 // GenerateUUID generated new UUID for server
 func GenerateUUID() (string, error) {
 	uuid := make([]byte, 16)
@@ -55,65 +50,135 @@ func initServer(s *data.Server) (*data.Server, error) {
 }
 
 // AddServer adds server instance record under the mock
-func AddServer(s *data.Server) error {
+func (srv *Server) AddServer(s *data.Server) error {
 	s, err := initServer(s)
 	if err != nil {
 		return err
 	}
 
-	syncServers.Lock()
-	defer syncServers.Unlock()
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
 
-	servers[s.UUID] = s
+	srv.servers[s.UUID] = s
+	srv.mountDrives(s)
 
 	return nil
 }
 
 // AddServers adds server instance records under the mock
-func AddServers(ss []data.Server) []string {
-	syncServers.Lock()
-	defer syncServers.Unlock()
+func (srv *Server) AddServers(ss []data.Server) []string {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
 
 	var result []string
 	for _, s := range ss {
-		s, err := initServer(&s)
+		s := s
+		ps, err := initServer(&s)
 		if err != nil {
-			servers[s.UUID] = s
-			result = append(result, s.UUID)
+			continue
 		}
+		srv.servers[ps.UUID] = ps
+		srv.mountDrives(ps)
+		result = append(result, ps.UUID)
 	}
 	return result
 }
 
 // RemoveServer removes server instance record from the mock
-func RemoveServer(uuid string) bool {
-	syncServers.Lock()
-	defer syncServers.Unlock()
+func (srv *Server) RemoveServer(uuid string) bool {
+	return srv.RemoveServerRecurse(uuid, "")
+}
 
-	_, ok := servers[uuid]
-	delete(servers, uuid)
+// RemoveServerRecurse removes server instance record from the mock with its
+// drives, according to recurse specification: "all_drives", "disks", "cdroms"
+// or empty string to leave all drives.
+func (srv *Server) RemoveServerRecurse(uuid, recurse string) bool {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
 
-	return ok
+	s, ok := srv.servers[uuid]
+	if !ok {
+		return false
+	}
+
+	delete(srv.servers, uuid)
+	delete(srv.ignoreShutdown, uuid)
+	delete(srv.hosts, uuid)
+
+	srv.unmountDrives(s)
+	srv.removeDrives(s, recurse)
+
+	return true
 }
 
 // ResetServers removes all server instance records from the mock
-func ResetServers() {
-	syncServers.Lock()
-	defer syncServers.Unlock()
-	servers = make(map[string]*data.Server)
+func (srv *Server) ResetServers() {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
+	for _, s := range srv.servers {
+		srv.unmountDrives(s)
+	}
+	srv.servers = make(map[string]*data.Server)
+	srv.ignoreShutdown = make(map[string]bool)
+	srv.hosts = make(map[string]int)
 }
 
 // SetServerStatus changes status of server instance in the mock
-func SetServerStatus(uuid, status string) {
-	syncServers.Lock()
-	defer syncServers.Unlock()
+func (srv *Server) SetServerStatus(uuid, status string) {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
 
-	s, ok := servers[uuid]
+	s, ok := srv.servers[uuid]
 	if ok {
 		s.Status = status
 	}
 }
 
+// IgnoreShutdown makes guest of server instance in the mock to ignore (or honour)
+// ACPI shutdown signal. Server, ignoring shutdown, stays in "stopping" state
+// until it is stopped.
+func (srv *Server) IgnoreShutdown(uuid string, ignore bool) {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
+
+	if ignore {
+		srv.ignoreShutdown[uuid] = true
+	} else {
+		delete(srv.ignoreShutdown, uuid)
+	}
+}
+
+// AddServer adds server instance record under the default mock
+func AddServer(s *data.Server) error {
+	return defaultServer.AddServer(s)
+}
+
+// AddServers adds server instance records under the default mock
+func AddServers(ss []data.Server) []string {
+	return defaultServer.AddServers(ss)
+}
+
+// RemoveServer removes server instance record from the default mock
+func RemoveServer(uuid string) bool {
+	return defaultServer.RemoveServer(uuid)
+}
+
+// ResetServers removes all server instance records from the default mock
+func ResetServers() {
+	defaultServer.ResetServers()
+}
+
+// SetServerStatus changes status of server instance in the default mock
+func SetServerStatus(uuid, status string) {
+	defaultServer.SetServerStatus(uuid, status)
+}
+
+// IgnoreShutdown makes guest of server instance in the default mock to ignore
+// (or honour) ACPI shutdown signal.
+func IgnoreShutdown(uuid string, ignore bool) {
+	defaultServer.IgnoreShutdown(uuid, ignore)
+}
+
 const jsonNotFound = `[{
 		"error_point": null,
 	 	"error_type": "notexist",
@@ -132,6 +197,18 @@ const jsonStopFailed = `[{
 		"error_message": "Cannot stop guest in state \"stopped\". Guest should be in state \"['started', 'running_legacy']\""
 }]`
 
+const jsonShutdownFailed = `[{
+		"error_point": null,
+		"error_type": "permission",
+		"error_message": "Cannot shutdown guest in state \"stopped\". Guest should be in state \"['started', 'running_legacy']\""
+}]`
+
+const jsonRestartFailed = `[{
+		"error_point": null,
+		"error_type": "permission",
+		"error_message": "Cannot restart guest in state \"stopped\". Guest should be in state \"['started', 'running_legacy']\""
+}]`
+
 const jsonActionSuccess = `{
 		"action": "%s",
 		"result": "success",
@@ -142,40 +219,95 @@ const jsonActionSuccess = `{
 // /api/2.0/servers
 // /api/2.0/servers/detail/
 // /api/2.0/servers/{uuid}/
-func serversHandler(w http.ResponseWriter, r *http.Request) {
+func (srv *Server) serversHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
-		serversHandlerGet(w, r)
+		srv.serversHandlerGet(w, r)
 	case "POST":
-		serversHandlerPost(w, r)
+		srv.serversHandlerPost(w, r)
+	case "PUT":
+		srv.serversHandlerPut(w, r)
 	case "DELETE":
-		serversHandlerDelete(w, r)
+		srv.serversHandlerDelete(w, r)
 	}
 }
 
-func serversHandlerGet(w http.ResponseWriter, r *http.Request) {
+func (srv *Server) serversHandlerGet(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimSuffix(r.URL.Path, "/")
 	switch path {
 	case "/api/2.0/servers":
-		handleServers(w, r)
+		srv.handleServers(w, r)
 	case "/api/2.0/servers/detail":
-		handleServersDetail(w, r, 200, nil)
+		srv.handleServersDetail(w, r, 200, nil)
 	default:
 		uuid := strings.TrimPrefix(path, "/api/2.0/servers/")
-		handleServer(w, r, 200, uuid)
+		srv.handleServer(w, r, 200, uuid)
 	}
 }
 
-func serversHandlerPost(w http.ResponseWriter, r *http.Request) {
+func (srv *Server) serversHandlerPost(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimSuffix(r.URL.Path, "/action/")
 	uuid := strings.TrimPrefix(path, "/api/2.0/servers/")
-	handleServerAction(w, r, uuid)
+	srv.handleServerAction(w, r, uuid)
 }
 
-func serversHandlerDelete(w http.ResponseWriter, r *http.Request) {
+// serversHandlerPut updates name, meta-information and access control lists of
+// the server instance
+func (srv *Server) serversHandlerPut(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimSuffix(r.URL.Path, "/")
 	uuid := strings.TrimPrefix(path, "/api/2.0/servers/")
-	if RemoveServer(uuid) {
+
+	s, err := data.ReadServer(r.Body)
+	if err != nil {
+		w.WriteHeader(400)
+		return
+	}
+
+	if err := validateServerUpdate(s); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	if err := srv.validateACLs(s.ACLs); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	srv.syncServers.Lock()
+	current, ok := srv.servers[uuid]
+	if ok {
+		if s.Name != "" {
+			current.Name = s.Name
+		}
+		if s.Meta != nil {
+			current.Meta = s.Meta
+		}
+		if s.ACLs != nil {
+			current.ACLs = s.ACLs
+		}
+	}
+	srv.syncServers.Unlock()
+
+	if !ok {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	srv.handleServer(w, r, 200, uuid)
+}
+
+func (srv *Server) serversHandlerDelete(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	uuid := strings.TrimPrefix(path, "/api/2.0/servers/")
+	recurse := r.URL.Query().Get("recurse")
+	if err := checkEnum("recurse", recurse, "", "all_drives", "disks", "cdroms"); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	if srv.RemoveServerRecurse(uuid, recurse) {
 		w.WriteHeader(204)
 	} else {
 		h := w.Header()
@@ -185,19 +317,19 @@ func serversHandlerDelete(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func handleServers(w http.ResponseWriter, r *http.Request) {
-	syncServers.Lock()
-	defer syncServers.Unlock()
+func (srv *Server) handleServers(w http.ResponseWriter, r *http.Request) {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
 
 	var ss data.Servers
-	ss.Meta.TotalCount = len(servers)
-	ss.Objects = make([]data.Server, 0, len(servers))
-	for _, s := range servers {
-		var srv data.Server
-		srv.Resource = s.Resource
-		srv.Name = s.Name
-		srv.Status = s.Status
-		ss.Objects = append(ss.Objects, srv)
+	ss.Meta.TotalCount = len(srv.servers)
+	ss.Objects = make([]data.Server, 0, len(srv.servers))
+	for _, s := range srv.servers {
+		var s0 data.Server
+		s0.Resource = s.Resource
+		s0.Name = s.Name
+		s0.Status = s.Status
+		ss.Objects = append(ss.Objects, s0)
 	}
 
 	data, err := json.Marshal(&ss)
@@ -212,23 +344,23 @@ func handleServers(w http.ResponseWriter, r *http.Request) {
 	w.Write(data)
 }
 
-func handleServersDetail(w http.ResponseWriter, r *http.Request, okcode int, filter []string) {
-	syncServers.Lock()
-	defer syncServers.Unlock()
+func (srv *Server) handleServersDetail(w http.ResponseWriter, r *http.Request, okcode int, filter []string) {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
 
 	var ss data.Servers
 
 	if len(filter) == 0 {
-		ss.Meta.TotalCount = len(servers)
-		ss.Objects = make([]data.Server, 0, len(servers))
-		for _, s := range servers {
+		ss.Meta.TotalCount = len(srv.servers)
+		ss.Objects = make([]data.Server, 0, len(srv.servers))
+		for _, s := range srv.servers {
 			ss.Objects = append(ss.Objects, *s)
 		}
 	} else {
 		ss.Meta.TotalCount = len(filter)
 		ss.Objects = make([]data.Server, 0, len(filter))
 		for _, uuid := range filter {
-			if s, ok := servers[uuid]; ok {
+			if s, ok := srv.servers[uuid]; ok {
 				ss.Objects = append(ss.Objects, *s)
 			}
 		}
@@ -247,13 +379,13 @@ func handleServersDetail(w http.ResponseWriter, r *http.Request, okcode int, fil
 	w.Write(data)
 }
 
-func handleServer(w http.ResponseWriter, r *http.Request, okcode int, uuid string) {
-	syncServers.Lock()
-	defer syncServers.Unlock()
+func (srv *Server) handleServer(w http.ResponseWriter, r *http.Request, okcode int, uuid string) {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
 
 	h := w.Header()
 
-	s, ok := servers[uuid]
+	s, ok := srv.servers[uuid]
 	if !ok {
 		h.Set("Content-Type", "application/json; charset=utf-8")
 		w.WriteHeader(404)
@@ -273,34 +405,38 @@ func handleServer(w http.ResponseWriter, r *http.Request, okcode int, uuid strin
 	w.Write(data)
 }
 
-func handleServerAction(w http.ResponseWriter, r *http.Request, uuid string) {
+func (srv *Server) handleServerAction(w http.ResponseWriter, r *http.Request, uuid string) {
 	vv := r.URL.Query()
 
 	v, ok := vv["do"]
 	if !ok || len(v) < 1 {
-		handleServerCreate(w, r)
+		srv.handleServerCreate(w, r)
 		return
 	}
 
 	action := v[0]
 	switch action {
 	case "start":
-		handleServerStart(w, r, uuid)
+		srv.handleServerStart(w, r, uuid)
 	case "stop":
-		handleServerStop(w, r, uuid)
+		srv.handleServerStop(w, r, uuid)
+	case "shutdown":
+		srv.handleServerShutdown(w, r, uuid)
+	case "restart":
+		srv.handleServerRestart(w, r, uuid)
 	default:
-		handleServerCreate(w, r)
+		srv.handleServerCreate(w, r)
 	}
 }
 
-func handleServerStart(w http.ResponseWriter, r *http.Request, uuid string) {
-	syncServers.Lock()
-	defer syncServers.Unlock()
+func (srv *Server) handleServerStart(w http.ResponseWriter, r *http.Request, uuid string) {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
 
 	h := w.Header()
 	h.Set("Content-Type", "application/json; charset=utf-8")
 
-	s, ok := servers[uuid]
+	s, ok := srv.servers[uuid]
 	if !ok {
 		w.WriteHeader(404)
 		w.Write([]byte(jsonNotFound))
@@ -313,84 +449,238 @@ func handleServerStart(w http.ResponseWriter, r *http.Request, uuid string) {
 		return
 	}
 
+	if err := srv.checkDrivesReady(s); err != nil {
+		w.WriteHeader(403)
+		w.Write([]byte(fmt.Sprintf(jsonStartDriveFailed, err.Error())))
+		return
+	}
+
+	srv.placeServer(uuid, parseAvoid(r))
+
 	s.Status = "starting"
-	go func() {
-		syncServers.Lock()
-		defer syncServers.Unlock()
-		<-time.After(300 * time.Millisecond)
-		s.Status = "running"
-		for i, n := range s.NICs {
-			if n.IPv4 != nil && n.IPv4.Conf == "dhcp" {
-				s.NICs[i].Runtime = &data.RuntimeNetwork{
-					InterfaceType: "public",
-					IPv4:          data.MakeIPResource("0.1.2.3"),
-				}
-			}
-		}
-	}()
+	srv.completeStart(s)
 
 	w.WriteHeader(202)
 	w.Write([]byte(fmt.Sprintf(string(jsonActionSuccess), "start", s.UUID)))
 }
 
-func handleServerStop(w http.ResponseWriter, r *http.Request, uuid string) {
-	syncServers.Lock()
-	defer syncServers.Unlock()
+// completeStart makes starting server running after start transition time,
+// must be called with syncServers locked
+func (srv *Server) completeStart(s *data.Server) {
+	srv.clock.AfterFunc(srv.transitions.Start, func() {
+		srv.syncServers.Lock()
+		defer srv.syncServers.Unlock()
+		if s.Status == "starting" {
+			setServerRunning(s)
+		}
+	})
+}
+
+// completeStop makes stopping server stopped after stop transition time,
+// must be called with syncServers locked
+func (srv *Server) completeStop(s *data.Server) {
+	srv.clock.AfterFunc(srv.transitions.Stop, func() {
+		srv.syncServers.Lock()
+		defer srv.syncServers.Unlock()
+		if s.Status == "stopping" {
+			setServerStopped(s)
+		}
+	})
+}
+
+// resumeTransitions completes transitions of servers loaded in starting or
+// stopping state. Stopping server, which guest ignores shutdown, stays in the
+// state until stopped.
+func (srv *Server) resumeTransitions() {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
+
+	for uuid, s := range srv.servers {
+		switch {
+		case s.Status == "starting":
+			srv.completeStart(s)
+		case s.Status == "stopping" && !srv.ignoreShutdown[uuid]:
+			srv.completeStop(s)
+		}
+	}
+}
+
+func setServerRunning(s *data.Server) {
+	s.Status = "running"
+	for i, n := range s.NICs {
+		if n.IPv4 != nil && n.IPv4.Conf == "dhcp" {
+			s.NICs[i].Runtime = &data.RuntimeNetwork{
+				InterfaceType: "public",
+				IPv4:          data.MakeIPResource("0.1.2.3"),
+			}
+		}
+	}
+}
+
+func setServerStopped(s *data.Server) {
+	s.Status = "stopped"
+	for i := range s.NICs {
+		s.NICs[i].Runtime = nil
+	}
+}
+
+func (srv *Server) handleServerStop(w http.ResponseWriter, r *http.Request, uuid string) {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
 
 	h := w.Header()
 	h.Set("Content-Type", "application/json; charset=utf-8")
 
-	s, ok := servers[uuid]
+	s, ok := srv.servers[uuid]
 	if !ok {
 		w.WriteHeader(404)
 		w.Write([]byte(jsonNotFound))
 		return
 	}
 
-	if !strings.HasPrefix(s.Status, "running") {
+	if !strings.HasPrefix(s.Status, "running") && s.Status != "stopping" {
 		w.WriteHeader(403)
 		w.Write([]byte(jsonStopFailed))
 		return
 	}
 
 	s.Status = "stopping"
-	go func() {
-		syncServers.Lock()
-		defer syncServers.Unlock()
-		<-time.After(300 * time.Millisecond)
-		s.Status = "stopped"
-		for i := range s.NICs {
-			s.NICs[i].Runtime = nil
-		}
-	}()
+	srv.completeStop(s)
 
 	w.WriteHeader(202)
 	w.Write([]byte(fmt.Sprintf(jsonActionSuccess, "stop", s.UUID)))
 }
 
-func handleServerCreate(w http.ResponseWriter, r *http.Request) {
+func (srv *Server) handleServerShutdown(w http.ResponseWriter, r *http.Request, uuid string) {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json; charset=utf-8")
+
+	s, ok := srv.servers[uuid]
+	if !ok {
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	if !strings.HasPrefix(s.Status, "running") {
+		w.WriteHeader(403)
+		w.Write([]byte(jsonShutdownFailed))
+		return
+	}
+
+	// guest ignoring ACPI signal stays in "stopping" state until stopped
+	s.Status = "stopping"
+	if !srv.ignoreShutdown[uuid] {
+		srv.completeStop(s)
+	}
+
+	w.WriteHeader(202)
+	w.Write([]byte(fmt.Sprintf(jsonActionSuccess, "shutdown", s.UUID)))
+}
+
+func (srv *Server) handleServerRestart(w http.ResponseWriter, r *http.Request, uuid string) {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json; charset=utf-8")
+
+	s, ok := srv.servers[uuid]
+	if !ok {
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	if !strings.HasPrefix(s.Status, "running") {
+		w.WriteHeader(403)
+		w.Write([]byte(jsonRestartFailed))
+		return
+	}
+
+	setServerStopped(s)
+	s.Status = "starting"
+	srv.completeStart(s)
+
+	w.WriteHeader(202)
+	w.Write([]byte(fmt.Sprintf(jsonActionSuccess, "restart", s.UUID)))
+}
+
+func (srv *Server) handleServerCreate(w http.ResponseWriter, r *http.Request) {
 	bb, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		w.WriteHeader(400)
 		return
 	}
 
-	s, err := data.ReadServer(bytes.NewReader(bb))
-	if err == nil {
-		if err = AddServer(s); err != nil {
+	ss, err := data.ReadServers(bytes.NewReader(bb))
+	if err != nil || len(ss) == 0 {
+		s, err := data.ReadServer(bytes.NewReader(bb))
+		if err != nil {
 			w.WriteHeader(400)
-		} else {
-			handleServersDetail(w, r, 201, []string{s.UUID})
+			return
 		}
-		return
+		ss = []data.Server{*s}
 	}
 
-	ss, err := data.ReadServers(bytes.NewReader(bb))
-	if err == nil {
-		uuids := AddServers(ss)
-		handleServersDetail(w, r, 201, uuids)
+	for i := range ss {
+		if err := validateServer(&ss[i]); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+	}
+
+	uuids, err := srv.createServers(ss)
+	if _, ok := err.(quotaError); ok {
+		writeQuotaError(w, err)
+		return
+	} else if err != nil {
+		writeValidationError(w, err)
 		return
 	}
 
-	w.WriteHeader(400)
+	srv.handleServersDetail(w, r, 201, uuids)
+}
+
+// createServers validates drives of new server instances and adds them under the mock
+func (srv *Server) createServers(ss []data.Server) ([]string, error) {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
+
+	claimed := make(map[string]bool)
+	for i := range ss {
+		if err := srv.validateDrives(&ss[i], claimed); err != nil {
+			return nil, err
+		}
+		if err := srv.validateFirewallPolicies(&ss[i]); err != nil {
+			return nil, err
+		}
+		if err := srv.validateKeyPairs(&ss[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var mem uint64
+	for _, s := range ss {
+		mem += s.Mem
+	}
+	if err := srv.checkServerQuota(len(ss), mem); err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, s := range ss {
+		s := s
+		ps, err := initServer(&s)
+		if err != nil {
+			return nil, err
+		}
+		srv.servers[ps.UUID] = ps
+		srv.mountDrives(ps)
+		result = append(result, ps.UUID)
+	}
+	return result, nil
 }
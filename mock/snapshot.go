@@ -0,0 +1,171 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/altoros/gosigma/data"
+)
+
+// ErrNoCheckpoint returned by Rollback for unknown checkpoint name
+var ErrNoCheckpoint = errors.New("checkpoint not found")
+
+type checkpoints struct {
+	s sync.Mutex
+	m map[string]*Fixture
+}
+
+// Snapshot returns copy of full state of the server: servers, drives,
+// library drives, jobs, firewall policies, key pairs, access control lists,
+// subscriptions, remote snapshots, balance and ledger. Fault injection rules,
+// journal, drive uploads and placement of servers and drives into failure
+// domains are not included.
+func (srv *Server) Snapshot() *Fixture {
+	srv.syncServers.Lock()
+	defer srv.syncServers.Unlock()
+
+	var f Fixture
+	for _, s := range srv.servers {
+		f.Servers = append(f.Servers, *s)
+	}
+	sort.Slice(f.Servers, func(i, j int) bool { return f.Servers[i].UUID < f.Servers[j].UUID })
+	for uuid := range srv.ignoreShutdown {
+		f.IgnoreShutdown = append(f.IgnoreShutdown, uuid)
+	}
+	sort.Strings(f.IgnoreShutdown)
+
+	f.Drives = srv.Drives.snapshot()
+	f.LibDrives = srv.LibDrives.snapshot()
+	f.Jobs = srv.Jobs.snapshot()
+	f.FirewallPolicies = srv.FirewallPolicies.snapshot()
+	f.KeyPairs = srv.KeyPairs.snapshot()
+	f.ACLs = srv.ACLs.snapshot()
+	f.Subscriptions = srv.Subscriptions.snapshot()
+	f.RemoteSnapshots = srv.RemoteSnapshots.snapshot()
+	b := srv.Balance()
+	f.Balance = &b
+	f.Ledger = srv.ledgerSnapshot()
+
+	return f.clone()
+}
+
+// Restore replaces full state of the server with the snapshot. The snapshot is
+// checked first, so state is kept if the snapshot fails to load. Servers in
+// starting or stopping state complete their transitions.
+func (srv *Server) Restore(f *Fixture) error {
+	f = f.clone()
+
+	// scratch server with clock never advancing does not run transitions
+	scratch := newServer(WithClock(NewManualClock(srv.clock.Now())))
+	if err := scratch.Load(f.clone()); err != nil {
+		return err
+	}
+
+	srv.ResetServers()
+	srv.Drives.Reset()
+	srv.LibDrives.Reset()
+	srv.Jobs.Reset()
+	srv.FirewallPolicies.Reset()
+	srv.KeyPairs.Reset()
+	srv.ACLs.Reset()
+	srv.Subscriptions.Reset()
+	srv.RemoteSnapshots.Reset()
+	srv.ResetLedger()
+	return srv.Load(f)
+}
+
+// WriteSnapshot writes full state of the server to the stream in JSON format,
+// the state can be restored with ReadFixture and Restore
+func (srv *Server) WriteSnapshot(w io.Writer) error {
+	return json.NewEncoder(w).Encode(srv.Snapshot())
+}
+
+// Checkpoint saves full state of the server under given name
+func (srv *Server) Checkpoint(name string) {
+	f := srv.Snapshot()
+
+	srv.checkpoints.s.Lock()
+	defer srv.checkpoints.s.Unlock()
+
+	if srv.checkpoints.m == nil {
+		srv.checkpoints.m = make(map[string]*Fixture)
+	}
+	srv.checkpoints.m[name] = f
+}
+
+// Rollback restores state of the server saved under given name with Checkpoint.
+// Checkpoint is kept and can be rolled back to again.
+func (srv *Server) Rollback(name string) error {
+	srv.checkpoints.s.Lock()
+	f, ok := srv.checkpoints.m[name]
+	srv.checkpoints.s.Unlock()
+
+	if !ok {
+		return ErrNoCheckpoint
+	}
+
+	return srv.Restore(f)
+}
+
+// clone makes deep copy of the fixture
+func (f *Fixture) clone() *Fixture {
+	bb, err := json.Marshal(f)
+	if err != nil {
+		panic(err)
+	}
+	var result Fixture
+	if err := json.Unmarshal(bb, &result); err != nil {
+		panic(err)
+	}
+	return &result
+}
+
+func (d *DriveLibrary) snapshot() []data.Drive {
+	d.s.Lock()
+	defer d.s.Unlock()
+
+	var result []data.Drive
+	for _, drv := range d.m {
+		result = append(result, *drv)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].UUID < result[j].UUID })
+	return result
+}
+
+func (j *JobLibrary) snapshot() []data.Job {
+	j.s.Lock()
+	defer j.s.Unlock()
+
+	var result []data.Job
+	for _, job := range j.m {
+		result = append(result, *job)
+	}
+	sort.Slice(result, func(a, b int) bool { return result[a].UUID < result[b].UUID })
+	return result
+}
+
+// Snapshot returns copy of full state of the default mock
+func Snapshot() *Fixture {
+	return defaultServer.Snapshot()
+}
+
+// Restore replaces full state of the default mock with the snapshot
+func Restore(f *Fixture) error {
+	return defaultServer.Restore(f)
+}
+
+// Checkpoint saves full state of the default mock under given name
+func Checkpoint(name string) {
+	defaultServer.Checkpoint(name)
+}
+
+// Rollback restores state of the default mock saved under given name with Checkpoint
+func Rollback(name string) error {
+	return defaultServer.Rollback(name)
+}
@@ -0,0 +1,151 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+)
+
+func seedServer() *Server {
+	srv := newServer()
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("d1"), Media: "disk"})
+	srv.LibDrives.Add(&data.Drive{Resource: *data.MakeLibDriveResource("l1")})
+	srv.Jobs.Add(&data.Job{Resource: *data.MakeJobResource("j1")})
+	srv.AddServer(&data.Server{
+		Resource: *data.MakeServerResource("s1"),
+		Meta:     map[string]string{"key": "value"},
+		Drives: []data.ServerDrive{
+			{Channel: "0:0", Device: "virtio", Drive: *data.MakeDriveResource("d1")},
+		},
+	})
+	srv.IgnoreShutdown("s1", true)
+	return srv
+}
+
+func checkSeeded(t *testing.T, srv *Server) {
+	s := srv.servers["s1"]
+	if len(srv.servers) != 1 || s == nil || s.Meta["key"] != "value" {
+		t.Errorf("invalid servers: %v", srv.servers)
+	}
+	if !srv.ignoreShutdown["s1"] {
+		t.Error("ignore shutdown must be restored")
+	}
+	d := srv.Drives.m["d1"]
+	if len(srv.Drives.m) != 1 || d == nil || len(d.MountedOn) != 1 || d.MountedOn[0].UUID != "s1" {
+		t.Errorf("invalid drives: %v", srv.Drives.m)
+	}
+	if len(srv.LibDrives.m) != 1 || srv.LibDrives.m["l1"] == nil {
+		t.Errorf("invalid libdrives: %v", srv.LibDrives.m)
+	}
+	if len(srv.Jobs.m) != 1 || srv.Jobs.m["j1"] == nil {
+		t.Errorf("invalid jobs: %v", srv.Jobs.m)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	srv := seedServer()
+
+	var buf bytes.Buffer
+	if err := srv.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := ReadFixture(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newServer()
+	restored.AddServer(&data.Server{Resource: *data.MakeServerResource("other")})
+	if err := restored.Restore(f); err != nil {
+		t.Fatal(err)
+	}
+
+	checkSeeded(t, restored)
+}
+
+func TestSnapshotIsCopy(t *testing.T) {
+	srv := seedServer()
+
+	f := srv.Snapshot()
+	f.Servers[0].Meta["key"] = "changed"
+	srv.servers["s1"].Name = "changed"
+
+	if srv.servers["s1"].Meta["key"] != "value" {
+		t.Error("snapshot must not share state with the server")
+	}
+	if f.Servers[0].Name != "" {
+		t.Error("snapshot must not share state with the server")
+	}
+}
+
+func TestCheckpointRollback(t *testing.T) {
+	srv := seedServer()
+	srv.Checkpoint("seeded")
+
+	for i := 0; i < 2; i++ {
+		srv.RemoveServerRecurse("s1", "all_drives")
+		srv.Jobs.SetState("j1", "success")
+		srv.AddServer(&data.Server{Resource: *data.MakeServerResource("s2")})
+
+		if err := srv.Rollback("seeded"); err != nil {
+			t.Fatal(err)
+		}
+
+		checkSeeded(t, srv)
+		if state := srv.Jobs.m["j1"].State; state != "started" {
+			t.Errorf("job state %q, wants started", state)
+		}
+	}
+
+	if err := srv.Rollback("unknown"); err != ErrNoCheckpoint {
+		t.Errorf("Rollback of unknown checkpoint: %v", err)
+	}
+}
+
+func TestRollbackTransitions(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	srv := newServer(WithClock(clock))
+	srv.AddServer(&data.Server{Resource: *data.MakeServerResource("s1"), Status: "starting"})
+	srv.AddServer(&data.Server{Resource: *data.MakeServerResource("s2"), Status: "stopping"})
+	srv.AddServer(&data.Server{Resource: *data.MakeServerResource("s3"), Status: "stopping"})
+	srv.IgnoreShutdown("s3", true)
+	srv.Checkpoint("transitions")
+
+	if err := srv.Rollback("transitions"); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(srv.transitions.Start + srv.transitions.Stop)
+
+	status := func(uuid string) string {
+		srv.syncServers.Lock()
+		defer srv.syncServers.Unlock()
+		return srv.servers[uuid].Status
+	}
+	if v := status("s1"); v != "running" {
+		t.Errorf("restored starting server status %q, wants running", v)
+	}
+	if v := status("s2"); v != "stopped" {
+		t.Errorf("restored stopping server status %q, wants stopped", v)
+	}
+	if v := status("s3"); v != "stopping" {
+		t.Errorf("restored server ignoring shutdown status %q, wants stopping", v)
+	}
+}
+
+func TestRestoreFailureKeepsState(t *testing.T) {
+	srv := seedServer()
+
+	f := srv.Snapshot()
+	f.Subscriptions = append(f.Subscriptions, data.Subscription{ID: "100", Period: "1 century"})
+	if err := srv.Restore(f); err == nil {
+		t.Fatal("Restore of invalid snapshot must fail")
+	}
+
+	checkSeeded(t, srv)
+}
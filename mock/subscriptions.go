@@ -0,0 +1,360 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+)
+
+// SubscriptionLibrary type to store all subscriptions in the mock
+type SubscriptionLibrary struct {
+	s   sync.Mutex
+	m   map[string]*data.Subscription
+	p   string
+	srv *Server
+	seq int
+}
+
+// Subscriptions defines library of all subscriptions in the default mock
+var Subscriptions = defaultServer.Subscriptions
+
+var periodRegexp = regexp.MustCompile(`^(\d+) (day|week|month|year)s?$`)
+
+// addPeriod returns time t moved forward by subscription period in form
+// '{count} {day|week|month|year}[s]'
+func addPeriod(t time.Time, period string) (time.Time, error) {
+	m := periodRegexp.FindStringSubmatch(strings.TrimSpace(period))
+	if m == nil {
+		return t, fmt.Errorf("invalid period %q", period)
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n == 0 {
+		return t, fmt.Errorf("invalid period %q", period)
+	}
+	switch m[2] {
+	case "day":
+		return t.AddDate(0, 0, n), nil
+	case "week":
+		return t.AddDate(0, 0, 7*n), nil
+	case "month":
+		return t.AddDate(0, n, 0), nil
+	default:
+		return t.AddDate(n, 0, 0), nil
+	}
+}
+
+// init initializes the subscription, must be called under library lock.
+// Subscriptions to IP addresses and VLANs get subscribed objects.
+func (sl *SubscriptionLibrary) init(s *data.Subscription) error {
+	sl.seq++
+	if s.ID == "" {
+		s.ID = strconv.Itoa(sl.seq)
+	} else if n, err := strconv.Atoi(s.ID); err == nil && n > sl.seq {
+		sl.seq = n
+	}
+	s.URI = data.MakeSubscriptionURI(s.ID)
+	if s.Period == "" {
+		s.Period = "1 month"
+	}
+	if s.StartTime == nil {
+		now := sl.srv.clock.Now()
+		s.StartTime = &now
+	}
+	if s.EndTime == nil {
+		end, err := addPeriod(*s.StartTime, s.Period)
+		if err != nil {
+			return err
+		}
+		s.EndTime = &end
+	}
+	if s.SubscribedObject == "" {
+		switch s.Resource {
+		case "ip":
+			s.SubscribedObject = fmt.Sprintf("10.%d.%d.%d", sl.seq>>16&0xff, sl.seq>>8&0xff, sl.seq&0xff)
+		case "vlan":
+			uuid, err := GenerateUUID()
+			if err != nil {
+				return err
+			}
+			s.SubscribedObject = uuid
+		}
+	}
+	return nil
+}
+
+// Add subscription to the library
+func (sl *SubscriptionLibrary) Add(s *data.Subscription) error {
+	sl.s.Lock()
+	defer sl.s.Unlock()
+
+	if err := sl.init(s); err != nil {
+		return err
+	}
+	sl.m[s.ID] = s
+
+	return nil
+}
+
+// AddSubscriptions adds subscription collection to the library
+func (sl *SubscriptionLibrary) AddSubscriptions(ss []data.Subscription) []string {
+	sl.s.Lock()
+	defer sl.s.Unlock()
+
+	var result []string
+	for _, s := range ss {
+		s := s
+		if err := sl.init(&s); err != nil {
+			continue
+		}
+		sl.m[s.ID] = &s
+		result = append(result, s.ID)
+	}
+	return result
+}
+
+// Remove subscription from the library
+func (sl *SubscriptionLibrary) Remove(id string) bool {
+	sl.s.Lock()
+	defer sl.s.Unlock()
+
+	_, ok := sl.m[id]
+	delete(sl.m, id)
+
+	return ok
+}
+
+// Reset the library
+func (sl *SubscriptionLibrary) Reset() {
+	sl.s.Lock()
+	defer sl.s.Unlock()
+	sl.m = make(map[string]*data.Subscription)
+	sl.seq = 0
+}
+
+func (sl *SubscriptionLibrary) snapshot() []data.Subscription {
+	sl.s.Lock()
+	defer sl.s.Unlock()
+
+	var result []data.Subscription
+	for _, s := range sl.m {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool { return lessID(result[i].ID, result[j].ID) })
+	return result
+}
+
+// lessID orders sequential IDs numerically and other IDs lexicographically
+func lessID(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// isActive reports whether subscription covers given time
+func isActive(s *data.Subscription, now time.Time) bool {
+	return s.StartTime != nil && s.EndTime != nil && !now.Before(*s.StartTime) && now.Before(*s.EndTime)
+}
+
+// subscribed returns amounts of active subscriptions by resource
+func (sl *SubscriptionLibrary) subscribed() map[string]uint64 {
+	now := sl.srv.clock.Now()
+
+	sl.s.Lock()
+	defer sl.s.Unlock()
+
+	result := make(map[string]uint64)
+	for _, s := range sl.m {
+		if !isActive(s, now) {
+			continue
+		}
+		amount, _ := strconv.ParseUint(string(s.Amount), 10, 64)
+		result[s.Resource] += amount
+	}
+	return result
+}
+
+// viewSubscription returns copy of subscription with status at given time
+func viewSubscription(s *data.Subscription, now time.Time) data.Subscription {
+	v := *s
+	if isActive(s, now) {
+		v.Status = "active"
+	} else {
+		v.Status = "inactive"
+	}
+	return v
+}
+
+// URLs:
+// /api/2.0/subscriptions/
+// /api/2.0/subscriptions/detail/
+// /api/2.0/subscriptions/{id}/
+// /api/2.0/subscriptions/{id}/action/?do=extend
+func (sl *SubscriptionLibrary) handleRequest(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	path = strings.TrimPrefix(path, sl.p)
+	path = strings.TrimPrefix(path, "/")
+
+	switch {
+	case r.Method == "GET" && (path == "" || path == "detail"):
+		sl.handleList(w, r, 200, nil)
+	case r.Method == "GET":
+		sl.handleGet(w, r, 200, path)
+	case r.Method == "POST" && path == "":
+		sl.handleCreate(w, r)
+	case r.Method == "POST" && strings.HasSuffix(path, "/action"):
+		sl.handleAction(w, r, strings.TrimSuffix(path, "/action"))
+	case r.Method == "PUT" && path != "":
+		sl.handleUpdate(w, r, path)
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+func (sl *SubscriptionLibrary) handleList(w http.ResponseWriter, r *http.Request, okcode int, filter []string) {
+	now := sl.srv.clock.Now()
+
+	sl.s.Lock()
+	defer sl.s.Unlock()
+
+	var ss data.Subscriptions
+	if len(filter) == 0 {
+		for id := range sl.m {
+			filter = append(filter, id)
+		}
+		sort.Slice(filter, func(i, j int) bool { return lessID(filter[i], filter[j]) })
+	}
+	ss.Objects = make([]data.Subscription, 0, len(filter))
+	for _, id := range filter {
+		if s, ok := sl.m[id]; ok {
+			ss.Objects = append(ss.Objects, viewSubscription(s, now))
+		}
+	}
+	ss.Meta.TotalCount = len(ss.Objects)
+
+	writeJSON(w, okcode, &ss)
+}
+
+func (sl *SubscriptionLibrary) handleGet(w http.ResponseWriter, r *http.Request, okcode int, id string) {
+	now := sl.srv.clock.Now()
+
+	sl.s.Lock()
+	defer sl.s.Unlock()
+
+	s, ok := sl.m[id]
+	if !ok {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(jsonNotFound))
+		return
+	}
+
+	v := viewSubscription(s, now)
+	writeJSON(w, okcode, &v)
+}
+
+func (sl *SubscriptionLibrary) handleCreate(w http.ResponseWriter, r *http.Request) {
+	bb, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(400)
+		return
+	}
+
+	ss, err := data.ReadSubscriptions(bytes.NewReader(bb))
+	if err != nil || len(ss) == 0 {
+		s, err := data.ReadSubscription(bytes.NewReader(bb))
+		if err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		ss = []data.Subscription{*s}
+	}
+
+	for i := range ss {
+		if err := validateSubscription(&ss[i]); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+		ss[i] = data.Subscription{
+			Amount:    ss[i].Amount,
+			AutoRenew: ss[i].AutoRenew,
+			Period:    ss[i].Period,
+			Resource:  ss[i].Resource,
+		}
+	}
+
+	ids := sl.AddSubscriptions(ss)
+	sl.handleList(w, r, 201, ids)
+}
+
+// handleUpdate changes auto-renewal of subscription, other properties of
+// subscription are read-only
+func (sl *SubscriptionLibrary) handleUpdate(w http.ResponseWriter, r *http.Request, id string) {
+	var params struct {
+		AutoRenew *bool `json:"auto_renew"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		w.WriteHeader(400)
+		return
+	}
+	if params.AutoRenew == nil {
+		writeValidationError(w, invalid("auto_renew", "This field is required."))
+		return
+	}
+
+	sl.s.Lock()
+	s, ok := sl.m[id]
+	if ok {
+		s.AutoRenew = *params.AutoRenew
+	}
+	sl.s.Unlock()
+
+	sl.handleGet(w, r, 200, id)
+}
+
+func (sl *SubscriptionLibrary) handleAction(w http.ResponseWriter, r *http.Request, id string) {
+	if r.URL.Query().Get("do") != "extend" {
+		w.WriteHeader(400)
+		return
+	}
+
+	var params struct {
+		Period string `json:"period"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		w.WriteHeader(400)
+		return
+	}
+
+	sl.s.Lock()
+	s, ok := sl.m[id]
+	var err error
+	if ok {
+		var end time.Time
+		if end, err = addPeriod(*s.EndTime, params.Period); err == nil {
+			s.EndTime = &end
+		}
+	}
+	sl.s.Unlock()
+
+	if err != nil {
+		writeValidationError(w, invalid("period", "Invalid period %q.", params.Period))
+		return
+	}
+
+	sl.handleGet(w, r, 200, id)
+}
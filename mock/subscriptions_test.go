@@ -0,0 +1,70 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+)
+
+func TestSubscriptionPeriod(t *testing.T) {
+	t0 := time.Date(2014, 1, 31, 12, 0, 0, 0, time.UTC)
+	for period, wants := range map[string]time.Time{
+		"1 day":    t0.AddDate(0, 0, 1),
+		"2 weeks":  t0.AddDate(0, 0, 14),
+		"1 month":  t0.AddDate(0, 1, 0),
+		"3 months": t0.AddDate(0, 3, 0),
+		"1 year":   t0.AddDate(1, 0, 0),
+	} {
+		if v, err := addPeriod(t0, period); err != nil || !v.Equal(wants) {
+			t.Errorf("%q: %v %v, wants %v", period, v, err, wants)
+		}
+	}
+
+	for _, period := range []string{"", "month", "0 days", "1 fortnight", "-1 day"} {
+		if _, err := addPeriod(t0, period); err == nil {
+			t.Errorf("%q must be invalid period", period)
+		}
+	}
+}
+
+func TestSubscriptionState(t *testing.T) {
+	now := time.Date(2014, 2, 10, 12, 0, 0, 0, time.UTC)
+	srv := New(WithClock(NewManualClock(now)))
+	defer srv.Close()
+
+	ids := srv.Subscriptions.AddSubscriptions([]data.Subscription{
+		{Resource: "ip", Amount: "1"},
+		{Resource: "cpu", Amount: "1000", Period: "1 year"},
+	})
+	if len(ids) != 2 {
+		t.Fatalf("invalid subscription IDs %v", ids)
+	}
+
+	f := srv.Snapshot()
+	if len(f.Subscriptions) != 2 {
+		t.Fatalf("invalid subscriptions snapshot %v", f.Subscriptions)
+	}
+	ip, cpu := f.Subscriptions[0], f.Subscriptions[1]
+	if ip.SubscribedObject == "" || ip.Period != "1 month" || !ip.EndTime.Equal(now.AddDate(0, 1, 0)) {
+		t.Errorf("invalid IP subscription %#v", ip)
+	}
+	if cpu.SubscribedObject != "" || !cpu.EndTime.Equal(now.AddDate(1, 0, 0)) {
+		t.Errorf("invalid CPU subscription %#v", cpu)
+	}
+
+	srv.Reset()
+	if n := len(srv.Snapshot().Subscriptions); n != 0 {
+		t.Errorf("subscriptions must be cleared by Reset, got %d", n)
+	}
+
+	if err := srv.Restore(f); err != nil {
+		t.Fatal(err)
+	}
+	if ss := srv.Snapshot().Subscriptions; len(ss) != 2 || ss[0].SubscribedObject != ip.SubscribedObject {
+		t.Errorf("subscriptions must be restored, got %v", ss)
+	}
+}
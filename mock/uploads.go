@@ -0,0 +1,263 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/altoros/gosigma/data"
+)
+
+// upload holds chunks of drive image received with resumable upload requests
+type upload struct {
+	params uploadParams
+	chunks map[int][]byte
+	sums   map[int]string
+	drive  string
+}
+
+type uploads struct {
+	s      sync.Mutex
+	m      map[string]*upload
+	images map[string][]byte
+}
+
+func (uu *uploads) reset() {
+	uu.s.Lock()
+	defer uu.s.Unlock()
+	uu.m = nil
+	uu.images = nil
+}
+
+// uploadParams describes chunk of resumable upload request
+type uploadParams struct {
+	identifier  string
+	filename    string
+	number      int
+	totalChunks int
+	chunkSize   uint64
+	currentSize uint64
+	totalSize   uint64
+}
+
+// sameImage reports whether parameters describe chunk of the same image
+func (p uploadParams) sameImage(o uploadParams) bool {
+	return p.totalSize == o.totalSize && p.chunkSize == o.chunkSize && p.totalChunks == o.totalChunks
+}
+
+func parseUploadParams(vv url.Values) (p uploadParams, err error) {
+	p.identifier = vv.Get("resumableIdentifier")
+	if p.identifier == "" {
+		return p, invalid("resumableIdentifier", "This field is required.")
+	}
+	p.filename = vv.Get("resumableFilename")
+	if p.filename == "" {
+		return p, invalid("resumableFilename", "This field is required.")
+	}
+
+	number := func(name string) (uint64, error) {
+		v, err := strconv.ParseUint(vv.Get(name), 10, 64)
+		if err != nil || v == 0 {
+			return 0, invalid(name, "Value of %s must be positive integer.", name)
+		}
+		return v, nil
+	}
+
+	var n uint64
+	if n, err = number("resumableChunkNumber"); err != nil {
+		return p, err
+	}
+	p.number = int(n)
+	if n, err = number("resumableTotalChunks"); err != nil {
+		return p, err
+	}
+	p.totalChunks = int(n)
+	if p.chunkSize, err = number("resumableChunkSize"); err != nil {
+		return p, err
+	}
+	if p.currentSize, err = number("resumableCurrentChunkSize"); err != nil {
+		return p, err
+	}
+	if p.totalSize, err = number("resumableTotalSize"); err != nil {
+		return p, err
+	}
+
+	if total := (p.totalSize + p.chunkSize - 1) / p.chunkSize; uint64(p.totalChunks) != total {
+		return p, invalid("resumableTotalChunks", "Image of %d bytes consists of %d chunks.", p.totalSize, total)
+	}
+	if p.number > p.totalChunks {
+		return p, invalid("resumableChunkNumber", "Value of resumableChunkNumber must not exceed %d.", p.totalChunks)
+	}
+
+	size := p.chunkSize
+	if p.number == p.totalChunks {
+		size = p.totalSize - uint64(p.totalChunks-1)*p.chunkSize
+	}
+	if p.currentSize != size {
+		return p, invalid("resumableCurrentChunkSize", "Chunk %d must be %d bytes long.", p.number, size)
+	}
+
+	return p, nil
+}
+
+// DriveImage returns contents of drive image uploaded to the server or set with
+// SetDriveImage
+func (srv *Server) DriveImage(uuid string) ([]byte, bool) {
+	srv.uploads.s.Lock()
+	defer srv.uploads.s.Unlock()
+	bb, ok := srv.uploads.images[uuid]
+	return bb, ok
+}
+
+// find returns upload of the image, chunk of which is described by parameters
+func (uu *uploads) find(p uploadParams) *upload {
+	if u, ok := uu.m[p.identifier]; ok && u.params.sameImage(p) {
+		return u
+	}
+	return nil
+}
+
+// resume returns upload the chunk with given checksum belongs to. Finished
+// upload is returned only for the last chunk sent again, other chunks start
+// upload of another image with the same identifier.
+func (uu *uploads) resume(p uploadParams, sum string) *upload {
+	u := uu.find(p)
+	if u != nil && u.drive != "" && (p.number != p.totalChunks || u.sums[p.number] != sum) {
+		return nil
+	}
+	return u
+}
+
+// uploadHandler implements resumable upload of drive images. GET request tests
+// whether chunk is already received, POST request sends the chunk. Drive is
+// created from the image when all its chunks are received.
+func (srv *Server) uploadHandler(w http.ResponseWriter, r *http.Request) {
+	p, err := parseUploadParams(r.URL.Query())
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		srv.handleUploadTest(w, p)
+	case "POST":
+		srv.handleUploadChunk(w, r, p)
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+func (srv *Server) handleUploadTest(w http.ResponseWriter, p uploadParams) {
+	srv.uploads.s.Lock()
+	var sum string
+	if u := srv.uploads.find(p); u != nil && u.drive == "" {
+		sum = u.sums[p.number]
+	}
+	srv.uploads.s.Unlock()
+
+	if sum == "" {
+		w.WriteHeader(204)
+		return
+	}
+
+	w.Header().Set("ETag", strconv.Quote(sum))
+	w.WriteHeader(200)
+}
+
+func (srv *Server) handleUploadChunk(w http.ResponseWriter, r *http.Request, p uploadParams) {
+	bb, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(400)
+		return
+	}
+	if uint64(len(bb)) != p.currentSize {
+		writeValidationError(w, invalid("resumableCurrentChunkSize", "Chunk %d must be %d bytes long.", p.number, p.currentSize))
+		return
+	}
+
+	sum := md5.Sum(bb)
+	if h := r.Header.Get("Content-MD5"); h != "" && h != base64.StdEncoding.EncodeToString(sum[:]) {
+		writeValidationError(w, invalid("Content-MD5", "Checksum of chunk %d does not match its contents.", p.number))
+		return
+	}
+
+	etag := hex.EncodeToString(sum[:])
+
+	srv.uploads.s.Lock()
+	known := srv.uploads.resume(p, etag) != nil
+	srv.uploads.s.Unlock()
+
+	if !known {
+		if err := srv.checkDriveQuota(defaultStorageType, p.totalSize); err != nil {
+			writeQuotaError(w, err)
+			return
+		}
+	}
+
+	var drv *data.Drive
+
+	srv.uploads.s.Lock()
+	u := srv.uploads.resume(p, etag)
+	if u == nil {
+		u = &upload{params: p, chunks: make(map[int][]byte), sums: make(map[int]string)}
+		if srv.uploads.m == nil {
+			srv.uploads.m = make(map[string]*upload)
+		}
+		srv.uploads.m[p.identifier] = u
+	}
+	if u.drive == "" {
+		u.chunks[p.number] = bb
+	}
+	u.sums[p.number] = etag
+	if u.drive == "" && len(u.chunks) == p.totalChunks {
+		drv, err = InitDrive(&data.Drive{
+			Name:        p.filename,
+			Size:        p.totalSize,
+			Media:       "disk",
+			StorageType: defaultStorageType,
+		})
+		if err == nil {
+			image := make([]byte, 0, p.totalSize)
+			for i := 1; i <= p.totalChunks; i++ {
+				image = append(image, u.chunks[i]...)
+			}
+			if srv.uploads.images == nil {
+				srv.uploads.images = make(map[string][]byte)
+			}
+			srv.uploads.images[drv.UUID] = image
+			u.chunks = nil
+			u.drive = drv.UUID
+		}
+	}
+	uuid := u.drive
+	srv.uploads.s.Unlock()
+
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte("500 " + err.Error()))
+		return
+	}
+
+	if drv != nil {
+		drv.Resource = *data.MakeDriveResource(drv.UUID)
+		srv.Drives.Add(drv)
+	}
+
+	w.Header().Set("ETag", strconv.Quote(etag))
+
+	if uuid == "" {
+		w.WriteHeader(200)
+		return
+	}
+
+	srv.Drives.handleDrive(w, r, 201, uuid)
+}
@@ -0,0 +1,163 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package mock
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/https"
+)
+
+func uploadQuery(number, size int) url.Values {
+	return url.Values{
+		"resumableIdentifier":       {"6-image"},
+		"resumableFilename":         {"image"},
+		"resumableChunkNumber":      {strconv.Itoa(number)},
+		"resumableChunkSize":        {"4"},
+		"resumableCurrentChunkSize": {strconv.Itoa(size)},
+		"resumableTotalSize":        {"6"},
+		"resumableTotalChunks":      {"2"},
+	}
+}
+
+func TestUploadParams(t *testing.T) {
+	if _, err := parseUploadParams(uploadQuery(2, 2)); err != nil {
+		t.Error(err)
+	}
+
+	for point, qq := range map[string]url.Values{
+		"resumableIdentifier":       {"resumableFilename": {"image"}},
+		"resumableChunkNumber":      uploadQuery(0, 4),
+		"resumableCurrentChunkSize": uploadQuery(2, 4),
+	} {
+		err := func() error { _, err := parseUploadParams(qq); return err }()
+		if ve, ok := err.(validationError); !ok || ve.point != point {
+			t.Errorf("invalid error %v, wants error of %s", err, point)
+		}
+	}
+
+	qq := uploadQuery(3, 2)
+	qq.Set("resumableTotalChunks", "3")
+	if _, err := parseUploadParams(qq); err == nil {
+		t.Error("invalid number of chunks must fail")
+	}
+}
+
+func TestUploadHandler(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	cli := https.NewAuthClient(srv.Username(), srv.Password(), nil)
+	u := srv.Endpoint("drives/upload/")
+	ctx := context.Background()
+
+	r, err := cli.Get(u, uploadQuery(1, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != 204 {
+		t.Errorf("missing chunk test, code %d", r.StatusCode)
+	}
+
+	h := http.Header{"Content-MD5": {"1B2M2Y8AsgTpgAmY7PhCfg=="}}
+	r, err = cli.Do(ctx, "POST", u, uploadQuery(1, 4), h, bytes.NewReader([]byte("abcd")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != 400 {
+		t.Errorf("checksum mismatch must fail, code %d", r.StatusCode)
+	}
+
+	r, err = cli.Do(ctx, "POST", u, uploadQuery(1, 4), nil, bytes.NewReader([]byte("abcd")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != 200 || r.Header.Get("ETag") != `"e2fc714c4727ee9395f324cd2e7f331f"` {
+		t.Errorf("invalid chunk response %d %v", r.StatusCode, r.Header)
+	}
+
+	r, err = cli.Get(u, uploadQuery(1, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != 200 {
+		t.Errorf("received chunk test, code %d", r.StatusCode)
+	}
+
+	r, err = cli.Do(ctx, "POST", u, uploadQuery(2, 2), nil, bytes.NewReader([]byte("ef")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	if err := r.VerifyJSON(201); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := data.ReadDrive(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != "image" || d.Size != 6 || d.Media != "disk" || d.Status != "unmounted" {
+		t.Errorf("invalid drive %#v", d)
+	}
+	if bb, ok := srv.DriveImage(d.UUID); !ok || string(bb) != "abcdef" {
+		t.Errorf("invalid image %q", bb)
+	}
+
+	// the last chunk sent again returns the same drive
+	r, err = cli.Do(ctx, "POST", u, uploadQuery(2, 2), nil, bytes.NewReader([]byte("ef")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay, err := data.ReadDrive(r.Body); err != nil || r.StatusCode != 201 || replay.UUID != d.UUID {
+		t.Errorf("replay of the last chunk, code %d, error %v", r.StatusCode, err)
+	}
+	r.Body.Close()
+
+	// another image with the same identifier starts new upload
+	r, err = cli.Get(u, uploadQuery(1, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != 204 {
+		t.Errorf("chunk test of finished upload, code %d", r.StatusCode)
+	}
+
+	for i, chunk := range []string{"ABCD", "EF"} {
+		r, err = cli.Do(ctx, "POST", u, uploadQuery(i+1, len(chunk)), nil, bytes.NewReader([]byte(chunk)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Body.Close()
+	}
+	if err := r.VerifyJSON(201); err != nil {
+		t.Fatal(err)
+	}
+	other, err := data.ReadDrive(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.UUID == d.UUID {
+		t.Error("another image must create new drive")
+	}
+	if bb, ok := srv.DriveImage(other.UUID); !ok || string(bb) != "ABCDEF" {
+		t.Errorf("invalid image %q", bb)
+	}
+
+	srv.Reset()
+	if _, ok := srv.DriveImage(d.UUID); ok {
+		t.Error("images must be cleared by Reset")
+	}
+}
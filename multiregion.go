@@ -0,0 +1,166 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A RegionServer holds server instance tagged with its region code
+type RegionServer struct {
+	Server
+	Region string
+}
+
+// A RegionDrive holds drive instance tagged with its region code
+type RegionDrive struct {
+	Drive
+	Region string
+}
+
+// MultiRegionError holds errors of failed regions by region code
+type MultiRegionError map[string]error
+
+// Error implements error interface
+func (e MultiRegionError) Error() string {
+	codes := make([]string, 0, len(e))
+	for code := range e {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	rr := make([]string, 0, len(codes))
+	for _, code := range codes {
+		rr = append(rr, code+": "+e[code].Error())
+	}
+	return strings.Join(rr, "; ")
+}
+
+// A MultiRegionClient fans requests out to clients of several regions.
+// MultiRegionClient is safe for concurrent use by multiple goroutines.
+type MultiRegionClient struct {
+	codes   []string
+	clients map[string]*Client
+}
+
+// NewMultiRegionClient returns client for given region codes. Options are applied
+// to the client of every region, endpoint of each client is taken from the catalogue.
+func NewMultiRegionClient(codes []string, opts ...Option) (*MultiRegionClient, error) {
+	o := envOptions()
+	if err := o.apply(opts); err != nil {
+		return nil, err
+	}
+
+	clients := make(map[string]*Client, len(codes))
+	for _, code := range codes {
+		r, err := LookupRegion(code)
+		if err != nil {
+			return nil, err
+		}
+		ro := o
+		ro.region = r.Code
+		ro.endpoint = r.Endpoint
+		c, err := newRegionClient(ro)
+		if err != nil {
+			return nil, err
+		}
+		clients[r.Code] = c
+	}
+
+	return NewMultiRegionClientFrom(clients), nil
+}
+
+// NewMultiRegionClientFrom returns client for given clients by region code
+func NewMultiRegionClientFrom(clients map[string]*Client) *MultiRegionClient {
+	m := &MultiRegionClient{
+		codes:   make([]string, 0, len(clients)),
+		clients: make(map[string]*Client, len(clients)),
+	}
+	for code, c := range clients {
+		m.codes = append(m.codes, code)
+		m.clients[code] = c
+	}
+	sort.Strings(m.codes)
+	return m
+}
+
+// Regions returns sorted codes of regions
+func (m *MultiRegionClient) Regions() []string {
+	r := make([]string, len(m.codes))
+	copy(r, m.codes)
+	return r
+}
+
+// Client returns client of given region
+func (m *MultiRegionClient) Client(code string) (*Client, bool) {
+	c, ok := m.clients[code]
+	return c, ok
+}
+
+// Servers in all regions. On failure of some regions, servers of other regions are
+// returned along with MultiRegionError.
+func (m *MultiRegionClient) Servers(rqspec RequestSpec) ([]RegionServer, error) {
+	var result []RegionServer
+	err := m.each(func(code string, c *Client, mu *sync.Mutex) error {
+		ss, err := c.Servers(rqspec)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, s := range ss {
+			result = append(result, RegionServer{s, code})
+		}
+		return nil
+	})
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Region < result[j].Region })
+	return result, err
+}
+
+// Drives in all regions. On failure of some regions, drives of other regions are
+// returned along with MultiRegionError.
+func (m *MultiRegionClient) Drives(rqspec RequestSpec, libspec LibrarySpec) ([]RegionDrive, error) {
+	var result []RegionDrive
+	err := m.each(func(code string, c *Client, mu *sync.Mutex) error {
+		dd, err := c.Drives(rqspec, libspec)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, d := range dd {
+			result = append(result, RegionDrive{d, code})
+		}
+		return nil
+	})
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Region < result[j].Region })
+	return result, err
+}
+
+// each calls f for every region concurrently, mutex is shared between calls
+func (m *MultiRegionClient) each(f func(code string, c *Client, mu *sync.Mutex) error) error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make(MultiRegionError)
+
+	for _, code := range m.codes {
+		wg.Add(1)
+		go func(code string, c *Client) {
+			defer wg.Done()
+			if err := f(code, c, &mu); err != nil {
+				mu.Lock()
+				errs[code] = err
+				mu.Unlock()
+			}
+		}(code, m.clients[code])
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"testing"
+	"time"
+
+	"github.com/altoros/gosigma/mock"
+)
+
+func TestMultiRegionClientNew(t *testing.T) {
+	m, err := NewMultiRegionClient([]string{"zrh", "lvs"}, WithCredentials("user", "pass"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if rr := m.Regions(); len(rr) != 2 || rr[0] != "lvs" || rr[1] != "zrh" {
+		t.Errorf("invalid regions %v", rr)
+	}
+
+	c, ok := m.Client("lvs")
+	if !ok || c.endpoint != "https://lvs.cloudsigma.com/api/2.0/" {
+		t.Errorf("invalid client for lvs: %v", c)
+	}
+
+	if _, err := NewMultiRegionClient([]string{"zrh", "xyz"}, WithCredentials("user", "pass")); err == nil {
+		t.Error("NewMultiRegionClient must fail for unknown region")
+	}
+}
+
+func TestMultiRegionClient(t *testing.T) {
+	mock.ResetServers()
+	mock.ResetDrives()
+	defer mock.ResetServers()
+	defer mock.ResetDrives()
+
+	mock.AddServer(newDataServer())
+	mock.Drives.Add(newDataDrive("uuid"))
+
+	c1, err := createTestClient(t)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	c2, err := createTestClient(t)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	m := NewMultiRegionClientFrom(map[string]*Client{"zrh": c1, "lvs": c2})
+
+	ss, err := m.Servers(RequestDetail)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(ss) != 2 || ss[0].Region != "lvs" || ss[1].Region != "zrh" {
+		t.Errorf("invalid servers %v", ss)
+	}
+	for _, s := range ss {
+		if s.UUID() != "uuid" {
+			t.Errorf("invalid server %v", s)
+		}
+	}
+
+	dd, err := m.Drives(RequestShort, LibraryAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(dd) != 2 || dd[0].Region != "lvs" || dd[1].Region != "zrh" {
+		t.Errorf("invalid drives %v", dd)
+	}
+}
+
+func TestMultiRegionClientPartialFailure(t *testing.T) {
+	mock.ResetServers()
+	defer mock.ResetServers()
+
+	mock.AddServer(newDataServer())
+
+	c1, err := createTestClient(t)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	c2, err := NewClient("https://0.1.2.3:2000/api/2.0/", mock.TestUser, mock.TestPassword, nil,
+		WithConnectTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	m := NewMultiRegionClientFrom(map[string]*Client{"zrh": c1, "lvs": c2})
+
+	ss, err := m.Servers(RequestShort)
+	if len(ss) != 1 || ss[0].Region != "zrh" {
+		t.Errorf("invalid servers %v", ss)
+	}
+
+	me, ok := err.(MultiRegionError)
+	if !ok {
+		t.Errorf("error %v must be MultiRegionError", err)
+		return
+	}
+	if _, ok := me["lvs"]; !ok || len(me) != 1 {
+		t.Errorf("invalid MultiRegionError %v", me)
+	}
+	t.Log(me)
+}
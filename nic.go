@@ -14,6 +14,9 @@ type NIC interface {
 	// Convert to string
 	fmt.Stringer
 
+	// FirewallPolicy attached to network interface card, or nil
+	FirewallPolicy() Resource
+
 	// IPv4 configuration
 	IPv4() IPv4
 
@@ -45,6 +48,14 @@ func (n nic) String() string {
 		n.Model(), n.MAC(), n.IPv4(), n.VLAN(), n.Runtime())
 }
 
+// FirewallPolicy attached to network interface card, or nil
+func (n nic) FirewallPolicy() Resource {
+	if n.obj.FirewallPolicy != nil {
+		return resource{n.obj.FirewallPolicy}
+	}
+	return nil
+}
+
 // IPv4 configuration
 func (n nic) IPv4() IPv4 {
 	if n.obj.IPv4 != nil {
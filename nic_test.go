@@ -12,6 +12,9 @@ import (
 func TestNIC_Empty(t *testing.T) {
 	var n NIC
 	n = nic{client: nil, obj: &data.NIC{}}
+	if v := n.FirewallPolicy(); v != nil {
+		t.Errorf("invalid NIC.FirewallPolicy %v, must be nil", v)
+	}
 	if v := n.IPv4(); v != nil {
 		t.Errorf("invalid NIC.IPv4 %v, must be nil", v)
 	}
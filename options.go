@@ -0,0 +1,220 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/altoros/gosigma/https"
+)
+
+const (
+	// EnvUsername defines environment variable with default account username for New
+	EnvUsername = "CLOUDSIGMA_USERNAME"
+	// EnvPassword defines environment variable with default account password for New
+	EnvPassword = "CLOUDSIGMA_PASSWORD"
+	// EnvRegion defines environment variable with default region code for New
+	EnvRegion = "CLOUDSIGMA_REGION"
+)
+
+var errEmptyRegion = errors.New("region is not allowed to be empty")
+var errRegionEndpoint = errors.New("region is not allowed to be combined with endpoint")
+var errNilAuthenticator = errors.New("authenticator is not allowed to be nil")
+
+// An Option configures Client at construction time
+type Option func(*options) error
+
+// options holds Client configuration collected from Option values
+type options struct {
+	region           string
+	regionOption     bool
+	endpoint         string
+	username         string
+	password         string
+	authenticator    https.Authenticator
+	tlsConfig        *tls.Config
+	connectTimeout   time.Duration
+	readWriteTimeout time.Duration
+	operationTimeout time.Duration
+	retryPolicy      *https.RetryPolicy
+	logger           https.Logger
+	userAgent        string
+	transport        http.RoundTripper
+}
+
+// WithRegion returns Option selecting CloudSigma region by its code, see Regions.
+// The option can not be combined with WithEndpoint option or endpoint of NewClient.
+func WithRegion(region string) Option {
+	return func(o *options) error {
+		region = strings.TrimSpace(region)
+		if region == "" {
+			return errEmptyRegion
+		}
+		if err := VerifyRegion(region); err != nil {
+			return err
+		}
+		o.region = region
+		o.regionOption = true
+		return nil
+	}
+}
+
+// WithEndpoint returns Option setting CloudSigma endpoint URL
+func WithEndpoint(endpoint string) Option {
+	return func(o *options) error {
+		if err := VerifyEndpoint(endpoint); err != nil {
+			return err
+		}
+		if !strings.HasSuffix(endpoint, "/") {
+			endpoint += "/"
+		}
+		o.endpoint = endpoint
+		return nil
+	}
+}
+
+// WithCredentials returns Option setting username and password for basic authentication
+func WithCredentials(username, password string) Option {
+	return func(o *options) error {
+		if len(username) == 0 {
+			return errEmptyUsername
+		}
+		if len(password) == 0 {
+			return errEmptyPassword
+		}
+		o.username = username
+		o.password = password
+		return nil
+	}
+}
+
+// WithAuthenticator returns Option setting authentication for requests.
+// Authenticator takes precedence over credentials.
+func WithAuthenticator(auth https.Authenticator) Option {
+	return func(o *options) error {
+		if auth == nil {
+			return errNilAuthenticator
+		}
+		o.authenticator = auth
+		return nil
+	}
+}
+
+// WithTLSConfig returns Option setting TLS configuration for https transport
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(o *options) error {
+		o.tlsConfig = tlsConfig
+		return nil
+	}
+}
+
+// WithConnectTimeout returns Option setting connection timeout
+func WithConnectTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		o.connectTimeout = timeout
+		return nil
+	}
+}
+
+// WithReadWriteTimeout returns Option setting read-write timeout
+func WithReadWriteTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		o.readWriteTimeout = timeout
+		return nil
+	}
+}
+
+// WithOperationTimeout returns Option setting timeout for cloud operations
+// (like cloning, starting, stopping etc)
+func WithOperationTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		o.operationTimeout = timeout
+		return nil
+	}
+}
+
+// WithRetryPolicy returns Option setting policy for retrying failed requests
+func WithRetryPolicy(policy https.RetryPolicy) Option {
+	return func(o *options) error {
+		o.retryPolicy = &policy
+		return nil
+	}
+}
+
+// WithLogger returns Option setting logger for http traces
+func WithLogger(logger https.Logger) Option {
+	return func(o *options) error {
+		o.logger = logger
+		return nil
+	}
+}
+
+// WithUserAgent returns Option setting User-Agent header for requests
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) error {
+		o.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithTransport returns Option replacing HTTP transport of the Client.
+// TLS configuration and connection timeout are not used by custom transport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) error {
+		o.transport = transport
+		return nil
+	}
+}
+
+// envOptions returns options with default values read from environment variables
+func envOptions() options {
+	o := options{
+		region:    os.Getenv(EnvRegion),
+		username:  os.Getenv(EnvUsername),
+		password:  os.Getenv(EnvPassword),
+		userAgent: "gosigma/" + Version(),
+	}
+	if o.region == "" {
+		o.region = DefaultRegion
+	}
+	return o
+}
+
+func (o *options) apply(opts []Option) error {
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o options) httpsOptions() []https.Option {
+	oo := []https.Option{
+		https.WithConnectTimeout(o.connectTimeout),
+		https.WithReadWriteTimeout(o.readWriteTimeout),
+		https.WithLogger(o.logger),
+		https.WithUserAgent(o.userAgent),
+	}
+	if o.authenticator != nil {
+		oo = append(oo, https.WithAuthenticator(o.authenticator))
+	} else {
+		oo = append(oo, https.WithAuthenticator(https.BasicAuth{
+			Username: o.username,
+			Password: o.password,
+		}))
+	}
+	if o.retryPolicy != nil {
+		oo = append(oo, https.WithRetryPolicy(*o.retryPolicy))
+	}
+	if o.transport != nil {
+		oo = append(oo, https.WithTransport(o.transport))
+	}
+	return oo
+}
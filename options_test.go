@@ -0,0 +1,189 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/altoros/gosigma/https"
+	"github.com/altoros/gosigma/mock"
+)
+
+func TestNewEnvironment(t *testing.T) {
+	t.Setenv(EnvUsername, mock.TestUser)
+	t.Setenv(EnvPassword, mock.TestPassword)
+	t.Setenv(EnvRegion, "lvs")
+
+	cli, err := New()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if cli.endpoint != "https://lvs.cloudsigma.com/api/2.0/" {
+		t.Errorf("invalid endpoint %q", cli.endpoint)
+	}
+
+	cli, err = New(WithRegion("sjc"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if cli.endpoint != "https://sjc.cloudsigma.com/api/2.0/" {
+		t.Errorf("invalid endpoint %q", cli.endpoint)
+	}
+
+	t.Setenv(EnvRegion, "")
+	cli, err = New()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if cli.endpoint != ResolveEndpoint(DefaultRegion) {
+		t.Errorf("invalid endpoint %q", cli.endpoint)
+	}
+}
+
+func TestNewInvalidOptions(t *testing.T) {
+	t.Setenv(EnvUsername, "")
+	t.Setenv(EnvPassword, "")
+
+	if _, err := New(); err != errEmptyUsername {
+		t.Error("New() without credentials must fail with errEmptyUsername, got", err)
+	}
+	if _, err := New(WithCredentials("user", "")); err != errEmptyPassword {
+		t.Error("WithCredentials with empty password must fail, got", err)
+	}
+	if _, err := New(WithRegion(" ")); err != errEmptyRegion {
+		t.Error("WithRegion with empty region must fail, got", err)
+	}
+	if _, err := New(WithEndpoint("http://example.com/")); err != errHttpsRequired {
+		t.Error("WithEndpoint with http scheme must fail, got", err)
+	}
+	if _, err := New(WithAuthenticator(nil)); err != errNilAuthenticator {
+		t.Error("WithAuthenticator(nil) must fail, got", err)
+	}
+	if _, err := New(WithCredentials("user", "pass"), WithRegion("zrh"), WithEndpoint(mockEndpoint)); err != errRegionEndpoint {
+		t.Error("WithRegion with WithEndpoint must fail, got", err)
+	}
+	if _, err := NewClient(mockEndpoint, "user", "pass", nil, WithRegion("zrh")); err != errRegionEndpoint {
+		t.Error("NewClient with WithRegion must fail, got", err)
+	}
+}
+
+func TestNewMock(t *testing.T) {
+	mock.ResetServers()
+
+	cli, err := New(
+		WithEndpoint(mockEndpoint),
+		WithCredentials(mock.TestUser, mock.TestPassword),
+		WithTLSConfig(&tls.Config{InsecureSkipVerify: true}),
+		WithRetryPolicy(https.RetryPolicy{Attempts: 2}),
+		WithUserAgent("gosigma-test"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if _, err := cli.Servers(RequestShort); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewVerifiesCertificate(t *testing.T) {
+	cli, err := New(
+		WithEndpoint(mockEndpoint),
+		WithCredentials(mock.TestUser, mock.TestPassword),
+		WithRetryPolicy(https.RetryPolicy{Attempts: 1}))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if _, err := cli.Servers(RequestShort); err == nil {
+		t.Error("request to endpoint with self-signed certificate must fail")
+	}
+}
+
+type testTransport struct {
+	requests  int32
+	userAgent atomic.Value
+	transport http.RoundTripper
+}
+
+func (t *testTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.requests, 1)
+	t.userAgent.Store(r.Header.Get("User-Agent"))
+	return t.transport.RoundTrip(r)
+}
+
+type testAuth struct{ calls int32 }
+
+func (a *testAuth) Authenticate(r *http.Request) error {
+	atomic.AddInt32(&a.calls, 1)
+	r.SetBasicAuth(mock.TestUser, mock.TestPassword)
+	return nil
+}
+
+func TestNewTransportAndAuthenticator(t *testing.T) {
+	mock.ResetServers()
+
+	tr := &testTransport{
+		transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	auth := &testAuth{}
+
+	cli, err := New(
+		WithEndpoint(mockEndpoint),
+		WithAuthenticator(auth),
+		WithTransport(tr))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if _, err := cli.Servers(RequestShort); err != nil {
+		t.Error(err)
+	}
+
+	if v := atomic.LoadInt32(&tr.requests); v == 0 {
+		t.Error("no requests through transport")
+	}
+	if v := atomic.LoadInt32(&auth.calls); v != 1 {
+		t.Errorf("authenticator calls %d, wants 1", v)
+	}
+	if v := tr.userAgent.Load(); v != "gosigma/"+Version() {
+		t.Errorf("User-Agent %q", v)
+	}
+}
+
+func TestNewRetryPolicyMockFault(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := New(
+		WithEndpoint(srv.Endpoint("")),
+		WithCredentials(srv.Username(), srv.Password()),
+		WithTLSConfig(&tls.Config{InsecureSkipVerify: true}),
+		WithRetryPolicy(https.RetryPolicy{Attempts: 3, StatusCodes: []int{503}}))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	srv.AddFault(mock.Fault{Path: "servers", Code: 503, Count: 2})
+	if _, err := cli.Servers(RequestShort); err != nil {
+		t.Error("request must succeed on third attempt:", err)
+	}
+
+	srv.AddFault(mock.Fault{Path: "servers", Code: 503, Count: 3})
+	_, err = cli.Servers(RequestShort)
+	if e, ok := err.(*Error); !ok || e.StatusCode != 503 {
+		t.Errorf("request must fail with 503 after three attempts, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+)
+
+// A Price describes price of billable resource at given level. Level 0 is the
+// subscription price, other levels are burst prices. Multiplier converts usage
+// of the resource in its units multiplied by seconds to the Unit of the price.
+type Price struct {
+	Resource   string
+	Currency   string
+	Level      int
+	Multiplier uint64
+	Price      Amount
+	Unit       string
+}
+
+// A Pricing describes catalogue of prices. Current and Next hold burst levels
+// by billable resource for current and next billing cycles.
+type Pricing struct {
+	Prices  []Price
+	Current map[string]int
+	Next    map[string]int
+}
+
+// CostPrecision defines number of digits after the point estimated costs are
+// rounded to
+const CostPrecision = 2
+
+// A Cost describes estimated cost of resources for a period, if paid by
+// subscription prices or by current burst prices. Costs are computed exactly
+// and rounded to CostPrecision digits.
+type Cost struct {
+	Currency     string
+	Subscription Amount
+	Burst        Amount
+}
+
+func makePricing(obj *data.Pricing) (Pricing, error) {
+	result := Pricing{
+		Prices:  make([]Price, 0, len(obj.Objects)),
+		Current: obj.Current,
+		Next:    obj.Next,
+	}
+	for _, p := range obj.Objects {
+		price, err := parseAmount(p.Price)
+		if err != nil {
+			return Pricing{}, err
+		}
+		result.Prices = append(result.Prices, Price{
+			Resource:   p.Resource,
+			Currency:   p.Currency,
+			Level:      p.Level,
+			Multiplier: p.Multiplier,
+			Price:      price,
+			Unit:       p.Unit,
+		})
+	}
+	return result, nil
+}
+
+// Price returns price of billable resource in given currency at given level
+func (p Pricing) Price(resource, currency string, level int) (Price, bool) {
+	for _, v := range p.Prices {
+		if v.Resource == resource && v.Currency == currency && v.Level == level {
+			return v, true
+		}
+	}
+	return Price{}, false
+}
+
+// Currencies returns sorted list of currencies of the catalogue
+func (p Pricing) Currencies() []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, v := range p.Prices {
+		if !seen[v.Currency] {
+			seen[v.Currency] = true
+			result = append(result, v.Currency)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// cost returns exact price of using amount of resource for given period
+func (v Price) cost(amount uint64, period time.Duration) *big.Rat {
+	if v.Multiplier == 0 {
+		return new(big.Rat)
+	}
+	usage := new(big.Int).Mul(new(big.Int).SetUint64(amount), big.NewInt(int64(period)))
+	units := new(big.Int).Mul(new(big.Int).SetUint64(v.Multiplier), big.NewInt(int64(time.Second)))
+	r := new(big.Rat).SetFrac(usage, units)
+	return r.Mul(r, v.Price.rat())
+}
+
+// roundCost rounds exact cost to CostPrecision digits
+func roundCost(r *big.Rat) Amount {
+	rounded, _ := new(big.Rat).SetString(r.FloatString(CostPrecision))
+	return Amount{rounded}
+}
+
+// estimate returns cost of using given amounts of billable resources for the
+// period in every currency of the catalogue. Burst cost is estimated with
+// current burst levels, resources without burst prices are charged by
+// subscription prices.
+func (p Pricing) estimate(usage map[string]uint64, period time.Duration) ([]Cost, error) {
+	resources := make([]string, 0, len(usage))
+	for resource, amount := range usage {
+		if amount > 0 {
+			resources = append(resources, resource)
+		}
+	}
+	sort.Strings(resources)
+
+	var result []Cost
+	for _, currency := range p.Currencies() {
+		subscriptionCost, burstCost := new(big.Rat), new(big.Rat)
+		for _, resource := range resources {
+			subscription, ok := p.Price(resource, currency, 0)
+			if !ok {
+				return nil, fmt.Errorf("no %s subscription price for %s", currency, resource)
+			}
+			burst, ok := p.Price(resource, currency, p.Current[resource])
+			if !ok {
+				burst = subscription
+			}
+			subscriptionCost.Add(subscriptionCost, subscription.cost(usage[resource], period))
+			burstCost.Add(burstCost, burst.cost(usage[resource], period))
+		}
+		result = append(result, Cost{
+			Currency:     currency,
+			Subscription: roundCost(subscriptionCost),
+			Burst:        roundCost(burstCost),
+		})
+	}
+
+	return result, nil
+}
+
+// EstimateCost returns cost of CPU and RAM of new server running for the
+// period in every currency of the pricing catalogue. Attached drives are
+// estimated separately with Drive.EstimateCost.
+func (c Components) EstimateCost(pricing Pricing, period time.Duration) ([]Cost, error) {
+	usage := make(map[string]uint64)
+	if c.data != nil {
+		usage[BillingCPU] = c.data.CPU
+		usage[BillingMem] = c.data.Mem
+	}
+	return pricing.estimate(usage, period)
+}
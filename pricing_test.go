@@ -0,0 +1,130 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"testing"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/mock"
+)
+
+const month = 30 * 24 * time.Hour
+
+// testAmount returns amount parsed from decimal string
+func testAmount(s string) Amount {
+	a, err := ParseAmount(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// checkCost compares costs with wanted ones, given as currency, subscription
+// and burst cost strings
+func checkCost(t *testing.T, cc []Cost, wants ...[3]string) {
+	if len(cc) != len(wants) {
+		t.Errorf("costs %v, wants %v", cc, wants)
+		return
+	}
+	for i, c := range cc {
+		if v := [3]string{c.Currency, c.Subscription.String(), c.Burst.String()}; v != wants[i] {
+			t.Errorf("cost %v, wants %v", v, wants[i])
+		}
+	}
+}
+
+func TestClientPricing(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := cli.Pricing()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cc := p.Currencies(); len(cc) != 1 || cc[0] != "USD" {
+		t.Errorf("Pricing.Currencies: %v", cc)
+	}
+	if v, ok := p.Price(BillingCPU, "USD", 1); !ok || v.Price.String() != "0.0125" || v.Unit != "GHz/hour" {
+		t.Errorf("Pricing.Price: %#v", v)
+	}
+	if p.Current[BillingCPU] != 1 {
+		t.Errorf("Pricing.Current: %v", p.Current)
+	}
+
+	var c Components
+	c.SetCPU(2000)
+	c.SetMem(2 * Gigabyte)
+
+	cc, err := c.EstimateCost(p, month)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCost(t, cc, [3]string{"USD", "28", "41.04"})
+
+	cc, err = Components{}.EstimateCost(p, month)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCost(t, cc, [3]string{"USD", "0", "0"})
+
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("drive"), Size: 10 * Gigabyte})
+
+	d, err := cli.Drive("drive", LibraryAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc, err = d.EstimateCost(p, month)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCost(t, cc, [3]string{"USD", "1.4", "2.1"})
+}
+
+func TestPricingEstimate(t *testing.T) {
+	p := Pricing{
+		Prices: []Price{
+			{Resource: BillingCPU, Currency: "EUR", Level: 0, Multiplier: 1000 * 3600, Price: testAmount("0.01")},
+			{Resource: BillingCPU, Currency: "EUR", Level: 3, Multiplier: 1000 * 3600, Price: testAmount("0.02")},
+			{Resource: BillingMem, Currency: "EUR", Level: 0, Multiplier: Gigabyte * 3600, Price: testAmount("0.01")},
+			{Resource: BillingCPU, Currency: "CHF", Level: 0, Multiplier: 1000 * 3600, Price: testAmount("0.015")},
+		},
+		Current: map[string]int{BillingCPU: 3, BillingMem: 2},
+	}
+
+	var c Components
+	c.SetCPU(1000)
+
+	cc, err := c.EstimateCost(p, 10*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCost(t, cc, [3]string{"CHF", "0.15", "0.15"}, [3]string{"EUR", "0.1", "0.2"})
+
+	c.SetMem(Gigabyte)
+	if _, err := c.EstimateCost(p, time.Hour); err == nil {
+		t.Error("estimate without CHF price of mem must fail")
+	}
+
+	// costs are summed exactly and rounded once
+	p = Pricing{
+		Prices: []Price{
+			{Resource: BillingCPU, Currency: "EUR", Level: 0, Multiplier: 1000 * 3600, Price: testAmount("0.003")},
+			{Resource: BillingMem, Currency: "EUR", Level: 0, Multiplier: Gigabyte * 3600, Price: testAmount("0.0035")},
+		},
+	}
+	c.SetCPU(1000)
+	c.SetMem(Gigabyte)
+	if cc, err := c.EstimateCost(p, time.Hour); err != nil {
+		t.Error(err)
+	} else {
+		checkCost(t, cc, [3]string{"EUR", "0.01", "0.01"})
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// EnvProfile defines environment variable selecting profile in credentials file
+	EnvProfile = "CLOUDSIGMA_PROFILE"
+	// EnvConfigFile defines environment variable overriding credentials file location
+	EnvConfigFile = "CLOUDSIGMA_CONFIG_FILE"
+	// DefaultProfile defines name of profile used when none is selected
+	DefaultProfile = "default"
+	// DefaultConfigFile defines name of credentials file in user home directory
+	DefaultConfigFile = ".cloudsigma.conf"
+)
+
+// A Profile holds named set of credentials and location read from credentials file.
+//
+// Credentials file uses INI format, every section defines a profile:
+//
+//	[default]
+//	username = user@example.com
+//	password = secret
+//	region = zrh
+//
+//	[lab]
+//	username = lab@example.com
+//	password = secret
+//	endpoint = https://lvs.cloudsigma.com/api/2.0/
+type Profile struct {
+	Name     string
+	Username string
+	Password string
+	Region   string
+	Endpoint string
+}
+
+// ReadProfiles reads and parses all profiles from credentials file content
+func ReadProfiles(r io.Reader) (map[string]Profile, error) {
+	profiles := make(map[string]Profile)
+
+	var current *Profile
+	flush := func() {
+		if current != nil {
+			profiles[current.Name] = *current
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return nil, fmt.Errorf("line %d: invalid section header %q", n, line)
+			}
+			flush()
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if name == "" {
+				return nil, fmt.Errorf("line %d: empty profile name", n)
+			}
+			current = &Profile{Name: name}
+			continue
+		}
+
+		if current == nil {
+			return nil, fmt.Errorf("line %d: key outside of profile section", n)
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("line %d: expected key = value, got %q", n, line)
+		}
+
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		value := strings.TrimSpace(kv[1])
+		switch key {
+		case "username":
+			current.Username = value
+		case "password":
+			current.Password = value
+		case "region":
+			current.Region = value
+		case "endpoint":
+			current.Endpoint = value
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q", n, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	flush()
+
+	return profiles, nil
+}
+
+// ConfigFile returns location of credentials file, taken from CLOUDSIGMA_CONFIG_FILE
+// environment variable or ~/.cloudsigma.conf otherwise
+func ConfigFile() (string, error) {
+	if path := os.Getenv(EnvConfigFile); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, DefaultConfigFile), nil
+}
+
+// LoadProfile reads and validates profile from credentials file. Empty path selects
+// file returned by ConfigFile, empty name selects profile from CLOUDSIGMA_PROFILE
+// environment variable or "default" profile otherwise.
+func LoadProfile(path, name string) (*Profile, error) {
+	if path == "" {
+		p, err := ConfigFile()
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+
+	if name == "" {
+		name = os.Getenv(EnvProfile)
+	}
+	if name == "" {
+		name = DefaultProfile
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	profiles, err := ReadProfiles(f)
+	if err != nil {
+		return nil, fmt.Errorf("credentials file %s: %s", path, err)
+	}
+
+	p, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("credentials file %s: profile %q not found", path, name)
+	}
+
+	if err := p.Verify(); err != nil {
+		return nil, fmt.Errorf("credentials file %s: %s", path, err)
+	}
+
+	return &p, nil
+}
+
+// Verify checks the profile is complete and its endpoint is valid
+func (p Profile) Verify() error {
+	var missing []string
+	if p.Username == "" {
+		missing = append(missing, "username")
+	}
+	if p.Password == "" {
+		missing = append(missing, "password")
+	}
+	if p.Region == "" && p.Endpoint == "" {
+		missing = append(missing, "region or endpoint")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("profile %q is incomplete, missing %s", p.Name, strings.Join(missing, ", "))
+	}
+
+	if p.Endpoint == "" {
+		if err := VerifyRegion(p.Region); err != nil {
+			return fmt.Errorf("profile %q has invalid region: %s", p.Name, err)
+		}
+	}
+
+	if err := VerifyEndpoint(p.endpoint()); err != nil {
+		return fmt.Errorf("profile %q has invalid endpoint: %s", p.Name, err)
+	}
+
+	return nil
+}
+
+func (p Profile) endpoint() string {
+	if p.Endpoint != "" {
+		return p.Endpoint
+	}
+	return ResolveEndpoint(p.Region)
+}
+
+// WithProfile returns Option configuring credentials and endpoint from the profile
+func WithProfile(p Profile) Option {
+	return func(o *options) error {
+		if err := p.Verify(); err != nil {
+			return err
+		}
+		o.username = p.Username
+		o.password = p.Password
+		if p.Region != "" {
+			o.region = p.Region
+		}
+		return WithEndpoint(p.endpoint())(o)
+	}
+}
+
+// WithProfileFile returns Option configuring credentials and endpoint from the profile
+// stored in credentials file. See LoadProfile for meaning of empty path and name.
+func WithProfileFile(path, name string) Option {
+	return func(o *options) error {
+		p, err := LoadProfile(path, name)
+		if err != nil {
+			return err
+		}
+		return WithProfile(*p)(o)
+	}
+}
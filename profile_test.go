@@ -0,0 +1,165 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/altoros/gosigma/mock"
+)
+
+const testProfiles = `
+# test credentials
+[default]
+username = user@example.com
+password = secret
+region = zrh
+
+[lab]
+username = lab@example.com
+password = lab secret
+endpoint = https://lvs.cloudsigma.com/api/2.0/
+
+; incomplete profile
+[broken]
+username = broken@example.com
+`
+
+func writeTestProfiles(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "cloudsigma.conf")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadProfiles(t *testing.T) {
+	pp, err := ReadProfiles(strings.NewReader(testProfiles))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(pp) != 3 {
+		t.Errorf("profiles count %d, wants 3", len(pp))
+	}
+
+	check := func(p, wants Profile) {
+		if p != wants {
+			t.Errorf("profile %#v, wants %#v", p, wants)
+		}
+	}
+	check(pp["default"], Profile{"default", "user@example.com", "secret", "zrh", ""})
+	check(pp["lab"], Profile{"lab", "lab@example.com", "lab secret", "", "https://lvs.cloudsigma.com/api/2.0/"})
+	check(pp["broken"], Profile{Name: "broken", Username: "broken@example.com"})
+}
+
+func TestReadProfilesInvalid(t *testing.T) {
+	check := func(s string) {
+		if _, err := ReadProfiles(strings.NewReader(s)); err == nil {
+			t.Errorf("ReadProfiles(%q) must fail", s)
+		} else {
+			t.Log(err)
+		}
+	}
+	check("username = user")
+	check("[default")
+	check("[]")
+	check("[default]\nusername")
+	check("[default]\ntoken = 1")
+}
+
+func TestLoadProfile(t *testing.T) {
+	path := writeTestProfiles(t, testProfiles)
+
+	p, err := LoadProfile(path, "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if p.Name != DefaultProfile {
+		t.Errorf("profile name %q, wants default", p.Name)
+	}
+
+	t.Setenv(EnvProfile, "lab")
+	p, err = LoadProfile(path, "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if p.Name != "lab" {
+		t.Errorf("profile name %q, wants lab", p.Name)
+	}
+
+	t.Setenv(EnvConfigFile, path)
+	p, err = LoadProfile("", "default")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if p.Name != "default" {
+		t.Errorf("profile name %q, wants default", p.Name)
+	}
+
+	if _, err := LoadProfile(path, "missing"); err == nil {
+		t.Error("LoadProfile must fail for missing profile")
+	}
+
+	_, err = LoadProfile(path, "broken")
+	if err == nil || !strings.Contains(err.Error(), "missing password, region or endpoint") {
+		t.Error("LoadProfile must fail for incomplete profile, got:", err)
+	}
+
+	if _, err := LoadProfile(path+".missing", ""); err == nil {
+		t.Error("LoadProfile must fail for missing file")
+	}
+}
+
+func TestProfileVerify(t *testing.T) {
+	p := Profile{Name: "test", Username: "user", Password: "pass", Region: "zrh"}
+	if err := p.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	p.Endpoint = "http://zrh.cloudsigma.com/api/2.0/"
+	if err := p.Verify(); err == nil {
+		t.Error("Verify must fail for http endpoint")
+	}
+}
+
+func TestNewWithProfile(t *testing.T) {
+	mock.ResetServers()
+
+	path := writeTestProfiles(t, `[mock]
+username = `+mock.TestUser+`
+password = `+mock.TestPassword+`
+endpoint = `+mockEndpoint)
+
+	cli, err := New(WithProfileFile(path, "mock"), WithTLSConfig(&tls.Config{InsecureSkipVerify: true}))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if _, err := cli.Servers(RequestShort); err != nil {
+		t.Error(err)
+	}
+
+	cli, err = New(WithProfile(Profile{Name: "lvs", Username: "u", Password: "p", Region: "lvs"}))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if cli.endpoint != "https://lvs.cloudsigma.com/api/2.0/" {
+		t.Errorf("invalid endpoint %q", cli.endpoint)
+	}
+
+	if _, err := New(WithProfileFile(path, "missing")); err == nil {
+		t.Error("New must fail for missing profile")
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Region describes CloudSigma location
+type Region struct {
+	// Code of region, like "zrh"
+	Code string
+	// Name of region for display purposes
+	Name string
+	// Endpoint URL of region REST API
+	Endpoint string
+	// WebsocketURL of region notification service
+	WebsocketURL string
+}
+
+func makeRegion(code, name string) Region {
+	return Region{
+		Code:         code,
+		Name:         name,
+		Endpoint:     fmt.Sprintf("https://%s.cloudsigma.com/api/2.0/", code),
+		WebsocketURL: fmt.Sprintf("wss://%s.cloudsigma.com/api/2.0/websocket", code),
+	}
+}
+
+var regions = map[string]Region{
+	"fra": makeRegion("fra", "Frankfurt, Germany"),
+	"gva": makeRegion("gva", "Geneva, Switzerland"),
+	"hnl": makeRegion("hnl", "Honolulu, USA"),
+	"lvs": makeRegion("lvs", "Las Vegas, USA"),
+	"mia": makeRegion("mia", "Miami, USA"),
+	"mnl": makeRegion("mnl", "Manila, Philippines"),
+	"per": makeRegion("per", "Perth, Australia"),
+	"sjc": makeRegion("sjc", "San Jose, USA"),
+	"tyo": makeRegion("tyo", "Tokyo, Japan"),
+	"wdc": makeRegion("wdc", "Washington DC, USA"),
+	"zrh": makeRegion("zrh", "Zurich, Switzerland"),
+}
+
+// Regions returns catalogue of known CloudSigma regions sorted by code
+func Regions() []Region {
+	r := make([]Region, 0, len(regions))
+	for _, v := range regions {
+		r = append(r, v)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].Code < r[j].Code })
+	return r
+}
+
+// LookupRegion returns region from the catalogue by its code
+func LookupRegion(code string) (Region, error) {
+	r, ok := regions[strings.ToLower(strings.TrimSpace(code))]
+	if !ok {
+		return Region{}, fmt.Errorf("unknown region %q", code)
+	}
+	return r, nil
+}
+
+// VerifyRegion checks the region code is known to the catalogue
+func VerifyRegion(code string) error {
+	_, err := LookupRegion(code)
+	return err
+}
@@ -0,0 +1,53 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import "testing"
+
+func TestRegions(t *testing.T) {
+	rr := Regions()
+	if len(rr) == 0 {
+		t.Error("empty region catalogue")
+		return
+	}
+
+	for i, r := range rr {
+		if i > 0 && rr[i-1].Code >= r.Code {
+			t.Errorf("regions not sorted at %d: %q >= %q", i, rr[i-1].Code, r.Code)
+		}
+		if r.Name == "" {
+			t.Errorf("region %q has empty name", r.Code)
+		}
+		if err := VerifyEndpoint(r.Endpoint); err != nil {
+			t.Errorf("region %q endpoint: %s", r.Code, err)
+		}
+		if r.WebsocketURL == "" {
+			t.Errorf("region %q has empty websocket URL", r.Code)
+		}
+	}
+}
+
+func TestLookupRegion(t *testing.T) {
+	r, err := LookupRegion(" ZRH ")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if r.Code != DefaultRegion {
+		t.Errorf("region code %q, wants %q", r.Code, DefaultRegion)
+	}
+	if r.Endpoint != "https://zrh.cloudsigma.com/api/2.0/" {
+		t.Errorf("region endpoint %q", r.Endpoint)
+	}
+	if r.WebsocketURL != "wss://zrh.cloudsigma.com/api/2.0/websocket" {
+		t.Errorf("region websocket URL %q", r.WebsocketURL)
+	}
+
+	if err := VerifyRegion("xyz"); err == nil {
+		t.Error("VerifyRegion must fail for unknown region")
+	}
+	if _, err := New(WithRegion("xyz")); err == nil {
+		t.Error("New must fail for unknown region")
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+)
+
+const (
+	// RemoteSnapshotCreating defines constant for remote snapshot being copied to its location
+	RemoteSnapshotCreating = "creating"
+	// RemoteSnapshotAvailable defines constant for remote snapshot ready to restore or clone
+	RemoteSnapshotAvailable = "available"
+)
+
+// A RemoteSnapshot interface represents drive snapshot stored in another
+// CloudSigma location
+type RemoteSnapshot interface {
+	// CloudSigma resource
+	Resource
+
+	// AllocatedSize of remote snapshot in bytes
+	AllocatedSize() uint64
+
+	// Drive the remote snapshot is taken from
+	Drive() Resource
+
+	// Get meta-information value stored in the remote snapshot
+	Get(key string) (v string, ok bool)
+
+	// Location code of the region storing the remote snapshot, see Regions
+	Location() string
+
+	// Name of remote snapshot
+	Name() string
+
+	// Status of remote snapshot
+	Status() string
+
+	// Timestamp of remote snapshot
+	Timestamp() time.Time
+
+	// Jobs of remote snapshot: its creation, clones and restores.
+	// Every job object in resulting slice carries only UUID and URI.
+	Jobs() []Job
+
+	// Refresh information about remote snapshot
+	Refresh() error
+
+	// Wait for jobs of remote snapshot finished
+	Wait() error
+
+	// Clone creates new drive from remote snapshot
+	Clone(params CloneParams) (Drive, error)
+
+	// CloneWait creates new drive from remote snapshot, wait for operation finished
+	CloneWait(params CloneParams) (Drive, error)
+
+	// Restore reverts the drive the remote snapshot is taken from to its
+	// contents, returns job of the operation
+	Restore() (Job, error)
+
+	// Remove remote snapshot
+	Remove() error
+}
+
+// A remoteSnapshot implements drive snapshot stored in another CloudSigma location
+type remoteSnapshot struct {
+	client *Client
+	obj    *data.RemoteSnapshot
+}
+
+var _ RemoteSnapshot = (*remoteSnapshot)(nil)
+
+// String method is used to print values passed as an operand to any format that
+// accepts a string or to an unformatted printer such as Print.
+func (s remoteSnapshot) String() string {
+	return fmt.Sprintf(`{UUID: %q, Name: %q, Location: %s, Status: %s}`,
+		s.UUID(), s.Name(), s.Location(), s.Status())
+}
+
+// URI of remote snapshot
+func (s remoteSnapshot) URI() string { return s.obj.URI }
+
+// UUID of remote snapshot
+func (s remoteSnapshot) UUID() string { return s.obj.UUID }
+
+// AllocatedSize of remote snapshot in bytes
+func (s remoteSnapshot) AllocatedSize() uint64 { return s.obj.AllocatedSize }
+
+// Drive the remote snapshot is taken from
+func (s remoteSnapshot) Drive() Resource {
+	if s.obj.Drive == nil {
+		return nil
+	}
+	return &resource{s.obj.Drive}
+}
+
+// Get meta-information value stored in the remote snapshot
+func (s remoteSnapshot) Get(key string) (v string, ok bool) {
+	v, ok = s.obj.Meta[key]
+	return
+}
+
+// Location code of the region storing the remote snapshot, see Regions
+func (s remoteSnapshot) Location() string { return s.obj.Location }
+
+// Name of remote snapshot
+func (s remoteSnapshot) Name() string { return s.obj.Name }
+
+// Status of remote snapshot
+func (s remoteSnapshot) Status() string { return s.obj.Status }
+
+// Timestamp of remote snapshot
+func (s remoteSnapshot) Timestamp() time.Time {
+	if s.obj.Timestamp == nil {
+		return time.Time{}
+	}
+	return *s.obj.Timestamp
+}
+
+// Jobs of remote snapshot: its creation, clones and restores.
+// Every job object in resulting slice carries only UUID and URI.
+func (s remoteSnapshot) Jobs() []Job {
+	r := make([]Job, 0, len(s.obj.Jobs))
+	for _, j := range s.obj.Jobs {
+		j := &job{s.client, &data.Job{Resource: j}}
+		r = append(r, j)
+	}
+	return r
+}
+
+// Refresh information about remote snapshot
+func (s *remoteSnapshot) Refresh() error {
+	obj, err := s.client.getRemoteSnapshot(s.UUID())
+	if err != nil {
+		return err
+	}
+	s.obj = obj
+	return nil
+}
+
+// Wait for jobs of remote snapshot finished
+func (s *remoteSnapshot) Wait() error {
+	for _, j := range s.Jobs() {
+		if err := j.Wait(); err != nil {
+			return err
+		}
+	}
+	return s.Refresh()
+}
+
+// Clone creates new drive from remote snapshot
+func (s remoteSnapshot) Clone(params CloneParams) (Drive, error) {
+	obj, err := s.client.cloneRemoteSnapshot(s.UUID(), params)
+	if err != nil {
+		return nil, err
+	}
+
+	drv := &drive{
+		client:  s.client,
+		obj:     obj,
+		library: LibraryAccount,
+	}
+
+	return drv, nil
+}
+
+// CloneWait creates new drive from remote snapshot, wait for operation finished
+func (s remoteSnapshot) CloneWait(params CloneParams) (Drive, error) {
+	drv, err := s.Clone(params)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, j := range drv.Jobs() {
+		if err := j.Wait(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := drv.Refresh(); err != nil {
+		return nil, err
+	}
+
+	return drv, nil
+}
+
+// Restore reverts the drive the remote snapshot is taken from to its
+// contents, returns job of the operation
+func (s remoteSnapshot) Restore() (Job, error) {
+	obj, err := s.client.restoreRemoteSnapshot(s.UUID())
+	if err != nil {
+		return nil, err
+	}
+	return &job{s.client, obj}, nil
+}
+
+// Remove remote snapshot
+func (s remoteSnapshot) Remove() error {
+	return s.client.RemoveRemoteSnapshot(s.UUID())
+}
@@ -0,0 +1,118 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"testing"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/mock"
+)
+
+func TestClientRemoteSnapshots(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drv := &data.Drive{Name: "data", Size: 1024, Status: DriveUnmounted}
+	if err := srv.Drives.Add(drv); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := cli.CreateRemoteSnapshot(drv.UUID, "wdc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name() != "data" || s.Location() != "wdc" || s.Status() != RemoteSnapshotCreating {
+		t.Errorf("invalid remote snapshot %v", s)
+	}
+	if r := s.Drive(); r == nil || r.UUID() != drv.UUID {
+		t.Errorf("RemoteSnapshot.Drive: %v", r)
+	}
+	if s.AllocatedSize() != 1024 || s.Timestamp().IsZero() || len(s.Jobs()) != 1 {
+		t.Errorf("invalid remote snapshot %v", s)
+	}
+
+	if err := s.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Status() != RemoteSnapshotAvailable {
+		t.Errorf("remote snapshot status %q, wants %q", s.Status(), RemoteSnapshotAvailable)
+	}
+
+	ss, err := cli.RemoteSnapshots(RequestShort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 1 || ss[0].UUID() != s.UUID() {
+		t.Errorf("Client.RemoteSnapshots: %v", ss)
+	}
+
+	d, err := s.CloneWait(CloneParams{Name: "restored"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Name() != "restored" || d.Size() != 1024 || d.Status() != DriveUnmounted {
+		t.Errorf("invalid clone %v", d)
+	}
+
+	j, err := s.Restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if j.State() != JobStateSuccess {
+		t.Errorf("restore job state %q", j.State())
+	}
+	if d, err := cli.Drive(drv.UUID, LibraryAccount); err != nil || d.Status() != DriveUnmounted {
+		t.Errorf("restored drive %v, error %v", d, err)
+	}
+
+	s, err = cli.RemoteSnapshot(s.UUID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Jobs()) != 3 {
+		t.Errorf("remote snapshot jobs %v", s.Jobs())
+	}
+
+	if err := s.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.RemoteSnapshot(s.UUID()); err == nil {
+		t.Error("removed remote snapshot must not be found")
+	}
+}
+
+func TestClientRemoteSnapshotErrors(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cli.CreateRemoteSnapshot("", "wdc", ""); err != errEmptyUUID {
+		t.Errorf("empty drive uuid, error %v", err)
+	}
+	if _, err := cli.CreateRemoteSnapshot("uuid", "xyz", ""); err == nil {
+		t.Error("unknown location must fail")
+	}
+
+	_, err = cli.CreateRemoteSnapshot("a4e8c2c6-1c3b-4b8e-9c3e-2f0e7c1a9b11", "wdc", "")
+	if e, ok := err.(*Error); !ok || e.StatusCode != 400 {
+		t.Errorf("missing drive, error %v", err)
+	}
+
+	if err := cli.RemoveRemoteSnapshot(""); err != errEmptyUUID {
+		t.Errorf("empty uuid, error %v", err)
+	}
+}
@@ -39,6 +39,9 @@ type Server interface {
 	// CloudSigma resource
 	Resource
 
+	// ACLs returns access control lists the server instance is shared with
+	ACLs() []Resource
+
 	// Context serial device enabled for server instance
 	Context() bool
 
@@ -67,6 +70,9 @@ type Server interface {
 	// NICs for this server instance
 	NICs() []NIC
 
+	// PubKeys returns SSH key pairs attached to this server instance
+	PubKeys() []Resource
+
 	// Symmetric Multiprocessing (SMP) i.e. number of CPU cores
 	SMP() uint64
 
@@ -90,12 +96,33 @@ type Server interface {
 	// stop command is issued to the endpoint in case of any value cached in Status().
 	Stop() error
 
+	// Shutdown server instance by sending ACPI shutdown signal to the guest operating system.
+	// This method does not check current server status, guest may ignore the signal.
+	Shutdown() error
+
+	// Restart server instance. This method does not check current server status,
+	// restart command is issued to the endpoint in case of any value cached in Status().
+	Restart() error
+
 	// Start server instance and waits for status ServerRunning with timeout
 	StartWait() error
 
 	// Stop server instance and waits for status ServerStopped with timeout
 	StopWait() error
 
+	// Shutdown server instance and waits for status ServerStopped with given timeout.
+	// If guest operating system does not stop in time, server instance is stopped
+	// with Stop and waits for status ServerStopped with operation timeout.
+	// Non-positive timeout does not wait for the guest, the server is stopped
+	// with Stop right after the shutdown request.
+	ShutdownWait(timeout time.Duration) error
+
+	// Share server instance with access control list, given by uuid
+	Share(acl string) error
+
+	// Unshare server instance from access control list, given by uuid
+	Unshare(acl string) error
+
 	// Remove server instance
 	Remove(recurse string) error
 
@@ -126,6 +153,15 @@ func (s server) URI() string { return s.obj.URI }
 // UUID of server instance
 func (s server) UUID() string { return s.obj.UUID }
 
+// ACLs returns access control lists the server instance is shared with
+func (s server) ACLs() []Resource {
+	result := make([]Resource, 0, len(s.obj.ACLs))
+	for i := range s.obj.ACLs {
+		result = append(result, &resource{&s.obj.ACLs[i]})
+	}
+	return result
+}
+
 // Context serial device enabled for server instance
 func (s server) Context() bool { return s.obj.Context }
 
@@ -168,6 +204,15 @@ func (s server) NICs() []NIC {
 	return r
 }
 
+// PubKeys returns SSH key pairs attached to this server instance
+func (s server) PubKeys() []Resource {
+	result := make([]Resource, 0, len(s.obj.PubKeys))
+	for i := range s.obj.PubKeys {
+		result = append(result, &resource{&s.obj.PubKeys[i]})
+	}
+	return result
+}
+
 // Symmetric Multiprocessing (SMP) i.e. number of CPU cores
 func (s server) SMP() uint64 { return s.obj.SMP }
 
@@ -205,6 +250,18 @@ func (s server) Stop() error {
 	return s.client.stopServer(s.UUID())
 }
 
+// Shutdown server instance by sending ACPI shutdown signal to the guest operating system.
+// This method does not check current server status, guest may ignore the signal.
+func (s server) Shutdown() error {
+	return s.client.shutdownServer(s.UUID())
+}
+
+// Restart server instance. This method does not check current server status,
+// restart command is issued to the endpoint in case of any value cached in Status().
+func (s server) Restart() error {
+	return s.client.restartServer(s.UUID())
+}
+
 // Start server instance and waits for status ServerRunning with timeout
 func (s *server) StartWait() error {
 	if err := s.Start(); err != nil {
@@ -225,6 +282,61 @@ func (s *server) StopWait() error {
 	})
 }
 
+// Shutdown server instance and waits for status ServerStopped with given timeout.
+// If guest operating system does not stop in time, server instance is stopped
+// with Stop and waits for status ServerStopped with operation timeout.
+// Non-positive timeout does not wait for the guest, the server is stopped
+// with Stop right after the shutdown request.
+func (s *server) ShutdownWait(timeout time.Duration) error {
+	if err := s.Shutdown(); err != nil {
+		return err
+	}
+
+	stopped := func(srv Server) bool {
+		return srv.Status() == ServerStopped
+	}
+
+	// wait without timeout would never reach Stop for guest ignoring ACPI
+	if timeout > 0 {
+		err := s.wait(timeout, stopped)
+		if err != ErrOperationTimeout {
+			return err
+		}
+	}
+
+	if err := s.Stop(); err != nil {
+		return err
+	}
+
+	return s.Wait(stopped)
+}
+
+// Share server instance with access control list, given by uuid
+func (s *server) Share(acl string) error {
+	return s.share(acl, true)
+}
+
+// Unshare server instance from access control list, given by uuid
+func (s *server) Unshare(acl string) error {
+	return s.share(acl, false)
+}
+
+func (s *server) share(acl string, share bool) error {
+	// server object is sent back as a whole, so it must be complete
+	if s.CPU() == 0 {
+		if err := s.Refresh(); err != nil {
+			return err
+		}
+	}
+
+	obj, err := s.client.shareServer(*s.obj, acl, share)
+	if err != nil {
+		return err
+	}
+	s.obj = obj
+	return nil
+}
+
 // Remove server instance
 func (s server) Remove(recurse string) error {
 	return s.client.removeServer(s.UUID(), recurse)
@@ -232,20 +344,23 @@ func (s server) Remove(recurse string) error {
 
 // Wait for user-defined event
 func (s *server) Wait(stop func(srv Server) bool) error {
-	var timedout = false
+	return s.wait(s.client.GetOperationTimeout(), stop)
+}
 
-	timeout := s.client.GetOperationTimeout()
+func (s *server) wait(timeout time.Duration, stop func(srv Server) bool) error {
+	var timer <-chan time.Time
 	if timeout > 0 {
-		timer := time.AfterFunc(timeout, func() { timedout = true })
-		defer timer.Stop()
+		timer = time.After(timeout)
 	}
 
 	for !stop(s) {
-		if err := s.Refresh(); err != nil {
-			return err
-		}
-		if timedout {
+		select {
+		case <-timer:
 			return ErrOperationTimeout
+		default:
+			if err := s.Refresh(); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -5,6 +5,7 @@ package gosigma
 
 import (
 	"testing"
+	"time"
 
 	"github.com/altoros/gosigma/data"
 	"github.com/altoros/gosigma/mock"
@@ -284,13 +285,15 @@ func TestClientStartServerInvalidUUID(t *testing.T) {
 }
 
 func TestClientStartServer(t *testing.T) {
-	mock.ResetServers()
+	clock := mock.NewManualClock(time.Now())
+	srv := mock.New(mock.WithClock(clock))
+	defer srv.Close()
 
 	ds := newDataServer()
 	ds.Status = "stopped"
-	mock.AddServer(ds)
+	srv.AddServer(ds)
 
-	cli, err := createTestClient(t)
+	cli, err := createIsolatedTestClient(t, srv)
 	if err != nil {
 		t.Error(err)
 		return
@@ -307,19 +310,71 @@ func TestClientStartServer(t *testing.T) {
 		return
 	}
 
-	for i := 0; i < 10 && s.Status() != ServerRunning; i++ {
-		if err := s.Refresh(); err != nil {
-			t.Error(err)
-			return
-		}
+	if err := s.Refresh(); err != nil {
+		t.Error(err)
+		return
+	}
+	if s.Status() != ServerStarting {
+		t.Error("Server status must be starting")
 	}
 
+	clock.Advance(mock.DefaultTransitions.Start)
+
+	if err := s.Refresh(); err != nil {
+		t.Error(err)
+		return
+	}
 	if s.Status() != ServerRunning {
 		t.Error("Server status must be running")
 	}
 }
 
 func TestClientStopServer(t *testing.T) {
+	clock := mock.NewManualClock(time.Now())
+	srv := mock.New(mock.WithClock(clock))
+	defer srv.Close()
+
+	ds := newDataServer()
+	ds.Status = "running"
+	srv.AddServer(ds)
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	s, err := cli.Server("uuid")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := s.Refresh(); err != nil {
+		t.Error(err)
+		return
+	}
+	if s.Status() != ServerStopping {
+		t.Error("Server status must be stopping")
+	}
+
+	clock.Advance(mock.DefaultTransitions.Stop)
+
+	if err := s.Refresh(); err != nil {
+		t.Error(err)
+		return
+	}
+	if s.Status() != ServerStopped {
+		t.Error("Server status must be stopped")
+	}
+}
+
+func TestClientShutdownServer(t *testing.T) {
 	mock.ResetServers()
 
 	ds := newDataServer()
@@ -338,16 +393,59 @@ func TestClientStopServer(t *testing.T) {
 		return
 	}
 
-	if err := s.Stop(); err != nil {
+	if err := s.ShutdownWait(time.Second); err != nil {
 		t.Error(err)
 		return
 	}
 
-	for i := 0; i < 10 && s.Status() != ServerStopped; i++ {
-		if err := s.Refresh(); err != nil {
-			t.Error(err)
-			return
-		}
+	if s.Status() != ServerStopped {
+		t.Error("Server status must be stopped")
+	}
+
+	if err := s.Shutdown(); err == nil {
+		t.Error("Shutdown of stopped server must fail")
+	}
+}
+
+func TestClientShutdownServerFallback(t *testing.T) {
+	mock.ResetServers()
+
+	ds := newDataServer()
+	ds.Status = "running"
+	mock.AddServer(ds)
+	mock.IgnoreShutdown(ds.UUID, true)
+
+	cli, err := createTestClient(t)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	s, err := cli.Server("uuid")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := s.Shutdown(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := s.Refresh(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if s.Status() != ServerStopping {
+		t.Errorf("Server status %q, must be stopping", s.Status())
+	}
+
+	mock.SetServerStatus(ds.UUID, ServerRunning)
+
+	if err := s.ShutdownWait(100 * time.Millisecond); err != nil {
+		t.Error(err)
+		return
 	}
 
 	if s.Status() != ServerStopped {
@@ -355,15 +453,84 @@ func TestClientStopServer(t *testing.T) {
 	}
 }
 
-func TestClientCreateServer(t *testing.T) {
+func TestClientShutdownServerNoTimeout(t *testing.T) {
 	mock.ResetServers()
 
+	ds := newDataServer()
+	ds.Status = "running"
+	mock.AddServer(ds)
+	mock.IgnoreShutdown(ds.UUID, true)
+
 	cli, err := createTestClient(t)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 
+	s, err := cli.Server("uuid")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := s.ShutdownWait(0); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if s.Status() != ServerStopped {
+		t.Error("Server status must be stopped")
+	}
+}
+
+func TestClientRestartServer(t *testing.T) {
+	mock.ResetServers()
+
+	ds := newDataServer()
+	ds.Status = "running"
+	mock.AddServer(ds)
+
+	cli, err := createTestClient(t)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	s, err := cli.Server("uuid")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := s.Restart(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := s.Wait(func(srv Server) bool { return srv.Status() == ServerRunning }); err != nil {
+		t.Error(err)
+		return
+	}
+
+	mock.SetServerStatus(ds.UUID, ServerStopped)
+
+	if err := s.Restart(); err == nil {
+		t.Error("Restart of stopped server must fail")
+	}
+}
+
+func TestClientCreateServer(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("uuid"), Media: MediaDisk})
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
 	var c Components
 	c.SetName("test")
 	c.SetCPU(2000)
@@ -516,6 +683,192 @@ func TestClientCreateServer(t *testing.T) {
 	}
 }
 
+func TestClientCreateServerDriveValidation(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("disk"), Media: MediaDisk})
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("cdrom"), Media: MediaCdrom})
+	srv.AddServer(&data.Server{
+		Resource: *data.MakeServerResource("mounted"),
+		Drives: []data.ServerDrive{
+			{Channel: "0:0", Device: "virtio", Drive: *data.MakeDriveResource("disk")},
+		},
+	})
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	check := func(name string, attach func(c *Components)) {
+		var c Components
+		c.SetName(name)
+		c.SetCPU(2000)
+		c.SetMem(2147483648)
+		c.SetVNCPassword("test")
+		attach(&c)
+		if _, err := cli.CreateServer(c); err == nil {
+			t.Errorf("%s: CreateServer must fail", name)
+		} else if e, ok := err.(*Error); !ok || e.StatusCode != 400 {
+			t.Errorf("%s: invalid error %v", name, err)
+		}
+	}
+
+	check("missing", func(c *Components) { c.AttachDrive(1, "0:0", "virtio", "missing") })
+	check("mounted", func(c *Components) { c.AttachDrive(1, "0:0", "virtio", "disk") })
+	check("cdrom", func(c *Components) { c.AttachDrive(1, "0:0", "virtio", "cdrom") })
+	check("channel", func(c *Components) {
+		c.AttachDrive(1, "0:0", "ide", "cdrom")
+		c.AttachDrive(2, "0:0", "ide", "cdrom")
+	})
+
+	var c Components
+	c.SetName("valid")
+	c.SetCPU(2000)
+	c.SetMem(2147483648)
+	c.SetVNCPassword("test")
+	c.AttachDrive(1, "0:0", "ide", "cdrom")
+	if _, err := cli.CreateServer(c); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClientServerMountedOn(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("uuid"), Media: MediaDisk, AllowMultimount: true})
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var uuids []string
+	for i := 0; i < 2; i++ {
+		var c Components
+		c.SetName("test")
+		c.SetCPU(2000)
+		c.SetMem(2147483648)
+		c.SetVNCPassword("test")
+		c.AttachDrive(1, "0:0", "virtio", "uuid")
+		s, err := cli.CreateServer(c)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		uuids = append(uuids, s.UUID())
+	}
+
+	mountedOn := func() []string {
+		d, err := cli.Drive("uuid", LibraryAccount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result []string
+		for _, r := range d.MountedOn() {
+			result = append(result, r.UUID())
+		}
+		return result
+	}
+
+	if v := mountedOn(); len(v) != 2 || v[0] != uuids[0] || v[1] != uuids[1] {
+		t.Errorf("Drive.MountedOn: %v, wants %v", v, uuids)
+	}
+
+	if err := cli.RemoveServer(uuids[0], RecurseNothing); err != nil {
+		t.Error(err)
+	}
+
+	if v := mountedOn(); len(v) != 1 || v[0] != uuids[1] {
+		t.Errorf("Drive.MountedOn: %v, wants %v", v, uuids[1:])
+	}
+}
+
+func TestClientRemoveServerRecurse(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	check := func(recurse string, disk, cdrom bool) {
+		srv.Reset()
+		srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("disk"), Media: MediaDisk})
+		srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("cdrom"), Media: MediaCdrom})
+
+		var c Components
+		c.SetName("test")
+		c.SetCPU(2000)
+		c.SetMem(2147483648)
+		c.SetVNCPassword("test")
+		c.AttachDrive(1, "0:0", "virtio", "disk")
+		c.AttachDrive(2, "0:1", "ide", "cdrom")
+		s, err := cli.CreateServer(c)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if err := s.Remove(recurse); err != nil {
+			t.Errorf("recurse %q: %v", recurse, err)
+			return
+		}
+
+		if _, err := cli.Drive("disk", LibraryAccount); (err == nil) != disk {
+			t.Errorf("recurse %q: disk exists %v, wants %v", recurse, err == nil, disk)
+		}
+		if _, err := cli.Drive("cdrom", LibraryAccount); (err == nil) != cdrom {
+			t.Errorf("recurse %q: cdrom exists %v, wants %v", recurse, err == nil, cdrom)
+		}
+	}
+
+	check(RecurseNothing, true, true)
+	check(RecurseAllDrives, false, false)
+	check(RecurseDisks, false, true)
+	check(RecurseCDROMs, true, false)
+
+	if err := cli.RemoveServer("uuid", "invalid"); err == nil {
+		t.Error("RemoveServer with invalid recurse must fail")
+	}
+}
+
+func TestClientStartServerCloningDrive(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	srv.Drives.Add(&data.Drive{Resource: *data.MakeDriveResource("uuid"), Status: DriveCloningDst})
+	srv.AddServer(&data.Server{
+		Resource: *data.MakeServerResource("server"),
+		Status:   ServerStopped,
+		Drives: []data.ServerDrive{
+			{Channel: "0:0", Device: "virtio", Drive: *data.MakeDriveResource("uuid")},
+		},
+	})
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := cli.StartServer("server", nil); err == nil {
+		t.Error("start of server with cloning drive must fail")
+	}
+
+	srv.Drives.SetStatus("uuid", DriveUnmounted)
+
+	if err := cli.StartServer("server", nil); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestServerIPv4(t *testing.T) {
 	s := &server{obj: &data.Server{
 		NICs: []data.NIC{
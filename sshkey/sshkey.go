@@ -0,0 +1,143 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+// Package sshkey implements generation of SSH key pairs and handling of public
+// keys in OpenSSH authorized_keys format, without calling external tools.
+package sshkey
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// DefaultBits defines default size of generated RSA keys
+const DefaultBits = 2048
+
+// ErrInvalidPublicKey returned for public keys not in OpenSSH authorized_keys format
+var ErrInvalidPublicKey = errors.New("invalid public key")
+
+// A Key holds generated SSH key pair. Private key is not stored anywhere else,
+// so it must be saved by the caller.
+type Key struct {
+	// PublicKey in OpenSSH authorized_keys format
+	PublicKey string
+	// PrivateKey in PEM encoded PKCS#1 format
+	PrivateKey []byte
+	// Fingerprint of public key, MD5 hex digest separated with colons
+	Fingerprint string
+}
+
+// Generate returns new RSA key pair of given size in bits, zero value means
+// DefaultBits. Comment is appended to the public key, if not empty.
+func Generate(bits int, comment string) (*Key, error) {
+	if bits == 0 {
+		bits = DefaultBits
+	}
+
+	pk, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+
+	blob := marshalRSAPublicKey(&pk.PublicKey)
+
+	pub := "ssh-rsa " + base64.StdEncoding.EncodeToString(blob)
+	if comment = strings.TrimSpace(comment); comment != "" {
+		pub += " " + comment
+	}
+
+	key := &Key{
+		PublicKey: pub,
+		PrivateKey: pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(pk),
+		}),
+		Fingerprint: fingerprint(blob),
+	}
+
+	return key, nil
+}
+
+// Fingerprint returns MD5 fingerprint of public key in OpenSSH authorized_keys format
+func Fingerprint(publicKey string) (string, error) {
+	_, blob, err := parse(publicKey)
+	if err != nil {
+		return "", err
+	}
+	return fingerprint(blob), nil
+}
+
+// Type returns algorithm name of public key in OpenSSH authorized_keys format,
+// for example "ssh-rsa"
+func Type(publicKey string) (string, error) {
+	t, _, err := parse(publicKey)
+	return t, err
+}
+
+// parse returns key type and decoded key blob, checking the type written in
+// the blob matches the one in the text
+func parse(publicKey string) (string, []byte, error) {
+	ff := strings.Fields(publicKey)
+	if len(ff) < 2 {
+		return "", nil, ErrInvalidPublicKey
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(ff[1])
+	if err != nil {
+		return "", nil, ErrInvalidPublicKey
+	}
+
+	if len(blob) < 4 {
+		return "", nil, ErrInvalidPublicKey
+	}
+	n := binary.BigEndian.Uint32(blob)
+	if uint64(n) > uint64(len(blob)-4) || string(blob[4:4+n]) != ff[0] {
+		return "", nil, ErrInvalidPublicKey
+	}
+
+	return ff[0], blob, nil
+}
+
+func fingerprint(blob []byte) string {
+	sum := md5.Sum(blob)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// marshalRSAPublicKey encodes public key in SSH wire format, see RFC 4253 section 6.6
+func marshalRSAPublicKey(k *rsa.PublicKey) []byte {
+	var buf bytes.Buffer
+	writeString(&buf, []byte("ssh-rsa"))
+	writeString(&buf, mpint(big.NewInt(int64(k.E))))
+	writeString(&buf, mpint(k.N))
+	return buf.Bytes()
+}
+
+func writeString(buf *bytes.Buffer, s []byte) {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(s)))
+	buf.Write(n[:])
+	buf.Write(s)
+}
+
+// mpint returns bytes of positive integer in SSH mpint format, see RFC 4251 section 5
+func mpint(v *big.Int) []byte {
+	bb := v.Bytes()
+	if len(bb) > 0 && bb[0]&0x80 != 0 {
+		bb = append([]byte{0}, bb...)
+	}
+	return bb
+}
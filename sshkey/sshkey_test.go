@@ -0,0 +1,103 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package sshkey
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	key, err := Generate(1024, " test@example.com ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(key.PublicKey, "ssh-rsa ") || !strings.HasSuffix(key.PublicKey, " test@example.com") {
+		t.Errorf("invalid public key %q", key.PublicKey)
+	}
+
+	block, _ := pem.Decode(key.PrivateKey)
+	if block == nil || block.Type != "RSA PRIVATE KEY" {
+		t.Fatalf("invalid private key %q", key.PrivateKey)
+	}
+	pk, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := pk.N.BitLen(); n != 1024 {
+		t.Errorf("key size %d, wants 1024", n)
+	}
+
+	fp, err := Fingerprint(key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp != key.Fingerprint || len(fp) != 47 {
+		t.Errorf("invalid fingerprint %q, wants %q", fp, key.Fingerprint)
+	}
+
+	if v, err := Type(key.PublicKey); err != nil || v != "ssh-rsa" {
+		t.Errorf("invalid type %q, %v", v, err)
+	}
+}
+
+func TestPublicKeyInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"ssh-rsa",
+		"ssh-rsa !!!",
+		"ssh-rsa AAAA",
+		"ssh-dss AAAAB3NzaC1yc2EAAAADAQAB",
+	} {
+		if _, err := Fingerprint(s); err != ErrInvalidPublicKey {
+			t.Errorf("%q: invalid error %v", s, err)
+		}
+	}
+}
+
+func TestGenerateSSHKeygen(t *testing.T) {
+	path, err := exec.LookPath("ssh-keygen")
+	if err != nil {
+		t.Skip("ssh-keygen is not found")
+	}
+
+	key, err := Generate(1024, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "sshkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "id_rsa")
+	if err := ioutil.WriteFile(file, key.PrivateKey, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(path, "-y", "-f", file).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := strings.TrimSpace(string(out)); v != key.PublicKey {
+		t.Errorf("ssh-keygen public key %q, wants %q", v, key.PublicKey)
+	}
+
+	out, err = exec.Command(path, "-l", "-E", "md5", "-f", file).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := string(out); !strings.Contains(v, "MD5:"+key.Fingerprint) {
+		t.Errorf("ssh-keygen fingerprint %q, wants %q", v, key.Fingerprint)
+	}
+}
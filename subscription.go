@@ -0,0 +1,161 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+)
+
+const (
+	// SubscriptionActive defines constant for subscription covering current time
+	SubscriptionActive = "active"
+	// SubscriptionInactive defines constant for expired or not yet started subscription
+	SubscriptionInactive = "inactive"
+)
+
+const (
+	// PeriodMonth defines subscription period of one month
+	PeriodMonth = "1 month"
+	// PeriodYear defines subscription period of one year
+	PeriodYear = "1 year"
+)
+
+// A Subscription interface represents subscription to billable resource in
+// CloudSigma account. Subscriptions to IP addresses and VLANs provide the
+// subscribed objects, which can be used in Components.NetworkStatic4 and
+// Components.NetworkVLan.
+type Subscription interface {
+	// ID of subscription
+	ID() string
+
+	// URI of subscription
+	URI() string
+
+	// ResourceType returns billable resource of subscription, see Billing*
+	// constants, or name of software license
+	ResourceType() string
+
+	// Amount of subscribed resource in its units
+	Amount() uint64
+
+	// Period of subscription, e.g. "1 month"
+	Period() string
+
+	// Start time of subscription
+	Start() time.Time
+
+	// End time of subscription
+	End() time.Time
+
+	// AutoRenew reports whether subscription is renewed at its end time
+	AutoRenew() bool
+
+	// Status of subscription
+	Status() string
+
+	// SubscribedObject returns IP address or UUID of VLAN, provided by subscription
+	SubscribedObject() string
+
+	// Refresh information about subscription
+	Refresh() error
+
+	// Extend subscription by given period
+	Extend(period string) error
+
+	// SetAutoRenew enables or disables auto-renewal of subscription
+	SetAutoRenew(autoRenew bool) error
+}
+
+// A subscription implements subscription to billable resource in CloudSigma account
+type subscription struct {
+	client *Client
+	obj    *data.Subscription
+}
+
+var _ Subscription = (*subscription)(nil)
+
+// String method is used to print values passed as an operand to any format that
+// accepts a string or to an unformatted printer such as Print.
+func (s subscription) String() string {
+	return fmt.Sprintf(`{ID: %q, Resource: %q, Amount: %d, Period: %q, Status: %q}`,
+		s.ID(), s.ResourceType(), s.Amount(), s.Period(), s.Status())
+}
+
+// ID of subscription
+func (s subscription) ID() string { return s.obj.ID }
+
+// URI of subscription
+func (s subscription) URI() string { return s.obj.URI }
+
+// ResourceType returns billable resource of subscription, see Billing*
+// constants, or name of software license
+func (s subscription) ResourceType() string { return s.obj.Resource }
+
+// Amount of subscribed resource in its units
+func (s subscription) Amount() uint64 {
+	amount, _ := strconv.ParseUint(string(s.obj.Amount), 10, 64)
+	return amount
+}
+
+// Period of subscription, e.g. "1 month"
+func (s subscription) Period() string { return s.obj.Period }
+
+// Start time of subscription
+func (s subscription) Start() time.Time {
+	if s.obj.StartTime == nil {
+		return time.Time{}
+	}
+	return *s.obj.StartTime
+}
+
+// End time of subscription
+func (s subscription) End() time.Time {
+	if s.obj.EndTime == nil {
+		return time.Time{}
+	}
+	return *s.obj.EndTime
+}
+
+// AutoRenew reports whether subscription is renewed at its end time
+func (s subscription) AutoRenew() bool { return s.obj.AutoRenew }
+
+// Status of subscription
+func (s subscription) Status() string { return s.obj.Status }
+
+// SubscribedObject returns IP address or UUID of VLAN, provided by subscription
+func (s subscription) SubscribedObject() string { return s.obj.SubscribedObject }
+
+// Refresh information about subscription
+func (s *subscription) Refresh() error {
+	obj, err := s.client.getSubscription(s.ID())
+	if err != nil {
+		return err
+	}
+	s.obj = obj
+	return nil
+}
+
+// Extend subscription by given period
+func (s *subscription) Extend(period string) error {
+	obj, err := s.client.extendSubscription(s.ID(), period)
+	if err != nil {
+		return err
+	}
+	s.obj = obj
+	return nil
+}
+
+// SetAutoRenew enables or disables auto-renewal of subscription
+func (s *subscription) SetAutoRenew(autoRenew bool) error {
+	obj, err := s.client.setSubscriptionAutoRenew(s.ID(), autoRenew)
+	if err != nil {
+		return err
+	}
+	s.obj = obj
+	return nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"testing"
+	"time"
+
+	"github.com/altoros/gosigma/data"
+	"github.com/altoros/gosigma/mock"
+)
+
+func TestClientSubscriptions(t *testing.T) {
+	now := time.Date(2014, 2, 10, 12, 0, 0, 0, time.UTC)
+	srv := mock.New(mock.WithClock(mock.NewManualClock(now)))
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := cli.CreateSubscription(BillingVLan, 1, PeriodMonth, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID() == "" || s.ResourceType() != BillingVLan || s.Amount() != 1 || s.Period() != PeriodMonth || !s.AutoRenew() {
+		t.Errorf("invalid subscription %v", s)
+	}
+	if s.Status() != SubscriptionActive || !s.Start().Equal(now) || !s.End().Equal(now.AddDate(0, 1, 0)) {
+		t.Errorf("invalid subscription status %v, %v - %v", s, s.Start(), s.End())
+	}
+	if s.SubscribedObject() == "" {
+		t.Error("VLAN subscription must provide subscribed object")
+	}
+
+	var c Components
+	c.SetName("test")
+	c.SetCPU(2000)
+	c.SetMem(2147483648)
+	c.SetVNCPassword("test")
+	c.NetworkVLan(ModelVirtio, s.SubscribedObject())
+	if _, err := cli.CreateServer(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Extend(PeriodYear); err != nil {
+		t.Fatal(err)
+	}
+	if !s.End().Equal(now.AddDate(1, 1, 0)) {
+		t.Errorf("Subscription.Extend: end time %v", s.End())
+	}
+
+	if err := s.SetAutoRenew(false); err != nil {
+		t.Fatal(err)
+	}
+	if s.AutoRenew() {
+		t.Error("Subscription.SetAutoRenew: auto-renewal is not disabled")
+	}
+
+	ip, err := cli.CreateSubscription(BillingIP, 1, "30 days", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip.SubscribedObject() == "" || !ip.End().Equal(now.AddDate(0, 0, 30)) {
+		t.Errorf("invalid IP subscription %v", ip)
+	}
+
+	ss, err := cli.Subscriptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 2 || ss[0].ID() != s.ID() || ss[1].ID() != ip.ID() {
+		t.Errorf("Client.Subscriptions: %v", ss)
+	}
+
+	s, err = cli.Subscription(s.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.AutoRenew() || !s.End().Equal(now.AddDate(1, 1, 0)) {
+		t.Errorf("Client.Subscription: %v", s)
+	}
+
+	if _, err := cli.ExtendSubscription("missing", PeriodMonth); err == nil {
+		t.Error("ExtendSubscription of missing subscription must fail")
+	} else if e, ok := err.(*Error); !ok || e.StatusCode != 404 {
+		t.Errorf("invalid error %v", err)
+	}
+
+	if _, err := cli.SetSubscriptionAutoRenew(" ", true); err != errEmptyID {
+		t.Errorf("SetSubscriptionAutoRenew with empty id: %v", err)
+	}
+}
+
+func TestClientSubscriptionValidation(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(resource string, amount uint64, period string) {
+		if _, err := cli.CreateSubscription(resource, amount, period, true); err == nil {
+			t.Errorf("%q %d %q: CreateSubscription must fail", resource, amount, period)
+		} else if e, ok := err.(*Error); !ok || e.StatusCode != 400 {
+			t.Errorf("%q %d %q: invalid error %v", resource, amount, period, err)
+		}
+	}
+
+	check("", 1, PeriodMonth)
+	check(BillingCPU, 0, PeriodMonth)
+	check(BillingIP, 2, PeriodMonth)
+	check(BillingCPU, 1000, "forever")
+}
+
+func TestClientSubscriptionUsage(t *testing.T) {
+	now := time.Date(2014, 2, 10, 12, 0, 0, 0, time.UTC)
+	clock := mock.NewManualClock(now)
+	srv := mock.New(mock.WithClock(clock))
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.AddServer(&data.Server{Resource: *data.MakeServerResource("running"), CPU: 2000, Mem: 1073741824, Status: "running"})
+
+	if _, err := cli.CreateSubscription(BillingCPU, 1500, "1 day", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.CreateSubscription(BillingIP, 1, PeriodMonth, false); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := cli.CurrentUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := u.Usage[BillingCPU]; v != (Usage{Burst: 500, Subscribed: 1500, Using: 2000}) {
+		t.Errorf("cpu usage %#v", v)
+	}
+	if v := u.Usage[BillingIP]; v != (Usage{Subscribed: 1, Using: 1}) {
+		t.Errorf("ip usage %#v", v)
+	}
+
+	clock.Advance(48 * time.Hour)
+
+	u, err = cli.CurrentUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := u.Usage[BillingCPU]; v != (Usage{Burst: 2000, Using: 2000}) {
+		t.Errorf("cpu usage after subscription end %#v", v)
+	}
+
+	ss, err := cli.Subscriptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 2 || ss[0].Status() != SubscriptionInactive || ss[1].Status() != SubscriptionActive {
+		t.Errorf("invalid subscription statuses %v", ss)
+	}
+}
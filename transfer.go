@@ -0,0 +1,150 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultChunkSize defines size of chunks drive images are transferred with
+const DefaultChunkSize = 5 * 1024 * 1024
+
+const (
+	defaultTransferRetries  = 3
+	defaultTransferDelay    = time.Second
+	defaultTransferParallel = 4
+)
+
+// A ProgressFunc receives number of transferred bytes of drive image and its
+// total size. It is called after every transferred chunk, calls are not
+// concurrent.
+type ProgressFunc func(done, total int64)
+
+// A TransferOption configures transfer of drive image
+type TransferOption func(*transfer)
+
+// transfer holds drive image transfer configuration collected from
+// TransferOption values
+type transfer struct {
+	chunkSize int64
+	retries   int
+	delay     time.Duration
+	parallel  int
+	progress  ProgressFunc
+
+	mu   sync.Mutex
+	done int64
+}
+
+// TransferChunkSize returns TransferOption setting size of chunks the image
+// is transferred with, DefaultChunkSize is used by default
+func TransferChunkSize(size int64) TransferOption {
+	return func(t *transfer) {
+		if size > 0 {
+			t.chunkSize = size
+		}
+	}
+}
+
+// TransferRetries returns TransferOption setting number of retries of failed
+// chunk and delay between them. By default chunk is retried three times with
+// one second delay.
+func TransferRetries(retries int, delay time.Duration) TransferOption {
+	return func(t *transfer) {
+		t.retries = retries
+		t.delay = delay
+	}
+}
+
+// TransferParallel returns TransferOption setting number of chunks downloaded
+// in parallel, four by default. Uploads always send chunks one by one.
+func TransferParallel(n int) TransferOption {
+	return func(t *transfer) {
+		if n > 0 {
+			t.parallel = n
+		}
+	}
+}
+
+// TransferProgress returns TransferOption setting function receiving progress
+// of the transfer
+func TransferProgress(f ProgressFunc) TransferOption {
+	return func(t *transfer) { t.progress = f }
+}
+
+func newTransfer(opts []TransferOption) *transfer {
+	t := &transfer{
+		chunkSize: DefaultChunkSize,
+		retries:   defaultTransferRetries,
+		delay:     defaultTransferDelay,
+		parallel:  defaultTransferParallel,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// chunks returns number of chunks of image with given size
+func (t *transfer) chunks(size int64) int64 {
+	return (size + t.chunkSize - 1) / t.chunkSize
+}
+
+// chunk returns offset and length of chunk with given zero-based index
+func (t *transfer) chunk(size, index int64) (int64, int64) {
+	offset := index * t.chunkSize
+	if n := size - offset; n < t.chunkSize {
+		return offset, n
+	}
+	return offset, t.chunkSize
+}
+
+// report adds transferred bytes and reports progress of the transfer
+func (t *transfer) report(n, total int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.done += n
+	if t.progress != nil {
+		t.progress(t.done, total)
+	}
+}
+
+// retry calls f until it succeeds, fails permanently, the context is done or
+// retries are exhausted
+func (t *transfer) retry(ctx context.Context, f func() error) error {
+	for i := 0; ; i++ {
+		err := f()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if i >= t.retries || !isTransient(err) {
+			return err
+		}
+		timer := time.NewTimer(t.delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isTransient reports whether failed chunk transfer can succeed when retried.
+// Client errors of the endpoint are permanent, except of chunk checksum mismatch.
+func isTransient(err error) bool {
+	e, ok := err.(*Error)
+	if !ok || e.StatusCode < 400 || e.StatusCode >= 500 {
+		return true
+	}
+	if e.StatusCode == 408 || e.StatusCode == 429 {
+		return true
+	}
+	return e.ServiceError != nil && e.ServiceError.Point == "Content-MD5"
+}
@@ -0,0 +1,150 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/altoros/gosigma/data"
+)
+
+var errEmptyName = errors.New("name is not allowed to be empty")
+var errInvalidSize = errors.New("size must be positive")
+
+// uploadIdentifierRegexp matches characters stripped from upload identifier
+var uploadIdentifierRegexp = regexp.MustCompile(`[^0-9a-zA-Z_-]`)
+
+// uploadIdentifier returns identifier of resumable upload of the image. Upload
+// of image with the same name and size is resumed by the endpoint.
+func uploadIdentifier(name string, size int64) string {
+	return strconv.FormatInt(size, 10) + "-" + uploadIdentifierRegexp.ReplaceAllString(name, "")
+}
+
+// chunkETag returns checksum of chunk reported by the endpoint
+func chunkETag(r *http.Response) string {
+	etag := r.Header.Get("ETag")
+	if s, err := strconv.Unquote(etag); err == nil {
+		return s
+	}
+	return etag
+}
+
+func (c *Client) uploadDrive(ctx context.Context, name string, rd io.Reader, size int64, t *transfer) (*data.Drive, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errEmptyName
+	}
+	if size <= 0 {
+		return nil, errInvalidSize
+	}
+
+	u := c.direct + "drives/upload/"
+	chunks := t.chunks(size)
+
+	qq := url.Values{
+		"resumableChunkSize":   {strconv.FormatInt(t.chunkSize, 10)},
+		"resumableTotalSize":   {strconv.FormatInt(size, 10)},
+		"resumableTotalChunks": {strconv.FormatInt(chunks, 10)},
+		"resumableIdentifier":  {uploadIdentifier(name, size)},
+		"resumableFilename":    {name},
+	}
+
+	buf := make([]byte, t.chunkSize)
+	for i := int64(0); i < chunks; i++ {
+		_, n := t.chunk(size, i)
+		chunk := buf[:n]
+		if _, err := io.ReadFull(rd, chunk); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil, fmt.Errorf("image is shorter than %d bytes", size)
+			}
+			return nil, err
+		}
+
+		q := make(url.Values, len(qq)+2)
+		for k, v := range qq {
+			q[k] = v
+		}
+		q.Set("resumableChunkNumber", strconv.FormatInt(i+1, 10))
+		q.Set("resumableCurrentChunkSize", strconv.FormatInt(n, 10))
+
+		last := i == chunks-1
+
+		var obj *data.Drive
+		err := t.retry(ctx, func() (err error) {
+			obj, err = c.uploadChunk(ctx, u, q, chunk, last)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		t.report(n, size)
+
+		if last {
+			return obj, nil
+		}
+	}
+
+	return nil, errors.New("no object was returned from server")
+}
+
+// uploadChunk sends chunk of the image, unless the endpoint already holds it.
+// Drive created from the image is returned for the last chunk.
+func (c *Client) uploadChunk(ctx context.Context, u string, qq url.Values, chunk []byte, last bool) (*data.Drive, error) {
+	sum := md5.Sum(chunk)
+	etag := hex.EncodeToString(sum[:])
+
+	// the last chunk is always sent, to receive the drive in reply
+	if !last {
+		r, err := c.https.Do(ctx, "GET", u, qq, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		r.Body.Close()
+		if r.StatusCode == 200 && chunkETag(r.Response) == etag {
+			return nil, nil
+		}
+	}
+
+	h := http.Header{
+		"Content-Type": {"application/octet-stream"},
+		"Content-MD5":  {base64.StdEncoding.EncodeToString(sum[:])},
+	}
+	r, err := c.https.Do(ctx, "POST", u, qq, h, bytes.NewReader(chunk))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if last {
+		err = r.VerifyJSON(201)
+	} else {
+		err = r.VerifyCode(200)
+	}
+	if err != nil {
+		return nil, NewError(r, err)
+	}
+
+	if chunkETag(r.Response) != etag {
+		return nil, fmt.Errorf("checksum of chunk %s does not match", qq.Get("resumableChunkNumber"))
+	}
+
+	if !last {
+		return nil, nil
+	}
+
+	return data.ReadDrive(r.Body)
+}
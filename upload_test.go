@@ -0,0 +1,222 @@
+// Copyright 2014 ALTOROS
+// Licensed under the AGPLv3, see LICENSE file for details.
+
+package gosigma
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/altoros/gosigma/mock"
+)
+
+// testImage returns drive image of given size with non-repeating chunks
+func testImage(size int) []byte {
+	bb := make([]byte, size)
+	for i := range bb {
+		bb[i] = byte(i*7 + i/251)
+	}
+	return bb
+}
+
+// countUploads returns number of chunks posted to the server
+func countUploads(srv *mock.Server) int {
+	n := 0
+	for _, e := range srv.GetSession() {
+		if e.Name == "drives/upload" && e.Request.Method == "POST" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestClientUploadDrive(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	image := testImage(10000)
+
+	var progress []int64
+	d, err := cli.UploadDrive(context.Background(), "install.iso", MediaCdrom, bytes.NewReader(image), int64(len(image)),
+		TransferChunkSize(4096),
+		TransferProgress(func(done, total int64) {
+			if total != int64(len(image)) {
+				t.Errorf("invalid total %d", total)
+			}
+			progress = append(progress, done)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Name() != "install.iso" || d.Size() != uint64(len(image)) || d.Media() != MediaCdrom {
+		t.Errorf("invalid uploaded drive %v", d)
+	}
+	if len(progress) != 3 || progress[0] != 4096 || progress[1] != 8192 || progress[2] != 10000 {
+		t.Errorf("invalid progress %v", progress)
+	}
+	if n := countUploads(srv); n != 3 {
+		t.Errorf("%d chunks uploaded, wants 3", n)
+	}
+
+	bb, ok := srv.DriveImage(d.UUID())
+	if !ok || !bytes.Equal(bb, image) {
+		t.Error("uploaded image does not match")
+	}
+
+	if _, err := cli.Drive(d.UUID(), LibraryAccount); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClientUploadDriveRetry(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.AddFault(mock.Fault{Method: "POST", Path: "drives/upload/", Code: 503, Count: 1})
+	srv.AddFault(mock.Fault{Method: "POST", Path: "drives/upload/", EveryNth: 2, Drop: true, Count: 1})
+
+	image := testImage(3000)
+	d, err := cli.UploadDrive(context.Background(), "image", "", bytes.NewReader(image), int64(len(image)),
+		TransferChunkSize(1024), TransferRetries(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Media() != MediaDisk {
+		t.Errorf("invalid media %q", d.Media())
+	}
+
+	bb, ok := srv.DriveImage(d.UUID())
+	if !ok || !bytes.Equal(bb, image) {
+		t.Error("uploaded image does not match")
+	}
+}
+
+func TestClientUploadDriveResume(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.AddFault(mock.Fault{Method: "POST", Path: "drives/upload/", EveryNth: 3, Code: 500, Count: 1})
+
+	image := testImage(3000)
+	_, err = cli.UploadDrive(context.Background(), "image", "", bytes.NewReader(image), int64(len(image)),
+		TransferChunkSize(1024), TransferRetries(0, 0))
+	if e, ok := err.(*Error); !ok || e.StatusCode != 500 {
+		t.Fatalf("invalid error %v", err)
+	}
+	if n := countUploads(srv); n != 3 {
+		t.Errorf("%d chunks uploaded, wants 3", n)
+	}
+
+	var progress []int64
+	d, err := cli.UploadDrive(context.Background(), "image", "", bytes.NewReader(image), int64(len(image)),
+		TransferChunkSize(1024), TransferProgress(func(done, total int64) { progress = append(progress, done) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countUploads(srv); n != 4 {
+		t.Errorf("%d chunks uploaded, wants 4", n)
+	}
+	if len(progress) != 3 || progress[2] != 3000 {
+		t.Errorf("invalid progress %v", progress)
+	}
+
+	bb, ok := srv.DriveImage(d.UUID())
+	if !ok || !bytes.Equal(bb, image) {
+		t.Error("uploaded image does not match")
+	}
+}
+
+func TestClientUploadDriveSameName(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upload := func(image []byte) Drive {
+		d, err := cli.UploadDrive(context.Background(), "image", "", bytes.NewReader(image), int64(len(image)),
+			TransferChunkSize(1024))
+		if err != nil {
+			t.Fatal(err)
+		}
+		bb, ok := srv.DriveImage(d.UUID())
+		if !ok || !bytes.Equal(bb, image) {
+			t.Error("uploaded image does not match")
+		}
+		return d
+	}
+
+	// images differ only in the last chunk
+	image := testImage(3000)
+	first := upload(image)
+	image = append([]byte(nil), image...)
+	image[2999]++
+	second := upload(image)
+
+	if first.UUID() == second.UUID() {
+		t.Error("different image with the same name and size must create new drive")
+	}
+	if n := countUploads(srv); n != 6 {
+		t.Errorf("%d chunks uploaded, wants 6", n)
+	}
+}
+
+func TestClientUploadDriveErrors(t *testing.T) {
+	srv := mock.New()
+	defer srv.Close()
+
+	cli, err := createIsolatedTestClient(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	image := testImage(100)
+
+	if _, err := cli.UploadDrive(ctx, " ", "", bytes.NewReader(image), 100); err != errEmptyName {
+		t.Errorf("invalid error %v", err)
+	}
+	if _, err := cli.UploadDrive(ctx, "image", "", bytes.NewReader(image), 0); err != errInvalidSize {
+		t.Errorf("invalid error %v", err)
+	}
+	if _, err := cli.UploadDrive(ctx, "image", "", bytes.NewReader(image), 200); err == nil {
+		t.Error("short image must fail")
+	}
+	if _, err := cli.UploadDrive(ctx, "image", "floppy", bytes.NewReader(image), 100); err == nil {
+		t.Error("invalid media must fail")
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := cli.UploadDrive(canceled, "image", "", bytes.NewReader(image), 100); err != context.Canceled {
+		t.Errorf("invalid error %v", err)
+	}
+
+	srv.SetLimits(mock.Limits{DriveSize: map[string]uint64{"dssd": 50}})
+	_, err = cli.UploadDrive(ctx, "quota", "", bytes.NewReader(image), 100, TransferRetries(3, 0))
+	if e, ok := err.(*Error); !ok || e.StatusCode != 402 {
+		t.Errorf("invalid error %v", err)
+	}
+	if n := countUploads(srv); n != 2 {
+		t.Errorf("quota error must not be retried, %d chunks uploaded", n)
+	}
+}